@@ -7,11 +7,9 @@ import (
 	"github.com/kolesa-team/scylla-octopus/pkg/entity"
 )
 
-const metadataFilename = "metadata.yml"
-
 // adds metadata to a backup, so it helps us with restoration in future versions
 func (s *Service) writeMetadata(ctx context.Context, cmd cmd.Executor, host string, metadata entity.BackupMetadata) error {
-	targetPath := s.options.LocalPath + "/" + metadataFilename
+	targetPath := s.options.LocalPath + "/" + entity.BackupMetadataFilename
 	err := cmd.WriteFile(ctx, targetPath, metadata.Bytes())
 
 	if err != nil {
@@ -40,6 +40,8 @@ type Options struct {
 	CleanupRemote bool `yaml:"cleanupRemote"`
 	// How long should the backups live in remote storage
 	Retention time.Duration
+	// The maximum age of the newest backup of every node (checked by `backup check-freshness`)
+	MaxAge time.Duration `yaml:"maxAge"`
 	// Settings for compress backup
 	Archive entity.Archive
 }
@@ -144,6 +146,13 @@ func (s *Service) Backup(ctx context.Context, node *entity.Node) entity.BackupRe
 		return result
 	}
 
+	var err error
+	result.Size, err = cmd.DirectorySize(ctx, node.Cmd, s.options.LocalPath)
+	if err != nil {
+		// the size is only informational
+		s.logger.Warnw("could not get the size of a backup", "host", node.Info.Host, "error", err)
+	}
+
 	if ctx.Err() != nil {
 		result.Error = ctx.Err()
 		return result
@@ -2,13 +2,21 @@ package app
 
 import (
 	"context"
+	"fmt"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd"
 	"github.com/kolesa-team/scylla-octopus/pkg/entity"
+	"github.com/kolesa-team/scylla-octopus/pkg/notifier"
+	"github.com/pkg/errors"
+	"path"
+	"sort"
+	"strings"
 	"time"
 )
 
 // Backup backs up every cluster node
 func (m *Octopus) Backup(ctx context.Context) entity.BackupResults {
-	results := m.cluster.RunParallel(ctx, func(ctx context.Context, node *entity.Node) entity.NodeCallbackResult {
+	dateStarted := time.Now()
+	results := m.cluster.RunLimited(ctx, m.parallelism.Backup, func(ctx context.Context, node *entity.Node) entity.NodeCallbackResult {
 		backupResult := m.backup.Backup(ctx, node)
 		if backupResult.Error != nil {
 			return entity.CallbackErrorWithValue(backupResult.Error, backupResult)
@@ -31,27 +39,38 @@ func (m *Octopus) Backup(ctx context.Context) entity.BackupResults {
 		}
 	}
 
+	run := entity.NewOperationRun(string(notifier.OperationBackup), dateStarted, m.cluster.Size(), results)
+	for i, hostRun := range run.Hosts {
+		run.Hosts[i].Bytes = backupResults.ByHost[hostRun.Host].Size
+	}
+	m.saveRun(run)
+
+	msg := notifier.Message{
+		Operation: notifier.OperationBackup,
+		Severity:  notifier.SeverityInfo,
+		Header:    "Backup completed successfully",
+		Body:      backupResults.Report(),
+		Result:    backupResults,
+	}
+
 	if backupResults.Error != nil {
-		m.notifier.Error(
-			"Could not back up cluster nodes",
-			backupResults.Report(),
-			backupResults.Error,
-			nil,
-		)
-	} else {
-		m.notifier.Info(
-			"Backup completed successfully",
-			backupResults.Report(),
-			nil,
-		)
+		msg.Severity = notifier.SeverityError
+		msg.Header = "Could not back up cluster nodes"
+		msg.Err = backupResults.Error
+	} else if cleanupErr := backupResults.CleanupErrors(); cleanupErr != nil {
+		msg.Severity = notifier.SeverityWarning
+		msg.Header = "Backup completed, but the cleanup failed"
+		msg.Err = cleanupErr
 	}
 
+	m.notifier.Notify(msg)
+
 	return backupResults
 }
 
 // ListSnapshots lists snapshots on every node in the cluser
 func (m *Octopus) ListSnapshots(ctx context.Context) (entity.SnapshotsByNode, error) {
-	results := m.cluster.RunParallel(ctx, func(ctx context.Context, node *entity.Node) entity.NodeCallbackResult {
+	results := m.cluster.RunLimited(ctx, m.parallelism.List, func(ctx context.Context, node *entity.Node) entity.NodeCallbackResult {
 		snapshots, err := m.scylla.ListSnapshots(ctx, node)
 		return entity.NodeCallbackResult{
 			Value: snapshots,
@@ -73,7 +92,7 @@ func (m *Octopus) ListSnapshots(ctx context.Context) (entity.SnapshotsByNode, er
 // CleanupExpiredBackups removes expired backups in remote storage
 func (m *Octopus) CleanupExpiredBackups(ctx context.Context) (entity.RemoteBackupsByHost, error) {
 	now := time.Now()
-	results := m.cluster.RunParallel(ctx, func(ctx context.Context, node *entity.Node) entity.NodeCallbackResult {
+	results := m.cluster.RunLimited(ctx, m.parallelism.CleanupExpired, func(ctx context.Context, node *entity.Node) entity.NodeCallbackResult {
 		expiredBackups, err := m.backup.CleanupExpiredBackups(ctx, node, now)
 		if err != nil {
 			return entity.CallbackError(err)
@@ -83,19 +102,42 @@ func (m *Octopus) CleanupExpiredBackups(ctx context.Context) (entity.RemoteBacku
 	})
 
 	cleanupResults := entity.RemoteBackupsByHost{}
+	removedCount := 0
 	for _, result := range results {
 		if result.Err == nil {
 			cleanupResults[result.Host] = result.Value.([]entity.RemoteBackup)
+			removedCount += len(cleanupResults[result.Host])
 		}
 	}
 
-	return cleanupResults, results.Error()
+	m.saveRun(entity.NewOperationRun(string(notifier.OperationCleanup), now, m.cluster.Size(), results))
+
+	err := results.Error()
+	if err != nil {
+		m.notifier.Notify(notifier.Message{
+			Operation: notifier.OperationCleanup,
+			Severity:  notifier.SeverityError,
+			Header:    "Could not remove expired backups",
+			Err:       err,
+			Result:    cleanupResults,
+		})
+	} else {
+		m.notifier.Notify(notifier.Message{
+			Operation: notifier.OperationCleanup,
+			Severity:  notifier.SeverityInfo,
+			Header:    "Expired backups removed",
+			Body:      fmt.Sprintf("Removed backups: %d", removedCount),
+			Result:    cleanupResults,
+		})
+	}
+
+	return cleanupResults, err
 }
 
 // ListExpiredBackups returns a list of expired backups in remote storage
 func (m *Octopus) ListExpiredBackups(ctx context.Context) (entity.RemoteBackupsByHost, error) {
 	now := time.Now()
-	results := m.cluster.RunParallel(ctx, func(ctx context.Context, node *entity.Node) entity.NodeCallbackResult {
+	results := m.cluster.RunLimited(ctx, m.parallelism.List, func(ctx context.Context, node *entity.Node) entity.NodeCallbackResult {
 		expiredBackups, err := m.backup.ListExpiredBackups(ctx, node, now)
 		if err != nil {
 			return entity.CallbackError(err)
@@ -116,7 +158,7 @@ func (m *Octopus) ListExpiredBackups(ctx context.Context) (entity.RemoteBackupsB
 
 // ListBackups returns a list of all backups in remote storage
 func (m *Octopus) ListBackups(ctx context.Context) (entity.RemoteBackupsByHost, error) {
-	results := m.cluster.RunParallel(ctx, func(ctx context.Context, node *entity.Node) entity.NodeCallbackResult {
+	results := m.cluster.RunLimited(ctx, m.parallelism.List, func(ctx context.Context, node *entity.Node) entity.NodeCallbackResult {
 		backups, err := m.storage.ListBackups(ctx, node.Cmd, node.Info.RemoteStoragePath())
 		if err != nil {
 			return entity.CallbackError(err)
@@ -134,3 +176,212 @@ func (m *Octopus) ListBackups(ctx context.Context) (entity.RemoteBackupsByHost,
 
 	return expiredBackups, results.Error()
 }
+
+// CheckBackupFreshness checks that the newest backup of every node in remote storage is not older than a given maximum age.
+// A node without backups is stale as well.
+func (m *Octopus) CheckBackupFreshness(ctx context.Context, now time.Time, maxAge time.Duration) entity.BackupFreshnessResults {
+	results := m.cluster.RunLimited(ctx, m.parallelism.List, func(ctx context.Context, node *entity.Node) entity.NodeCallbackResult {
+		backups, err := m.storage.ListBackups(ctx, node.Cmd, node.Info.RemoteStoragePath())
+		if err != nil {
+			return entity.CallbackError(err)
+		}
+
+		return entity.CallbackOk(backups)
+	})
+
+	freshnessResults := entity.BackupFreshnessResults{
+		MaxAge:     maxAge,
+		TotalNodes: m.cluster.Size(),
+		ByHost:     map[string]entity.BackupFreshness{},
+	}
+
+	for _, result := range results {
+		if result.Err != nil {
+			freshnessResults.ByHost[result.Host] = entity.BackupFreshness{Stale: true, Error: result.Err}
+			continue
+		}
+
+		freshness := entity.NewBackupFreshness(result.Value.([]entity.RemoteBackup), now, maxAge)
+		freshnessResults.ByHost[result.Host] = freshness
+
+		if !freshness.Stale {
+			freshnessResults.FreshNodes++
+		}
+	}
+
+	freshnessResults.Error = freshnessResults.Errors()
+
+	msg := notifier.Message{
+		Operation: notifier.OperationFreshness,
+		Severity:  notifier.SeverityInfo,
+		Header:    "All nodes have fresh backups",
+		Body:      freshnessResults.Report(),
+		Result:    freshnessResults,
+	}
+
+	if freshnessResults.Error != nil {
+		msg.Severity = notifier.SeverityError
+		msg.Header = "Backups are missing or stale"
+		msg.Err = freshnessResults.Error
+	}
+
+	m.notifier.Notify(msg)
+
+	return freshnessResults
+}
+
+// DescribeBackup returns the contents of a backup of a given host in remote storage.
+// The date is the name of a backup directory (e.g. "09-07-2021-10-29"), or "latest" for the newest backup.
+func (m *Octopus) DescribeBackup(ctx context.Context, host, date string) (entity.BackupDescription, error) {
+	value, err := m.runOnHost(ctx, host, func(ctx context.Context, node *entity.Node) entity.NodeCallbackResult {
+		description, err := m.describeBackup(ctx, node, date)
+		if err != nil {
+			return entity.CallbackError(err)
+		}
+
+		return entity.CallbackOk(description)
+	})
+	if err != nil {
+		return entity.BackupDescription{}, err
+	}
+
+	return value.(entity.BackupDescription), nil
+}
+
+// reads the files, metadata and schema of a node backup
+func (m *Octopus) describeBackup(ctx context.Context, node *entity.Node, date string) (entity.BackupDescription, error) {
+	backup, err := m.findBackup(ctx, node.Cmd, node, date)
+	if err != nil {
+		return entity.BackupDescription{}, err
+	}
+
+	files, err := m.storage.ListFiles(ctx, node.Cmd, backup.Path)
+	if err != nil {
+		return entity.BackupDescription{}, err
+	}
+
+	description := entity.NewBackupDescription(node.Info.Host, backup, files)
+
+	metadata, err := m.readBackupMetadata(ctx, node, backup.Path)
+	if err != nil {
+		description.MetadataError = err.Error()
+	} else {
+		description.Metadata = &metadata
+	}
+
+	switch {
+	case entity.HasFile(files, backup.Path, entity.BackupSchemaFilename):
+		data, err := m.storage.ReadFile(ctx, node.Cmd, backup.Path+"/"+entity.BackupSchemaFilename)
+		if err != nil {
+			description.SchemaError = err.Error()
+		} else {
+			schema := entity.ParseSchemaSummary(string(data))
+			description.Schema = &schema
+		}
+	case description.Metadata != nil && description.Metadata.Archive.Method != "":
+		description.SchemaError = "the schema is inside the archive"
+	default:
+		description.SchemaError = "the schema is not found"
+	}
+
+	return description, nil
+}
+
+// runs a callback on a node with a given address or short domain name, and returns its result.
+// Returns an error if several nodes match (e.g. the same short domain name in different datacenters).
+func (m *Octopus) runOnHost(ctx context.Context, host string, callback entity.NodeCallback) (interface{}, error) {
+	matches := m.cluster.RunParallel(ctx, func(ctx context.Context, node *entity.Node) entity.NodeCallbackResult {
+		return entity.CallbackOk(node.Info.HasAddress(host) || node.Info.ShortDomainName() == host)
+	})
+
+	matchedHosts := []string{}
+	for _, match := range matches {
+		if matched, _ := match.Value.(bool); matched {
+			matchedHosts = append(matchedHosts, match.Host)
+		}
+	}
+	sort.Strings(matchedHosts)
+
+	if len(matchedHosts) == 0 {
+		if err := matches.Error(); err != nil {
+			return nil, errors.Wrapf(err, "host %s is not found in the cluster", host)
+		}
+
+		return nil, fmt.Errorf("host %s is not found in the cluster", host)
+	}
+
+	if len(matchedHosts) > 1 {
+		return nil, fmt.Errorf("host %s matches several nodes: %s", host, strings.Join(matchedHosts, ", "))
+	}
+
+	results := m.cluster.Run(ctx, func(ctx context.Context, node *entity.Node) entity.NodeCallbackResult {
+		if node.Info.Host != matchedHosts[0] {
+			return entity.CallbackOk(nil)
+		}
+
+		return callback(ctx, node)
+	})
+
+	result, found := results[matchedHosts[0]]
+	if !found {
+		err := results.Error()
+		if err == nil {
+			err = errors.New("the execution was interrupted")
+		}
+
+		return nil, errors.Wrapf(err, "could not run on host %s", host)
+	}
+
+	return result.Value, result.Err
+}
+
+func (m *Octopus) readBackupMetadata(ctx context.Context, node *entity.Node, backupPath string) (entity.BackupMetadata, error) {
+	data, err := m.storage.ReadFile(ctx, node.Cmd, backupPath+"/"+entity.BackupMetadataFilename)
+	if err != nil {
+		return entity.BackupMetadata{}, err
+	}
+
+	metadata, err := entity.ParseBackupMetadata(data)
+	if err != nil {
+		return entity.BackupMetadata{}, errors.Wrap(err, "could not parse backup metadata")
+	}
+
+	return metadata, nil
+}
+
+// finds a node backup in remote storage by date (see DescribeBackup)
+func (m *Octopus) findBackup(ctx context.Context, executor cmd.Executor, node *entity.Node, date string) (entity.RemoteBackup, error) {
+	backups, err := m.storage.ListBackups(ctx, executor, node.Info.RemoteStoragePath())
+	if err != nil {
+		return entity.RemoteBackup{}, err
+	}
+
+	backup, found := selectBackup(backups, date)
+	if !found {
+		return backup, fmt.Errorf("backup %s of %s is not found", date, node.Info.Host)
+	}
+
+	return backup, nil
+}
+
+// returns a backup with a given directory name, or the newest backup if the date is "latest".
+// The paths of returned backups point to backup directories rather than their subdirectories.
+func selectBackup(backups []entity.RemoteBackup, date string) (entity.RemoteBackup, bool) {
+	result := entity.RemoteBackup{}
+	found := false
+
+	for _, backup := range backups {
+		backup.Path = backup.RootPath()
+
+		if date == "latest" {
+			if !found || backup.DateCreated.After(result.DateCreated) {
+				result = backup
+				found = true
+			}
+		} else if path.Base(backup.Path) == date {
+			return backup, true
+		}
+	}
+
+	return result, found
+}
@@ -21,6 +21,7 @@ func TestOctopus_Backup(t *testing.T) {
 			Hosts: []string{"127.0.0.1", "127.0.0.2"},
 		},
 		factory.NewTestFactory(),
+		nil,
 		logger,
 	)
 	// a test backup service implementation that returns
@@ -31,6 +32,7 @@ func TestOctopus_Backup(t *testing.T) {
 			"127.0.0.1": {
 				SnapshotTag: "host-1-snapshot",
 				Duration:    time.Second,
+				Size:        1024,
 			},
 			"127.0.0.2": {
 				SnapshotTag: "host-2-snapshot",
@@ -39,11 +41,15 @@ func TestOctopus_Backup(t *testing.T) {
 			},
 		},
 	}
+	runHistory := &testRunHistory{}
 	app := NewOctopus(
 		clusterInstance,
 		testDb{},
 		backupService,
 		testStorage{},
+		&testRepairHistory{},
+		runHistory,
+		ParallelismOptions{},
 		notifier.Disabled{},
 		zap.S(),
 	)
@@ -61,4 +67,204 @@ func TestOctopus_Backup(t *testing.T) {
 		`Total nodes: 2
 Backed up nodes: 1`,
 	)
+
+	// the run is recorded with the outcome and the backup size of every node
+	require.Len(t, runHistory.runs, 1)
+	run := runHistory.runs[0]
+	require.Equal(t, "backup", run.Operation)
+	require.False(t, run.IsOk())
+	require.Len(t, run.Hosts, 2)
+	require.Equal(t, int64(1024), run.Hosts[0].Bytes)
+	require.Empty(t, run.Hosts[0].Error)
+	require.Equal(t, "test error", run.Hosts[1].Error)
+}
+
+// A successful backup with a cleanup error is reported as a warning
+func TestOctopus_BackupCleanupWarning(t *testing.T) {
+	clusterInstance := clusterPkg.NewCluster(
+		clusterPkg.Options{
+			Hosts: []string{"127.0.0.1", "127.0.0.2"},
+		},
+		factory.NewTestFactory(),
+		nil,
+		zap.S(),
+	)
+	backupService := testBackupService{
+		backupResultsByHost: map[string]entity.BackupResult{
+			"127.0.0.1": {},
+			"127.0.0.2": {
+				CleanupResult: entity.CleanupResult{
+					RemoteError: errors.New("access denied"),
+				},
+			},
+		},
+	}
+	testNotifier := &testNotifier{}
+	app := NewOctopus(
+		clusterInstance,
+		testDb{},
+		backupService,
+		testStorage{},
+		&testRepairHistory{},
+		&testRunHistory{},
+		ParallelismOptions{},
+		testNotifier,
+		zap.S(),
+	)
+
+	result := app.Backup(context.Background())
+	require.NoError(t, result.Error)
+	require.Equal(t, 2, result.BackedUpNodes)
+
+	require.Len(t, testNotifier.messages, 1)
+	msg := testNotifier.messages[0]
+	require.Equal(t, notifier.OperationBackup, msg.Operation)
+	require.Equal(t, notifier.SeverityWarning, msg.Severity)
+	require.Contains(t, msg.Err.Error(), "could not remove expired backups of 127.0.0.2: access denied")
+	require.Equal(t, result, msg.Result)
+}
+
+// A node with an old backup, or without backups, fails the check and sends an error notification
+func TestOctopus_CheckBackupFreshness(t *testing.T) {
+	now := time.Date(2022, 1, 2, 12, 0, 0, 0, time.UTC)
+	cluster := testCluster{
+		nodeCount: 3,
+		callbackResults: map[string]entity.NodeCallbackResult{
+			"host-1": {
+				Host:  "host-1",
+				Value: []entity.RemoteBackup{{DateCreated: now.Add(-time.Hour)}},
+			},
+			"host-2": {
+				Host:  "host-2",
+				Value: []entity.RemoteBackup{{DateCreated: now.Add(-time.Hour * 30)}},
+			},
+			"host-3": {
+				Host:  "host-3",
+				Value: []entity.RemoteBackup{},
+			},
+		},
+	}
+	testNotifier := &testNotifier{}
+	app := NewOctopus(
+		cluster,
+		testDb{},
+		testBackupService{},
+		testStorage{},
+		&testRepairHistory{},
+		&testRunHistory{},
+		ParallelismOptions{},
+		testNotifier,
+		zap.S(),
+	)
+
+	results := app.CheckBackupFreshness(context.Background(), now, time.Hour*24)
+	require.Error(t, results.Error)
+	require.Equal(t, 3, results.TotalNodes)
+	require.Equal(t, 1, results.FreshNodes)
+	require.Contains(t, results.Error.Error(), "host-2 is 30h0m0s old")
+	require.Contains(t, results.Error.Error(), "host-3 has no backups")
+
+	require.Len(t, testNotifier.messages, 1)
+	require.Equal(t, notifier.OperationFreshness, testNotifier.messages[0].Operation)
+	require.Equal(t, notifier.SeverityError, testNotifier.messages[0].Severity)
+
+	// all backups are fresh
+	delete(cluster.callbackResults, "host-3")
+	cluster.callbackResults["host-2"] = entity.NodeCallbackResult{
+		Host:  "host-2",
+		Value: []entity.RemoteBackup{{DateCreated: now.Add(-time.Hour * 2)}},
+	}
+
+	results = app.CheckBackupFreshness(context.Background(), now, time.Hour*24)
+	require.NoError(t, results.Error)
+	require.Equal(t, 2, results.FreshNodes)
+	require.Equal(t, notifier.SeverityInfo, testNotifier.messages[1].Severity)
+}
+
+func TestOctopus_DescribeBackup(t *testing.T) {
+	clusterInstance := clusterPkg.NewCluster(
+		clusterPkg.Options{
+			Hosts: []string{"127.0.0.1", "127.0.0.2"},
+		},
+		factory.NewTestFactory(),
+		nil,
+		zap.S(),
+	)
+	backupPath := "cluster/dc1/127.0.0.2/09-07-2021-10-29"
+	metadata := entity.BackupMetadata{
+		Host:        "127.0.0.2",
+		SnapshotTag: "tag",
+		BuildInfo:   entity.BuildInfo{Version: "1.2.3"},
+	}
+	storage := testStorage{
+		backups: []entity.RemoteBackup{
+			{Path: "cluster/dc1/127.0.0.2/08-07-2021-10-29", DateCreated: time.Date(2021, 7, 8, 10, 29, 0, 0, time.UTC)},
+			{Path: backupPath, DateCreated: time.Date(2021, 7, 9, 10, 29, 0, 0, time.UTC)},
+		},
+		files: []entity.RemoteFile{
+			{Path: backupPath + "/metadata.yml", Size: 10},
+			{Path: backupPath + "/db_schema.cql", Size: 20},
+		},
+		contents: map[string]string{
+			backupPath + "/metadata.yml":  string(metadata.Bytes()),
+			backupPath + "/db_schema.cql": "CREATE KEYSPACE shop WITH replication = {};\nCREATE TABLE shop.users (id uuid PRIMARY KEY);",
+		},
+	}
+	app := NewOctopus(
+		clusterInstance,
+		testDb{},
+		testBackupService{},
+		storage,
+		&testRepairHistory{},
+		&testRunHistory{},
+		ParallelismOptions{},
+		notifier.Disabled{},
+		zap.S(),
+	)
+
+	description, err := app.DescribeBackup(context.Background(), "127.0.0.2", "latest")
+	require.NoError(t, err)
+	require.Equal(t, "127.0.0.2", description.Host)
+	require.Equal(t, backupPath, description.Path)
+	require.Equal(t, int64(30), description.Size)
+	require.Equal(t, "1.2.3", description.Metadata.BuildInfo.Version)
+	require.Equal(t, []string{"shop.users"}, description.Schema.Tables)
+
+	description, err = app.DescribeBackup(context.Background(), "127.0.0.2", "09-07-2021-10-29")
+	require.NoError(t, err)
+	require.Equal(t, backupPath, description.Path)
+
+	_, err = app.DescribeBackup(context.Background(), "127.0.0.2", "01-01-2020-00-00")
+	require.EqualError(t, err, "backup 01-01-2020-00-00 of 127.0.0.2 is not found")
+
+	_, err = app.DescribeBackup(context.Background(), "127.0.0.3", "latest")
+	require.EqualError(t, err, "host 127.0.0.3 is not found in the cluster")
+}
+
+// A host that matches several nodes is rejected
+func TestOctopus_DescribeBackupAmbiguousHost(t *testing.T) {
+	// both nodes have the same short domain name, "scylla1"
+	clusterInstance := clusterPkg.NewCluster(
+		clusterPkg.Options{
+			Hosts:          []string{"scylla1.dc1.local", "scylla1.dc2.local"},
+			SkipDnsResolve: true,
+		},
+		factory.NewTestFactory(),
+		nil,
+		zap.S(),
+	)
+	app := NewOctopus(
+		clusterInstance,
+		testDb{},
+		testBackupService{},
+		testStorage{},
+		&testRepairHistory{},
+		&testRunHistory{},
+		ParallelismOptions{},
+		notifier.Disabled{},
+		zap.S(),
+	)
+
+	_, err := app.DescribeBackup(context.Background(), "scylla1", "latest")
+	require.EqualError(t, err, "host scylla1 matches several nodes: scylla1.dc1.local, scylla1.dc2.local")
 }
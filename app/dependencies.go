@@ -14,12 +14,31 @@ type dbClient interface {
 	Healthcheck(ctx context.Context, node *entity.Node) error
 	ListSnapshots(ctx context.Context, node *entity.Node) (entity.Snapshots, error)
 	Repair(ctx context.Context, node *entity.Node) (entity.RepairResult, error)
+	ListTables(ctx context.Context, node *entity.Node) ([]entity.Table, error)
+	Maintenance(
+		ctx context.Context,
+		node *entity.Node,
+		operation entity.MaintenanceOperation,
+		filter entity.MaintenanceFilter,
+	) (entity.MaintenanceResult, error)
+	ClusterStatus(ctx context.Context, node *entity.Node) ([]entity.NodeStatus, error)
+	Drain(ctx context.Context, node *entity.Node) error
+	SchemaVersions(ctx context.Context, node *entity.Node) (map[string][]string, error)
 }
 
 // Remote storage (implemented in `pkg/awscli`)
 type remoteStorageClient interface {
 	Healthcheck(ctx context.Context, cmdExecutor cmd.Executor) error
 	ListBackups(ctx context.Context, cmdExecutor cmd.Executor, basePath string) ([]entity.RemoteBackup, error)
+	ListHostBackups(
+		ctx context.Context,
+		cmdExecutor cmd.Executor,
+		basePath, host string,
+		datacenters []string,
+	) ([]entity.RemoteBackup, error)
+	ListFiles(ctx context.Context, cmdExecutor cmd.Executor, path string) ([]entity.RemoteFile, error)
+	ReadFile(ctx context.Context, cmdExecutor cmd.Executor, path string) ([]byte, error)
+	Download(ctx context.Context, cmdExecutor cmd.Executor, path, localPath string) error
 }
 
 // Backup service (implemented in `pkg/backup`)
@@ -30,10 +49,29 @@ type backupService interface {
 	ListExpiredBackups(ctx context.Context, node *entity.Node, now time.Time) ([]entity.RemoteBackup, error)
 }
 
+// Repair history storage (implemented in `pkg/history`)
+type repairHistoryStore interface {
+	Add(run entity.RepairRun) error
+	List() (entity.RepairHistory, error)
+}
+
+// Operation run history storage (implemented in `pkg/history`)
+type runHistoryStore interface {
+	Add(run entity.OperationRun) error
+	List() (entity.RunHistory, error)
+}
+
 // A cluster of database nodes (implemented in `pkg/cluster`)
 type cluster interface {
 	Run(ctx context.Context, callback entity.NodeCallback) entity.NodeCallbackResults
 	RunParallel(ctx context.Context, callback entity.NodeCallback) entity.NodeCallbackResults
+	RunLimited(
+		ctx context.Context,
+		parallelism entity.Parallelism,
+		callback entity.NodeCallback,
+	) entity.NodeCallbackResults
 	Connect(ctx context.Context) entity.NodeCallbackResults
 	Size() int
+	TotalSize() int
+	Peers(host string) []*entity.Node
 }
@@ -0,0 +1,222 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"github.com/kolesa-team/scylla-octopus/pkg/archive"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd"
+	"github.com/kolesa-team/scylla-octopus/pkg/entity"
+	"github.com/pkg/errors"
+	"path"
+	"sort"
+	"strings"
+)
+
+// the prefix of an archived backup, e.g. "backup.tar.pigz"
+const archivePrefix = "backup.tar."
+
+// DownloadOptions configure downloading a backup to a local directory
+type DownloadOptions struct {
+	// the cluster name, the first part of a backup path in remote storage
+	Cluster string
+	// the datacenter of a host; found in remote storage if empty
+	Datacenter string
+	// the datacenters to look for a host in if Datacenter is empty (e.g. cluster.datacenters);
+	// all datacenters in remote storage if empty
+	Datacenters []string
+	// check the downloaded files against remote storage and snapshot manifests
+	Verify bool
+	// extract "backup.tar.<method>" if a backup is archived
+	Decompress bool
+}
+
+// DownloadBackup downloads a backup from remote storage to a local directory.
+// The backup is found by its path ("cluster/datacenter/host/date") without connecting to the database nodes,
+// and the commands are executed with a given local executor, so it works when the cluster is unavailable.
+// The host is a short domain name the backups are stored by (see entity.NodeInfo.ShortDomainName),
+// and the date is a backup directory name, or "latest" for the newest backup.
+// The files that are already downloaded are skipped, so an interrupted download continues with the missing files.
+func (m *Octopus) DownloadBackup(
+	ctx context.Context,
+	localCmd cmd.Executor,
+	host, date, localPath string,
+	options DownloadOptions,
+) (entity.BackupDownload, error) {
+	if len(options.Cluster) == 0 {
+		return entity.BackupDownload{}, errors.New("the cluster name is required to find a backup")
+	}
+
+	err := m.storage.Healthcheck(ctx, localCmd)
+	if err != nil {
+		return entity.BackupDownload{}, err
+	}
+
+	backup, err := m.findBackupByHost(ctx, localCmd, host, date, options)
+	if err != nil {
+		return entity.BackupDownload{}, err
+	}
+
+	download := entity.BackupDownload{
+		Host:        host,
+		Path:        backup.Path,
+		LocalPath:   localPath,
+		DateCreated: backup.DateCreated,
+	}
+	logger := m.logger.With("host", download.Host, "path", download.Path, "localPath", localPath)
+
+	files, err := m.storage.ListFiles(ctx, localCmd, download.Path)
+	if err != nil {
+		return download, err
+	}
+
+	download.Files = len(files)
+	for _, file := range files {
+		download.Size += file.Size
+	}
+
+	err = cmd.CreateDirectory(ctx, localCmd, localPath)
+	if err != nil {
+		return download, err
+	}
+
+	logger.Infow("downloading a backup", "files", download.Files, "size", entity.FormatBytes(download.Size))
+
+	err = m.storage.Download(ctx, localCmd, download.Path, localPath)
+	if err != nil {
+		return download, err
+	}
+
+	if options.Verify {
+		localFiles, err := cmd.FileSizes(ctx, localCmd, localPath)
+		if err != nil {
+			return download, err
+		}
+
+		download.Verified = true
+		download.Problems = entity.VerifyDownload(download.Path, files, localFiles)
+	}
+
+	if options.Decompress {
+		method := archiveMethod(download.Path, files)
+		if len(method) > 0 {
+			logger.Infow("extracting an archive", "method", method)
+
+			err = archive.Extract(ctx, localCmd, localPath, method)
+			if err != nil {
+				return download, err
+			}
+
+			download.Extracted = archivePrefix + method
+		}
+	}
+
+	if options.Verify {
+		// the snapshots of an archived backup are only available after extraction
+		err = m.verifySnapshotManifests(ctx, localCmd, localPath, &download)
+		if err != nil {
+			return download, err
+		}
+	}
+
+	if len(download.Problems) > 0 {
+		return download, fmt.Errorf(
+			"the backup of %s downloaded to %s is incomplete: %d problems",
+			download.Host,
+			localPath,
+			len(download.Problems),
+		)
+	}
+
+	logger.Info("backup downloaded")
+
+	return download, nil
+}
+
+// checks that the files listed in every downloaded snapshot manifest exist
+func (m *Octopus) verifySnapshotManifests(
+	ctx context.Context,
+	localCmd cmd.Executor,
+	localPath string,
+	download *entity.BackupDownload,
+) error {
+	localFiles, err := cmd.FileSizes(ctx, localCmd, localPath)
+	if err != nil {
+		return err
+	}
+
+	for _, manifest := range entity.SnapshotManifests(localFiles) {
+		data, err := localCmd.ReadFile(ctx, localPath+"/"+manifest)
+		if err != nil {
+			return err
+		}
+
+		download.Problems = append(download.Problems, entity.VerifySnapshotManifest(manifest, data, localFiles)...)
+	}
+
+	return nil
+}
+
+// returns the compression method of an archived backup (e.g. "pigz"), or an empty string
+func archiveMethod(backupPath string, files []entity.RemoteFile) string {
+	for _, file := range files {
+		name := strings.TrimPrefix(file.Path, strings.Trim(backupPath, "/")+"/")
+		if path.Dir(name) == "." && strings.HasPrefix(name, archivePrefix) {
+			return strings.TrimPrefix(name, archivePrefix)
+		}
+	}
+
+	return ""
+}
+
+// finds a backup of a host by date in remote storage, in a given datacenter or in any datacenter of a cluster
+func (m *Octopus) findBackupByHost(
+	ctx context.Context,
+	localCmd cmd.Executor,
+	host, date string,
+	options DownloadOptions,
+) (entity.RemoteBackup, error) {
+	datacenters := options.Datacenters
+	if len(options.Datacenter) > 0 {
+		datacenters = []string{options.Datacenter}
+	}
+
+	backups, err := m.storage.ListHostBackups(ctx, localCmd, options.Cluster, host, datacenters)
+	if err != nil {
+		return entity.RemoteBackup{}, err
+	}
+
+	backupDatacenters := map[string]bool{}
+	for _, backup := range backups {
+		// cluster/datacenter/host/date
+		parts := strings.Split(strings.Trim(backup.RootPath(), "/"), "/")
+		if len(parts) >= 3 {
+			backupDatacenters[parts[len(parts)-3]] = true
+		}
+	}
+
+	if len(backupDatacenters) > 1 {
+		names := []string{}
+		for datacenter := range backupDatacenters {
+			names = append(names, datacenter)
+		}
+		sort.Strings(names)
+
+		return entity.RemoteBackup{}, fmt.Errorf(
+			"host %s has backups in several datacenters (%s); choose one of them",
+			host,
+			strings.Join(names, ", "),
+		)
+	}
+
+	backup, found := selectBackup(backups, date)
+	if !found {
+		location := options.Cluster
+		if len(datacenters) > 0 {
+			location += " (datacenters: " + strings.Join(datacenters, ", ") + ")"
+		}
+
+		return backup, fmt.Errorf("backup %s of %s is not found in %s", date, host, location)
+	}
+
+	return backup, nil
+}
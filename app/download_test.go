@@ -0,0 +1,176 @@
+package app
+
+import (
+	"context"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd/local"
+	"github.com/kolesa-team/scylla-octopus/pkg/entity"
+	"github.com/kolesa-team/scylla-octopus/pkg/notifier"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"os"
+	"testing"
+	"time"
+)
+
+const testBackupPath = "cluster/dc1/scylla1/09-07-2021-10-29"
+
+func newDownloadTestApp(storage testStorage) *Octopus {
+	storage.backups = []entity.RemoteBackup{
+		{Path: testBackupPath, HostPrefix: "scylla1", DateCreated: time.Date(2021, 7, 9, 10, 29, 0, 0, time.UTC)},
+		{Path: "cluster/dc1/scylla2/09-07-2021-10-29", HostPrefix: "scylla2", DateCreated: time.Date(2021, 7, 9, 10, 29, 0, 0, time.UTC)},
+	}
+
+	// remote files are listed with the sizes of their contents
+	storage.files = []entity.RemoteFile{}
+	for name, content := range storage.downloaded {
+		storage.files = append(storage.files, entity.RemoteFile{
+			Path: testBackupPath + "/" + name,
+			Size: int64(len(content)),
+		})
+	}
+
+	// the database nodes are not used to download a backup
+	return NewOctopus(
+		testCluster{},
+		testDb{},
+		testBackupService{},
+		storage,
+		&testRepairHistory{},
+		&testRunHistory{},
+		ParallelismOptions{},
+		notifier.Disabled{},
+		zap.S(),
+	)
+}
+
+func TestOctopus_DownloadBackup(t *testing.T) {
+	app := newDownloadTestApp(testStorage{
+		downloaded: map[string]string{
+			"metadata.yml": "host: 127.0.0.1",
+			"data/shop/users-3f2a/snapshots/tag/manifest.json":    `{"files":["md-1-big-Data.db"]}`,
+			"data/shop/users-3f2a/snapshots/tag/md-1-big-Data.db": "data",
+		},
+	})
+	localPath := t.TempDir() + "/backup"
+
+	download, err := app.DownloadBackup(
+		context.Background(),
+		local.Executor{},
+		"scylla1",
+		"latest",
+		localPath,
+		DownloadOptions{Cluster: "cluster", Verify: true, Decompress: true},
+	)
+	require.NoError(t, err)
+	require.Equal(t, "scylla1", download.Host)
+	require.Equal(t, testBackupPath, download.Path)
+	require.Equal(t, 3, download.Files)
+	require.True(t, download.Verified)
+	require.Empty(t, download.Problems)
+	require.Empty(t, download.Extracted)
+	require.FileExists(t, localPath+"/data/shop/users-3f2a/snapshots/tag/md-1-big-Data.db")
+}
+
+// A file listed in a snapshot manifest is missing
+func TestOctopus_DownloadBackupIncomplete(t *testing.T) {
+	app := newDownloadTestApp(testStorage{
+		downloaded: map[string]string{
+			"data/shop/users-3f2a/snapshots/tag/manifest.json": `{"files":["md-1-big-Data.db"]}`,
+		},
+	})
+
+	download, err := app.DownloadBackup(
+		context.Background(),
+		local.Executor{},
+		"scylla1",
+		"09-07-2021-10-29",
+		t.TempDir(),
+		DownloadOptions{Cluster: "cluster", Verify: true},
+	)
+	require.EqualError(t, err, "the backup of scylla1 downloaded to "+download.LocalPath+" is incomplete: 1 problems")
+	require.Equal(
+		t,
+		[]string{"data/shop/users-3f2a/snapshots/tag/md-1-big-Data.db is listed in data/shop/users-3f2a/snapshots/tag/manifest.json, but missing"},
+		download.Problems,
+	)
+}
+
+// An archived backup is extracted, and the snapshot manifests inside the archive are verified
+func TestOctopus_DownloadBackupDecompress(t *testing.T) {
+	executor := local.Executor{}
+	source := t.TempDir()
+	snapshotPath := source + "/data/shop/users-3f2a/snapshots/tag"
+	require.NoError(t, os.MkdirAll(snapshotPath, 0700))
+	require.NoError(t, os.WriteFile(snapshotPath+"/manifest.json", []byte(`{"files":["md-1-big-Data.db"]}`), 0600))
+	require.NoError(t, os.WriteFile(snapshotPath+"/md-1-big-Data.db", []byte("data"), 0600))
+	archived, err := executor.Execute(context.Background(), cmd.Shellf("cd %s && tar -cf - ./ | gzip", source))
+	require.NoError(t, err)
+
+	app := newDownloadTestApp(testStorage{
+		downloaded: map[string]string{
+			"metadata.yml":    "host: 127.0.0.1",
+			"backup.tar.gzip": string(archived),
+		},
+	})
+	localPath := t.TempDir()
+
+	download, err := app.DownloadBackup(
+		context.Background(),
+		executor,
+		"scylla1",
+		"latest",
+		localPath,
+		DownloadOptions{Cluster: "cluster", Verify: true, Decompress: true},
+	)
+	require.NoError(t, err)
+	require.Equal(t, "backup.tar.gzip", download.Extracted)
+	require.FileExists(t, localPath+"/data/shop/users-3f2a/snapshots/tag/md-1-big-Data.db")
+}
+
+// A host with backups in several datacenters requires a datacenter
+func TestOctopus_DownloadBackupAmbiguousDatacenter(t *testing.T) {
+	app := newDownloadTestApp(testStorage{})
+	storage := app.storage.(testStorage)
+	storage.backups = append(storage.backups, entity.RemoteBackup{
+		Path:        "cluster/dc2/scylla1/10-07-2021-10-29/data",
+		HostPrefix:  "scylla1",
+		DateCreated: time.Date(2021, 7, 10, 10, 29, 0, 0, time.UTC),
+	})
+	app.storage = storage
+
+	_, err := app.DownloadBackup(
+		context.Background(),
+		local.Executor{},
+		"scylla1",
+		"latest",
+		t.TempDir(),
+		DownloadOptions{Cluster: "cluster"},
+	)
+	require.EqualError(t, err, "host scylla1 has backups in several datacenters (dc1, dc2); choose one of them")
+
+	_, err = app.DownloadBackup(context.Background(), local.Executor{}, "scylla1", "latest", t.TempDir(), DownloadOptions{})
+	require.EqualError(t, err, "the cluster name is required to find a backup")
+
+	// the configured datacenters narrow the search
+	download, err := app.DownloadBackup(
+		context.Background(),
+		local.Executor{},
+		"scylla1",
+		"latest",
+		t.TempDir(),
+		DownloadOptions{Cluster: "cluster", Datacenters: []string{"dc2", "dc3"}},
+	)
+	require.NoError(t, err)
+	require.Equal(t, "cluster/dc2/scylla1/10-07-2021-10-29", download.Path)
+
+	_, err = app.DownloadBackup(
+		context.Background(),
+		local.Executor{},
+		"scylla1",
+		"latest",
+		t.TempDir(),
+		DownloadOptions{Cluster: "cluster", Datacenter: "dc3", Datacenters: []string{"dc2"}},
+	)
+	require.EqualError(t, err, "backup latest of scylla1 is not found in cluster (datacenters: dc3)")
+}
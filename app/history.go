@@ -0,0 +1,29 @@
+package app
+
+import (
+	"github.com/kolesa-team/scylla-octopus/pkg/entity"
+	"time"
+)
+
+// HistoryOptions configure the history of operation runs
+type HistoryOptions struct {
+	// where to keep the history of operation runs (used by `history list`, `history show` and `history stats`)
+	File string
+	// the number of the newest runs to keep. defaults to 1000
+	MaxRuns int `yaml:"maxRuns"`
+	// the runs started earlier than this are removed. no limit by default
+	MaxAge time.Duration `yaml:"maxAge"`
+}
+
+// RunHistory returns all recorded operation runs
+func (m *Octopus) RunHistory() (entity.RunHistory, error) {
+	return m.runHistory.List()
+}
+
+// records an operation run into the run history
+func (m *Octopus) saveRun(run entity.OperationRun) {
+	err := m.runHistory.Add(run)
+	if err != nil {
+		m.logger.Warnw("could not save run history", "operation", run.Operation, "error", err)
+	}
+}
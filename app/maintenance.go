@@ -0,0 +1,77 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"github.com/kolesa-team/scylla-octopus/pkg/entity"
+	"github.com/kolesa-team/scylla-octopus/pkg/notifier"
+	"time"
+)
+
+// Maintenance executes a `nodetool` maintenance operation (cleanup, compact, etc) on every cluster node.
+// In serial mode, the nodes are processed one by one, and the execution stops on the first error.
+// In parallel mode, the operation is executed on all nodes at once, and an error on one node doesn't stop the others.
+func (m *Octopus) Maintenance(
+	ctx context.Context,
+	operation entity.MaintenanceOperation,
+	filter entity.MaintenanceFilter,
+	parallel bool,
+) entity.MaintenanceResults {
+	callback := func(ctx context.Context, node *entity.Node) entity.NodeCallbackResult {
+		result, err := m.scylla.Maintenance(ctx, node, operation, filter)
+		if err != nil {
+			return entity.CallbackErrorWithValue(err, result)
+		}
+
+		return entity.CallbackOk(result)
+	}
+
+	dateStarted := time.Now()
+	var callbackResults entity.NodeCallbackResults
+	if parallel {
+		callbackResults = m.cluster.RunParallel(ctx, callback)
+	} else {
+		callbackResults = m.cluster.Run(ctx, callback)
+	}
+
+	results := entity.MaintenanceResults{
+		Operation:  operation,
+		TotalNodes: m.cluster.Size(),
+		ByHost:     map[string]entity.MaintenanceResult{},
+		Error:      callbackResults.Error(),
+	}
+
+	for _, callbackResult := range callbackResults {
+		if callbackResult.Err != nil {
+			continue
+		}
+
+		results.ByHost[callbackResult.Host] = callbackResult.Value.(entity.MaintenanceResult)
+		results.CompletedNodes++
+	}
+
+	// e.g. "maintenance-compact"
+	runOperation := string(notifier.OperationMaintenance) + "-" + string(operation)
+	m.saveRun(entity.NewOperationRun(runOperation, dateStarted, m.cluster.Size(), callbackResults))
+
+	if results.Error != nil {
+		m.notifier.Notify(notifier.Message{
+			Operation: notifier.OperationMaintenance,
+			Severity:  notifier.SeverityError,
+			Header:    fmt.Sprintf("Could not execute nodetool %s", operation),
+			Body:      results.Report(),
+			Result:    results,
+			Err:       results.Error,
+		})
+	} else {
+		m.notifier.Notify(notifier.Message{
+			Operation: notifier.OperationMaintenance,
+			Severity:  notifier.SeverityInfo,
+			Header:    fmt.Sprintf("nodetool %s executed successfully", operation),
+			Body:      results.Report(),
+			Result:    results,
+		})
+	}
+
+	return results
+}
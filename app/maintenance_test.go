@@ -0,0 +1,52 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"github.com/kolesa-team/scylla-octopus/pkg/entity"
+	"github.com/kolesa-team/scylla-octopus/pkg/notifier"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+func TestOctopus_Maintenance(t *testing.T) {
+	// a test implementation of database cluster returning 2 cleanup results:
+	// one is successful and one is not.
+	cluster := testCluster{
+		nodeCount: 2,
+		callbackResults: map[string]entity.NodeCallbackResult{
+			"host-1": {
+				Host: "host-1",
+				Value: entity.MaintenanceResult{
+					Duration: time.Second,
+				},
+			},
+			"host-2": {
+				Host:  "host-2",
+				Value: entity.MaintenanceResult{},
+				Err:   errors.New("test error"),
+			},
+		},
+	}
+	app := NewOctopus(
+		cluster,
+		testDb{},
+		testBackupService{},
+		testStorage{},
+		&testRepairHistory{},
+		&testRunHistory{},
+		ParallelismOptions{},
+		notifier.Disabled{},
+		zap.S(),
+	)
+
+	result := app.Maintenance(context.Background(), entity.MaintenanceCleanup, entity.MaintenanceFilter{}, true)
+
+	require.Error(t, result.Error)
+	require.Equal(t, entity.MaintenanceCleanup, result.Operation)
+	require.Equal(t, 2, result.TotalNodes, "a cluster must contain 2 nodes")
+	require.Equal(t, 1, result.CompletedNodes, "only 1 node should be cleaned up")
+	require.Equal(t, time.Second, result.ByHost["host-1"].Duration)
+}
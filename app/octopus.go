@@ -9,12 +9,27 @@ import (
 
 // Octopus operates a cluster of scylladb nodes
 type Octopus struct {
-	cluster  cluster
-	scylla   dbClient
-	backup   backupService
-	storage  remoteStorageClient
-	notifier notifier.Notifier
-	logger   *zap.SugaredLogger
+	cluster cluster
+	scylla  dbClient
+	backup  backupService
+	storage remoteStorageClient
+	// repair runs are recorded here
+	repairHistory repairHistoryStore
+	// every run of an operation (backup, repair, etc.) is recorded here
+	runHistory  runHistoryStore
+	parallelism ParallelismOptions
+	notifier    notifier.Notifier
+	logger      *zap.SugaredLogger
+}
+
+// ParallelismOptions limits the number of nodes processed at once by each operation.
+// By default, all nodes are processed at once.
+type ParallelismOptions struct {
+	Backup entity.Parallelism
+	// removing expired backups from remote storage
+	CleanupExpired entity.Parallelism `yaml:"cleanupExpired"`
+	// listing backups, expired backups and snapshots
+	List entity.Parallelism
 }
 
 func NewOctopus(
@@ -22,16 +37,22 @@ func NewOctopus(
 	scylla dbClient,
 	backup backupService,
 	storage remoteStorageClient,
+	repairHistory repairHistoryStore,
+	runHistory runHistoryStore,
+	parallelism ParallelismOptions,
 	notifier notifier.Notifier,
 	logger *zap.SugaredLogger,
 ) *Octopus {
 	return &Octopus{
-		cluster:  cluster,
-		scylla:   scylla,
-		backup:   backup,
-		storage:  storage,
-		notifier: notifier,
-		logger:   logger,
+		cluster:       cluster,
+		scylla:        scylla,
+		backup:        backup,
+		storage:       storage,
+		repairHistory: repairHistory,
+		runHistory:    runHistory,
+		parallelism:   parallelism,
+		notifier:      notifier,
+		logger:        logger,
 	}
 }
 
@@ -29,6 +29,9 @@ func TestOctopus_Healthcheck_Ok(t *testing.T) {
 		testDb{},
 		testBackupService{},
 		testStorage{},
+		&testRepairHistory{},
+		&testRunHistory{},
+		ParallelismOptions{},
 		notifier.Disabled{},
 		zap.S(),
 	)
@@ -64,6 +67,9 @@ func TestOctopus_Healthcheck_WithError(t *testing.T) {
 		testDb{},
 		testBackupService{},
 		testStorage{},
+		&testRepairHistory{},
+		&testRunHistory{},
+		ParallelismOptions{},
 		notifier.Disabled{},
 		zap.S(),
 	)
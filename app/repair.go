@@ -3,14 +3,32 @@ package app
 import (
 	"context"
 	"github.com/kolesa-team/scylla-octopus/pkg/entity"
+	"github.com/kolesa-team/scylla-octopus/pkg/notifier"
+	"github.com/pkg/errors"
+	"time"
 )
 
+// RepairOptions repair history settings
+type RepairOptions struct {
+	// a file where the repair history is stored.
+	// defaults to ~/.scylla-octopus/repair-history.json
+	HistoryFile string `yaml:"historyFile"`
+	// the number of the newest repair runs to keep. defaults to 1000
+	HistoryMaxRuns int `yaml:"historyMaxRuns"`
+	// the repair runs started earlier than this are removed. no limit by default
+	HistoryMaxAge time.Duration `yaml:"historyMaxAge"`
+	// a table is due for repair when its last repair is older than this fraction of its gc_grace_seconds.
+	// defaults to 0.5
+	DueFraction float64 `yaml:"dueFraction"`
+}
+
 // Repair executes `nodetool repair` on every cluster node consecutively.
 func (m *Octopus) Repair(ctx context.Context) entity.RepairResults {
+	dateStarted := time.Now()
 	callbackResults := m.cluster.Run(ctx, func(ctx context.Context, node *entity.Node) entity.NodeCallbackResult {
 		result, err := m.scylla.Repair(ctx, node)
 		if err != nil {
-			return entity.CallbackError(err)
+			return entity.CallbackErrorWithValue(err, result)
 		}
 
 		return entity.CallbackOk(result)
@@ -31,20 +49,90 @@ func (m *Octopus) Repair(ctx context.Context) entity.RepairResults {
 		repairResults.RepairedNodes++
 	}
 
+	m.saveRepairRun(dateStarted, callbackResults)
+	m.saveRun(entity.NewOperationRun(string(notifier.OperationRepair), dateStarted, m.cluster.Size(), callbackResults))
+
 	if repairResults.Error != nil {
-		m.notifier.Error(
-			"Could not execute nodetool repair",
-			repairResults.Report(),
-			repairResults.Error,
-			nil,
-		)
+		m.notifier.Notify(notifier.Message{
+			Operation: notifier.OperationRepair,
+			Severity:  notifier.SeverityError,
+			Header:    "Could not execute nodetool repair",
+			Body:      repairResults.Report(),
+			Result:    repairResults,
+			Err:       repairResults.Error,
+		})
 	} else {
-		m.notifier.Info(
-			"nodetool repair executed successfully",
-			repairResults.Report(),
-			nil,
-		)
+		m.notifier.Notify(notifier.Message{
+			Operation: notifier.OperationRepair,
+			Severity:  notifier.SeverityInfo,
+			Header:    "nodetool repair executed successfully",
+			Body:      repairResults.Report(),
+			Result:    repairResults,
+		})
 	}
 
 	return repairResults
 }
+
+// RepairHistory returns all recorded repair runs
+func (m *Octopus) RepairHistory() (entity.RepairHistory, error) {
+	return m.repairHistory.List()
+}
+
+// RepairDue returns the tables whose last repair is older than a given fraction of their gc_grace_seconds.
+// The list of tables and their gc_grace_seconds are read from the database schema.
+func (m *Octopus) RepairDue(ctx context.Context, now time.Time, dueFraction float64) ([]entity.RepairDueTable, error) {
+	history, err := m.repairHistory.List()
+	if err != nil {
+		return nil, err
+	}
+
+	results := m.cluster.RunParallel(ctx, func(ctx context.Context, node *entity.Node) entity.NodeCallbackResult {
+		tables, err := m.scylla.ListTables(ctx, node)
+		if err != nil {
+			return entity.CallbackError(err)
+		}
+
+		return entity.CallbackOk(tables)
+	})
+
+	// the schema is the same on every node, so any successful result will do
+	for _, host := range results.Hosts() {
+		if results[host].Err == nil {
+			tables := results[host].Value.([]entity.Table)
+
+			return history.DueTables(tables, now, dueFraction), nil
+		}
+	}
+
+	err = results.Error()
+	if err == nil {
+		err = errors.New("no database nodes available")
+	}
+
+	return nil, errors.Wrap(err, "could not read the database schema")
+}
+
+// records a repair run with the results of every table on every node into the repair history.
+// Since each node only repairs its primary token ranges, a table is only repaired
+// when every node of the whole cluster has repaired it, so the total is not limited by the datacenter and rack filters.
+func (m *Octopus) saveRepairRun(dateStarted time.Time, callbackResults entity.NodeCallbackResults) {
+	run := entity.RepairRun{
+		Id:           entity.NewRepairRunId(dateStarted),
+		DateStarted:  dateStarted,
+		DateFinished: time.Now(),
+		TotalNodes:   m.cluster.TotalSize(),
+		Tables:       []entity.TableRepair{},
+	}
+
+	for _, host := range callbackResults.Hosts() {
+		if result, ok := callbackResults[host].Value.(entity.RepairResult); ok {
+			run.Tables = append(run.Tables, result.Tables...)
+		}
+	}
+
+	err := m.repairHistory.Add(run)
+	if err != nil {
+		m.logger.Warnw("could not save repair history", "error", err)
+	}
+}
@@ -33,11 +33,15 @@ func TestOctopus_Repair(t *testing.T) {
 			},
 		},
 	}
+	repairHistory := &testRepairHistory{}
 	app := NewOctopus(
 		cluster,
 		testDb{},
 		testBackupService{},
 		testStorage{},
+		repairHistory,
+		&testRunHistory{},
+		ParallelismOptions{},
 		notifier.Disabled{},
 		zap.S(),
 	)
@@ -58,4 +62,117 @@ Error:
 Duration:
 host-1: 1s`,
 		result.Report())
+
+	require.Len(t, repairHistory.runs, 1, "a repair run must be saved in history")
+	require.Equal(t, 2, repairHistory.runs[0].TotalNodes)
+}
+
+// A repair limited to some datacenters is recorded with the size of the whole cluster,
+// so that the tables are not considered repaired by it
+func TestOctopus_RepairFiltered(t *testing.T) {
+	cluster := testCluster{
+		nodeCount:      1,
+		totalNodeCount: 3,
+		callbackResults: map[string]entity.NodeCallbackResult{
+			"host-1": {
+				Host:  "host-1",
+				Value: entity.RepairResult{},
+			},
+		},
+	}
+	repairHistory := &testRepairHistory{}
+	app := NewOctopus(
+		cluster,
+		testDb{},
+		testBackupService{},
+		testStorage{},
+		repairHistory,
+		&testRunHistory{},
+		ParallelismOptions{},
+		notifier.Disabled{},
+		zap.S(),
+	)
+
+	result := app.Repair(context.Background())
+	require.NoError(t, result.Error)
+	require.Equal(t, 1, result.TotalNodes)
+
+	require.Len(t, repairHistory.runs, 1)
+	require.Equal(t, 3, repairHistory.runs[0].TotalNodes)
+}
+
+func TestOctopus_RepairDue(t *testing.T) {
+	now := time.Date(2021, 10, 22, 15, 0, 0, 0, time.UTC)
+	// a test implementation of database cluster returning a list of tables from the schema
+	cluster := testCluster{
+		nodeCount: 1,
+		callbackResults: map[string]entity.NodeCallbackResult{
+			"host-1": {
+				Host: "host-1",
+				Value: []entity.Table{
+					{Keyspace: "system", Name: "local", GcGraceSeconds: 0},
+					{Keyspace: "test", Name: "repaired_recently", GcGraceSeconds: 864000},
+					{Keyspace: "test", Name: "repaired_long_ago", GcGraceSeconds: 864000},
+					{Keyspace: "test", Name: "never_repaired", GcGraceSeconds: 864000},
+				},
+			},
+		},
+	}
+	// the history of 2 repair runs: 8 days ago and 1 day ago
+	repairHistory := &testRepairHistory{
+		runs: entity.RepairHistory{
+			{
+				TotalNodes: 1,
+				Tables: []entity.TableRepair{
+					{
+						Host:        "host-1",
+						Keyspace:    "test",
+						Table:       "repaired_long_ago",
+						DateStarted: now.Add(-time.Hour * 24 * 8),
+						Status:      entity.RepairStatusOk,
+					},
+				},
+			},
+			{
+				TotalNodes: 1,
+				Tables: []entity.TableRepair{
+					{
+						Host:        "host-1",
+						Keyspace:    "test",
+						Table:       "repaired_recently",
+						DateStarted: now.Add(-time.Hour * 24),
+						Status:      entity.RepairStatusOk,
+					},
+				},
+			},
+		},
+	}
+	app := NewOctopus(
+		cluster,
+		testDb{},
+		testBackupService{},
+		testStorage{},
+		repairHistory,
+		&testRunHistory{},
+		ParallelismOptions{},
+		notifier.Disabled{},
+		zap.S(),
+	)
+
+	// gc_grace_seconds is 10 days, so the tables must be repaired every 5 days
+	dueTables, err := app.RepairDue(context.Background(), now, 0.5)
+	require.NoError(t, err)
+	require.Equal(t, []entity.RepairDueTable{
+		{
+			Keyspace:       "test",
+			Table:          "repaired_long_ago",
+			GcGraceSeconds: 864000,
+			LastRepaired:   now.Add(-time.Hour * 24 * 8),
+		},
+		{
+			Keyspace:       "test",
+			Table:          "never_repaired",
+			GcGraceSeconds: 864000,
+		},
+	}, dueTables)
 }
@@ -0,0 +1,235 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/go-multierror"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd"
+	"github.com/kolesa-team/scylla-octopus/pkg/entity"
+	"github.com/kolesa-team/scylla-octopus/pkg/notifier"
+	"github.com/pkg/errors"
+	"strings"
+	"time"
+)
+
+// RollingRestartOptions rolling restart settings
+type RollingRestartOptions struct {
+	// a shell command that restarts scylladb on a node (executed with `sh -c`)
+	Command string
+	// how often to check the cluster status while waiting for a restarted node
+	PollInterval time.Duration `yaml:"pollInterval"`
+	// how long to wait until a restarted node is up and the schema is in agreement
+	Timeout time.Duration
+}
+
+// RollingRestart restarts every cluster node, one by one:
+// drains a node, executes a restart command, and waits until the node is "UN" again and the schema is in agreement.
+// The restart is aborted if any other node is not "UN" (as seen by a peer node), either before or during a node restart.
+func (m *Octopus) RollingRestart(ctx context.Context, options RollingRestartOptions) entity.RollingRestartResults {
+	dateStarted := time.Now()
+	callbackResults := m.cluster.Run(ctx, func(ctx context.Context, node *entity.Node) entity.NodeCallbackResult {
+		timeStarted := time.Now()
+		err := m.restartNode(ctx, node, options)
+		if err != nil {
+			return entity.CallbackError(err)
+		}
+
+		return entity.CallbackOk(entity.RollingRestartResult{
+			Duration: time.Now().Sub(timeStarted),
+		})
+	})
+
+	results := entity.RollingRestartResults{
+		TotalNodes: m.cluster.Size(),
+		ByHost:     map[string]entity.RollingRestartResult{},
+		Error:      callbackResults.Error(),
+	}
+
+	for _, callbackResult := range callbackResults {
+		if callbackResult.Err != nil {
+			continue
+		}
+
+		results.ByHost[callbackResult.Host] = callbackResult.Value.(entity.RollingRestartResult)
+		results.RestartedNodes++
+	}
+
+	m.saveRun(entity.NewOperationRun(string(notifier.OperationRestart), dateStarted, m.cluster.Size(), callbackResults))
+
+	if results.Error != nil {
+		m.notifier.Notify(notifier.Message{
+			Operation: notifier.OperationRestart,
+			Severity:  notifier.SeverityError,
+			Header:    "Could not perform a rolling restart",
+			Body:      results.Report(),
+			Result:    results,
+			Err:       results.Error,
+		})
+	} else {
+		m.notifier.Notify(notifier.Message{
+			Operation: notifier.OperationRestart,
+			Severity:  notifier.SeverityInfo,
+			Header:    "Rolling restart completed successfully",
+			Body:      results.Report(),
+			Result:    results,
+		})
+	}
+
+	return results
+}
+
+// drains and restarts a single node, then waits until it is back
+func (m *Octopus) restartNode(ctx context.Context, node *entity.Node, options RollingRestartOptions) error {
+	logCtx := m.logger.With("host", node.Info.Host)
+	if len(strings.TrimSpace(options.Command)) == 0 {
+		return errors.New("restart command is not configured")
+	}
+
+	statuses, err := m.peerClusterStatus(ctx, node)
+	if err != nil {
+		return err
+	}
+
+	for _, status := range statuses {
+		if !status.IsOk() {
+			return fmt.Errorf(
+				"node %s has status %s, refusing to restart %s",
+				status.Address,
+				status.Status,
+				node.Info.Host,
+			)
+		}
+	}
+
+	logCtx.Info("draining node")
+	err = m.scylla.Drain(ctx, node)
+	if err != nil {
+		return err
+	}
+
+	logCtx.Infow("restarting node", "command", options.Command)
+	output, err := node.Cmd.Execute(ctx, cmd.Command("sh", "-c", options.Command))
+	if err != nil {
+		return errors.Wrapf(
+			err,
+			"could not restart drained node %s. output:\n%s",
+			node.Info.Host,
+			string(output),
+		)
+	}
+
+	logCtx.Info("waiting for node to come back")
+	err = m.waitForNode(ctx, node, options)
+	if err != nil {
+		return err
+	}
+
+	logCtx.Info("node restarted")
+
+	return nil
+}
+
+// waits until a restarted node is "UN" and the schema is in agreement.
+// Returns an error immediately if any other node goes down.
+func (m *Octopus) waitForNode(ctx context.Context, node *entity.Node, options RollingRestartOptions) error {
+	ctx, cancel := context.WithTimeout(ctx, options.Timeout)
+	defer cancel()
+
+	for {
+		ready, err := m.isNodeReady(ctx, node)
+		if err != nil {
+			return err
+		}
+
+		if ready {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Wrapf(
+				ctx.Err(),
+				"node %s did not come back within %s",
+				node.Info.Host,
+				options.Timeout,
+			)
+		case <-time.After(options.PollInterval):
+		}
+	}
+}
+
+// checks whether a restarted node is "UN" and the schema is in agreement.
+// The other nodes are checked from a healthy peer, because right after startup the gossip view
+// of a restarted node may still show them as down. Returns an error if any other node is not "UN".
+func (m *Octopus) isNodeReady(ctx context.Context, node *entity.Node) (bool, error) {
+	logCtx := m.logger.With("host", node.Info.Host)
+	peerStatuses, err := m.peerClusterStatus(ctx, node)
+	if err != nil {
+		return false, err
+	}
+
+	for _, status := range peerStatuses {
+		if !node.Info.OwnsStatus(status) && !status.IsOk() {
+			return false, fmt.Errorf(
+				"node %s has status %s while waiting for %s to restart, aborting",
+				status.Address,
+				status.Status,
+				node.Info.Host,
+			)
+		}
+	}
+
+	statuses, err := m.scylla.ClusterStatus(ctx, node)
+	if err != nil {
+		// the node is probably still starting up
+		logCtx.Debugw("could not get cluster status", "error", err)
+		return false, nil
+	}
+
+	status, found := node.Info.FindStatus(statuses)
+	if !found {
+		return false, fmt.Errorf(
+			"node %s is missing in its own `nodetool status` after a restart, aborting",
+			node.Info.Host,
+		)
+	}
+
+	if !status.IsOk() {
+		logCtx.Debugw("node is not up yet", "status", status.Status)
+		return false, nil
+	}
+
+	versions, err := m.scylla.SchemaVersions(ctx, node)
+	if err != nil {
+		logCtx.Debugw("could not get schema versions", "error", err)
+		return false, nil
+	}
+
+	if _, hasUnreachable := versions["UNREACHABLE"]; hasUnreachable || len(versions) != 1 {
+		logCtx.Debugw("schema is not in agreement yet", "versions", versions)
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// returns the cluster status as seen by the first available peer of a node.
+// A node without peers (a single-node cluster) reports its own status.
+func (m *Octopus) peerClusterStatus(ctx context.Context, node *entity.Node) ([]entity.NodeStatus, error) {
+	var result *multierror.Error
+
+	for _, peer := range m.cluster.Peers(node.Info.Host) {
+		statuses, err := m.scylla.ClusterStatus(ctx, peer)
+		if err == nil {
+			return statuses, nil
+		}
+
+		result = multierror.Append(result, err)
+	}
+
+	if result != nil {
+		return nil, errors.Wrapf(result, "could not get cluster status from any peer of %s", node.Info.Host)
+	}
+
+	return m.scylla.ClusterStatus(ctx, node)
+}
@@ -0,0 +1,262 @@
+package app
+
+import (
+	"context"
+	clusterPkg "github.com/kolesa-team/scylla-octopus/pkg/cluster"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd/factory"
+	"github.com/kolesa-team/scylla-octopus/pkg/entity"
+	"github.com/kolesa-team/scylla-octopus/pkg/notifier"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+// a test cluster with 2 hosts: 127.0.0.1, 127.0.0.2
+func newTestRestartCluster(t *testing.T) *clusterPkg.Cluster {
+	clusterInstance := clusterPkg.NewCluster(
+		clusterPkg.Options{
+			Hosts:          []string{"127.0.0.1", "127.0.0.2"},
+			SkipDnsResolve: true,
+		},
+		factory.NewTestFactory(),
+		nil,
+		zap.S(),
+	)
+	require.NoError(t, clusterInstance.Connect(context.Background()).Error())
+
+	return clusterInstance
+}
+
+// a restart command that does nothing on a local machine
+var testRestartOptions = RollingRestartOptions{
+	Command:      "true",
+	PollInterval: time.Millisecond,
+	Timeout:      time.Second,
+}
+
+func TestOctopus_RollingRestart_Ok(t *testing.T) {
+	db := testDb{
+		clusterStatus: []entity.NodeStatus{
+			{Address: "127.0.0.1", Status: entity.NodeStatusOk},
+			{Address: "127.0.0.2", Status: entity.NodeStatusOk},
+		},
+		schemaVersions: map[string][]string{
+			"497cda5f-fdb2-3b57-8b68-611d4c6200f5": {"127.0.0.1", "127.0.0.2"},
+		},
+	}
+	app := NewOctopus(
+		newTestRestartCluster(t),
+		db,
+		testBackupService{},
+		testStorage{},
+		&testRepairHistory{},
+		&testRunHistory{},
+		ParallelismOptions{},
+		notifier.Disabled{},
+		zap.S(),
+	)
+
+	result := app.RollingRestart(context.Background(), testRestartOptions)
+
+	require.NoError(t, result.Error)
+	require.Equal(t, 2, result.TotalNodes)
+	require.Equal(t, 2, result.RestartedNodes)
+}
+
+// Right after a restart, a node may still see its peers as down;
+// the other nodes are checked from a peer, so the restart goes on
+func TestOctopus_RollingRestart_StaleStatusOfRestartedNode(t *testing.T) {
+	db := testDb{
+		clusterStatus: []entity.NodeStatus{
+			{Address: "127.0.0.1", Status: entity.NodeStatusOk},
+			{Address: "127.0.0.2", Status: entity.NodeStatusOk},
+		},
+		clusterStatusAfterDrain: []entity.NodeStatus{
+			{Address: "127.0.0.1", Status: entity.NodeStatusOk},
+			{Address: "127.0.0.2", Status: "DN"},
+		},
+		staleStatusCalls: map[string]int{},
+		schemaVersions: map[string][]string{
+			"497cda5f-fdb2-3b57-8b68-611d4c6200f5": {"127.0.0.1", "127.0.0.2"},
+		},
+	}
+	app := NewOctopus(
+		newTestRestartCluster(t),
+		db,
+		testBackupService{},
+		testStorage{},
+		&testRepairHistory{},
+		&testRunHistory{},
+		ParallelismOptions{},
+		notifier.Disabled{},
+		zap.S(),
+	)
+
+	result := app.RollingRestart(context.Background(), testRestartOptions)
+
+	require.NoError(t, result.Error)
+	require.Equal(t, 2, result.RestartedNodes)
+}
+
+func TestOctopus_RollingRestart_OtherNodeDown(t *testing.T) {
+	db := testDb{
+		// a node outside the configured hosts is down
+		clusterStatus: []entity.NodeStatus{
+			{Address: "127.0.0.1", Status: entity.NodeStatusOk},
+			{Address: "127.0.0.2", Status: entity.NodeStatusOk},
+			{Address: "127.0.0.3", Status: "DN"},
+		},
+	}
+	app := NewOctopus(
+		newTestRestartCluster(t),
+		db,
+		testBackupService{},
+		testStorage{},
+		&testRepairHistory{},
+		&testRunHistory{},
+		ParallelismOptions{},
+		notifier.Disabled{},
+		zap.S(),
+	)
+
+	result := app.RollingRestart(context.Background(), testRestartOptions)
+
+	require.Error(t, result.Error)
+	require.Contains(t, result.Error.Error(), "node 127.0.0.3 has status DN")
+	require.Equal(t, 0, result.RestartedNodes, "no nodes must be restarted")
+}
+
+func TestOctopus_RollingRestart_NoSchemaAgreement(t *testing.T) {
+	db := testDb{
+		clusterStatus: []entity.NodeStatus{
+			{Address: "127.0.0.1", Status: entity.NodeStatusOk},
+			{Address: "127.0.0.2", Status: entity.NodeStatusOk},
+		},
+		// the schema versions never agree, so a restart must time out
+		schemaVersions: map[string][]string{
+			"497cda5f-fdb2-3b57-8b68-611d4c6200f5": {"127.0.0.1"},
+			"5e1e7b4a-8a77-3b2c-a3c0-7a5e0cbe2c5e": {"127.0.0.2"},
+		},
+	}
+	options := testRestartOptions
+	options.Timeout = time.Millisecond * 20
+	app := NewOctopus(
+		newTestRestartCluster(t),
+		db,
+		testBackupService{},
+		testStorage{},
+		&testRepairHistory{},
+		&testRunHistory{},
+		ParallelismOptions{},
+		notifier.Disabled{},
+		zap.S(),
+	)
+
+	result := app.RollingRestart(context.Background(), options)
+
+	require.Error(t, result.Error)
+	require.Contains(t, result.Error.Error(), "did not come back")
+	require.Equal(t, 0, result.RestartedNodes, "the restart must stop after the first node")
+}
+
+// The restart command is executed by a shell
+func TestOctopus_RollingRestart_ShellCommand(t *testing.T) {
+	db := testDb{
+		clusterStatus: []entity.NodeStatus{
+			{Address: "127.0.0.1", Status: entity.NodeStatusOk},
+			{Address: "127.0.0.2", Status: entity.NodeStatusOk},
+		},
+		schemaVersions: map[string][]string{
+			"497cda5f-fdb2-3b57-8b68-611d4c6200f5": {"127.0.0.1", "127.0.0.2"},
+		},
+	}
+	options := testRestartOptions
+	options.Command = `test "a b" = "a b" && echo restarted | grep -q restarted`
+	app := NewOctopus(
+		newTestRestartCluster(t),
+		db,
+		testBackupService{},
+		testStorage{},
+		&testRepairHistory{},
+		&testRunHistory{},
+		ParallelismOptions{},
+		notifier.Disabled{},
+		zap.S(),
+	)
+
+	result := app.RollingRestart(context.Background(), options)
+
+	require.NoError(t, result.Error)
+	require.Equal(t, 2, result.RestartedNodes)
+}
+
+// The nodes configured by hostnames are found in `nodetool status` by their host IDs
+func TestOctopus_RollingRestart_Hostnames(t *testing.T) {
+	clusterInstance := clusterPkg.NewCluster(
+		clusterPkg.Options{
+			Hosts:          []string{"db-1", "db-2"},
+			SkipDnsResolve: true,
+		},
+		factory.NewTestFactory(),
+		nil,
+		zap.S(),
+	)
+	require.NoError(t, clusterInstance.Connect(context.Background()).Error())
+	clusterInstance.Peers("db-2")[0].Info.HostId = "a36d1408-f32b-469f-b22e-df314becc200"
+	clusterInstance.Peers("db-1")[0].Info.HostId = "985284bd-6373-480d-9780-05cbf82954de"
+
+	db := testDb{
+		clusterStatus: []entity.NodeStatus{
+			{Address: "10.0.0.1", Status: entity.NodeStatusOk, HostId: "a36d1408-f32b-469f-b22e-df314becc200"},
+			{Address: "10.0.0.2", Status: entity.NodeStatusOk, HostId: "985284bd-6373-480d-9780-05cbf82954de"},
+		},
+		schemaVersions: map[string][]string{
+			"497cda5f-fdb2-3b57-8b68-611d4c6200f5": {"10.0.0.1", "10.0.0.2"},
+		},
+	}
+	app := NewOctopus(
+		clusterInstance,
+		db,
+		testBackupService{},
+		testStorage{},
+		&testRepairHistory{},
+		&testRunHistory{},
+		ParallelismOptions{},
+		notifier.Disabled{},
+		zap.S(),
+	)
+
+	result := app.RollingRestart(context.Background(), testRestartOptions)
+
+	require.NoError(t, result.Error)
+	require.Equal(t, 2, result.RestartedNodes)
+}
+
+// A node that is missing in its own `nodetool status` fails immediately instead of waiting for a timeout
+func TestOctopus_RollingRestart_MissingNode(t *testing.T) {
+	db := testDb{
+		clusterStatus: []entity.NodeStatus{
+			{Address: "127.0.0.3", Status: entity.NodeStatusOk},
+		},
+	}
+	options := testRestartOptions
+	options.Timeout = time.Minute
+	app := NewOctopus(
+		newTestRestartCluster(t),
+		db,
+		testBackupService{},
+		testStorage{},
+		&testRepairHistory{},
+		&testRunHistory{},
+		ParallelismOptions{},
+		notifier.Disabled{},
+		zap.S(),
+	)
+
+	result := app.RollingRestart(context.Background(), options)
+
+	require.Error(t, result.Error)
+	require.Contains(t, result.Error.Error(), "is missing in its own `nodetool status`")
+	require.Equal(t, 0, result.RestartedNodes)
+}
@@ -2,8 +2,13 @@ package app
 
 import (
 	"context"
+	"fmt"
 	"github.com/kolesa-team/scylla-octopus/pkg/cmd"
 	"github.com/kolesa-team/scylla-octopus/pkg/entity"
+	"github.com/kolesa-team/scylla-octopus/pkg/notifier"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 )
 
@@ -11,7 +16,9 @@ import (
 
 // testCluster operations always return whatever is given in structure properties
 type testCluster struct {
-	nodeCount       int
+	nodeCount int
+	// the number of nodes regardless of the datacenter and rack filters (nodeCount by default)
+	totalNodeCount  int
 	callbackResults entity.NodeCallbackResults
 }
 
@@ -23,6 +30,14 @@ func (t testCluster) RunParallel(ctx context.Context, callback entity.NodeCallba
 	return t.callbackResults
 }
 
+func (t testCluster) RunLimited(
+	ctx context.Context,
+	parallelism entity.Parallelism,
+	callback entity.NodeCallback,
+) entity.NodeCallbackResults {
+	return t.callbackResults
+}
+
 func (t testCluster) Connect(ctx context.Context) entity.NodeCallbackResults {
 	return t.callbackResults
 }
@@ -31,11 +46,31 @@ func (t testCluster) Size() int {
 	return t.nodeCount
 }
 
+func (t testCluster) Peers(host string) []*entity.Node {
+	return nil
+}
+
+func (t testCluster) TotalSize() int {
+	if t.totalNodeCount > 0 {
+		return t.totalNodeCount
+	}
+
+	return t.nodeCount
+}
+
 // testDb operations always return whatever is given in structure properties
 type testDb struct {
-	err          error
-	snapshots    entity.Snapshots
-	repairResult entity.RepairResult
+	err           error
+	snapshots     entity.Snapshots
+	repairResult  entity.RepairResult
+	tables        []entity.Table
+	maintenance   entity.MaintenanceResult
+	clusterStatus []entity.NodeStatus
+	// the statuses returned once by a drained node (e.g. a stale gossip view after a restart);
+	// enabled by a non-nil staleStatusCalls
+	clusterStatusAfterDrain []entity.NodeStatus
+	staleStatusCalls        map[string]int
+	schemaVersions          map[string][]string
 }
 
 func (t testDb) Healthcheck(ctx context.Context, node *entity.Node) error {
@@ -50,6 +85,40 @@ func (t testDb) Repair(ctx context.Context, node *entity.Node) (entity.RepairRes
 	return t.repairResult, t.err
 }
 
+func (t testDb) ListTables(ctx context.Context, node *entity.Node) ([]entity.Table, error) {
+	return t.tables, t.err
+}
+
+func (t testDb) Maintenance(
+	ctx context.Context,
+	node *entity.Node,
+	operation entity.MaintenanceOperation,
+	filter entity.MaintenanceFilter,
+) (entity.MaintenanceResult, error) {
+	return t.maintenance, t.err
+}
+
+func (t testDb) ClusterStatus(ctx context.Context, node *entity.Node) ([]entity.NodeStatus, error) {
+	if t.staleStatusCalls[node.Info.Host] > 0 {
+		t.staleStatusCalls[node.Info.Host]--
+		return t.clusterStatusAfterDrain, t.err
+	}
+
+	return t.clusterStatus, t.err
+}
+
+func (t testDb) Drain(ctx context.Context, node *entity.Node) error {
+	if t.staleStatusCalls != nil {
+		t.staleStatusCalls[node.Info.Host] = 1
+	}
+
+	return t.err
+}
+
+func (t testDb) SchemaVersions(ctx context.Context, node *entity.Node) (map[string][]string, error) {
+	return t.schemaVersions, t.err
+}
+
 // testBackupService operations always return whatever is given in structure properties
 type testBackupService struct {
 	err                 error
@@ -77,6 +146,11 @@ func (t testBackupService) ListExpiredBackups(ctx context.Context, node *entity.
 type testStorage struct {
 	err     error
 	backups []entity.RemoteBackup
+	files   []entity.RemoteFile
+	// file contents by path
+	contents map[string]string
+	// file contents by path relative to a backup, written to a local directory by Download
+	downloaded map[string]string
 }
 
 func (t testStorage) Healthcheck(ctx context.Context, cmdExecutor cmd.Executor) error {
@@ -86,3 +160,100 @@ func (t testStorage) Healthcheck(ctx context.Context, cmdExecutor cmd.Executor)
 func (t testStorage) ListBackups(ctx context.Context, cmdExecutor cmd.Executor, basePath string) ([]entity.RemoteBackup, error) {
 	return t.backups, t.err
 }
+
+func (t testStorage) ListHostBackups(
+	ctx context.Context,
+	cmdExecutor cmd.Executor,
+	basePath, host string,
+	datacenters []string,
+) ([]entity.RemoteBackup, error) {
+	backups := []entity.RemoteBackup{}
+	for _, backup := range t.backups {
+		// basePath/datacenter/host/...
+		parts := strings.Split(strings.TrimPrefix(backup.Path, basePath+"/"), "/")
+		if len(parts) < 2 || parts[1] != host {
+			continue
+		}
+
+		selected := len(datacenters) == 0
+		for _, datacenter := range datacenters {
+			selected = selected || datacenter == parts[0]
+		}
+
+		if selected {
+			backups = append(backups, backup)
+		}
+	}
+
+	return backups, t.err
+}
+
+func (t testStorage) ListFiles(ctx context.Context, cmdExecutor cmd.Executor, path string) ([]entity.RemoteFile, error) {
+	return t.files, t.err
+}
+
+func (t testStorage) ReadFile(ctx context.Context, cmdExecutor cmd.Executor, path string) ([]byte, error) {
+	content, ok := t.contents[path]
+	if !ok {
+		return nil, fmt.Errorf("file %s is not found", path)
+	}
+
+	return []byte(content), t.err
+}
+
+func (t testStorage) Download(ctx context.Context, cmdExecutor cmd.Executor, path, localPath string) error {
+	for name, content := range t.downloaded {
+		err := os.MkdirAll(filepath.Dir(localPath+"/"+name), 0700)
+		if err != nil {
+			return err
+		}
+
+		err = os.WriteFile(localPath+"/"+name, []byte(content), 0600)
+		if err != nil {
+			return err
+		}
+	}
+
+	return t.err
+}
+
+// testRepairHistory keeps repair runs in memory
+type testRepairHistory struct {
+	err  error
+	runs entity.RepairHistory
+}
+
+func (t *testRepairHistory) Add(run entity.RepairRun) error {
+	t.runs = append(t.runs, run)
+
+	return t.err
+}
+
+func (t *testRepairHistory) List() (entity.RepairHistory, error) {
+	return t.runs, t.err
+}
+
+// testRunHistory keeps operation runs in memory
+type testRunHistory struct {
+	err  error
+	runs entity.RunHistory
+}
+
+func (t *testRunHistory) Add(run entity.OperationRun) error {
+	t.runs = append(t.runs, run)
+
+	return t.err
+}
+
+func (t *testRunHistory) List() (entity.RunHistory, error) {
+	return t.runs, t.err
+}
+
+// testNotifier keeps notifications in memory
+type testNotifier struct {
+	messages []notifier.Message
+}
+
+func (t *testNotifier) Notify(msg notifier.Message) {
+	t.messages = append(t.messages, msg)
+}
@@ -2,10 +2,18 @@ package cmd
 
 import (
 	"fmt"
+	"github.com/kolesa-team/scylla-octopus/app"
+	"github.com/kolesa-team/scylla-octopus/pkg/entity"
 	"github.com/spf13/cobra"
+	"time"
 )
 
 var (
+	// overrides backup.maxAge in `backup check-freshness`
+	backupMaxAge time.Duration
+	// `backup download` settings
+	downloadOptions app.DownloadOptions
+
 	backupCmd = &cobra.Command{
 		Use:   "backup",
 		Short: "backup-related commands",
@@ -14,19 +22,13 @@ var (
 		Use:   "run",
 		Short: "runs a backup (exports database schema and snapshot, uploads to remote storage, cleans up)",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			_, err := env.App.Healthcheck(cmd.Context())
+			err := healthcheckBefore(cmd, "creating backups")
 			if err != nil {
-				env.Notifier.Error(
-					"Could not perform a healthcheck before creating backups.",
-					"",
-					err,
-					nil,
-				)
 				return err
 			}
 
 			result := env.App.Backup(cmd.Context())
-			fmt.Println(result.Report())
+			printOutput(result)
 
 			return result.Error
 		},
@@ -41,7 +43,7 @@ var (
 			}
 
 			expired, err := env.App.CleanupExpiredBackups(cmd.Context())
-			printJson(expired)
+			printOutput(expired)
 
 			return err
 		},
@@ -56,11 +58,35 @@ var (
 			}
 
 			expired, err := env.App.ListExpiredBackups(cmd.Context())
-			printJson(expired)
+			printOutput(expired)
 
 			return err
 		},
 	}
+	backupCheckFreshness = &cobra.Command{
+		Use:   "check-freshness",
+		Short: "fails and notifies if the newest backup of any node in remote storage is older than backup.maxAge, or missing",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			maxAge := env.Config.Backup.MaxAge
+			if cmd.Flags().Changed("max-age") {
+				if backupMaxAge <= 0 {
+					return fmt.Errorf("invalid --max-age %s (expected a positive duration)", backupMaxAge)
+				}
+
+				maxAge = backupMaxAge
+			}
+
+			err := healthcheckBefore(cmd, "checking backup freshness")
+			if err != nil {
+				return err
+			}
+
+			results := env.App.CheckBackupFreshness(cmd.Context(), time.Now(), maxAge)
+			printOutput(results)
+
+			return results.Error
+		},
+	}
 	backupList = &cobra.Command{
 		Use:   "list",
 		Short: "prints a list of existing backups in remote storage",
@@ -71,7 +97,61 @@ var (
 			}
 
 			expired, err := env.App.ListBackups(cmd.Context())
-			printJson(expired)
+			printOutput(expired)
+
+			return err
+		},
+	}
+	backupDescribe = &cobra.Command{
+		Use:   "describe <host> <date|latest>",
+		Short: "prints the contents of a backup in remote storage: size, files, metadata and schema",
+		Example: `  scylla-octopus backup describe scylla-node1 09-07-2021-10-29
+  scylla-octopus backup describe 10.0.0.1 latest`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, err := env.App.Healthcheck(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			description, err := env.App.DescribeBackup(cmd.Context(), args[0], args[1])
+			if err != nil {
+				return err
+			}
+
+			printOutput(description)
+
+			return nil
+		},
+	}
+	backupDownload = &cobra.Command{
+		Use:   "download <host> <date|latest> <dir>",
+		Short: "downloads a backup from remote storage to a local directory without connecting to the database nodes, verifies and optionally extracts it",
+		Example: `  scylla-octopus backup download scylla-node1 latest /tmp/scylla-node1
+  scylla-octopus backup download 10.0.0.1 09-07-2021-10-29 /tmp/backup --datacenter dc1 --decompress`,
+		Args: cobra.ExactArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// nothing is downloaded in dry-run mode, so there's nothing to verify
+			downloadOptions.Verify = !env.Config.Commands.DryRun
+			if len(downloadOptions.Cluster) == 0 {
+				downloadOptions.Cluster = env.Config.Cluster.ClusterName
+			}
+			downloadOptions.Datacenters = env.Config.Cluster.Datacenters
+
+			// the backups are stored by a short domain name; an ip address is resolved locally
+			host := entity.NewNodeInfo(args[0], "", entity.NodeBinaries{}, !env.Config.Cluster.SkipDnsResolve).ShortDomainName()
+
+			download, err := env.App.DownloadBackup(
+				cmd.Context(),
+				env.CmdFactory.Local(),
+				host,
+				args[1],
+				args[2],
+				downloadOptions,
+			)
+			if len(download.Path) > 0 {
+				printOutput(download)
+			}
 
 			return err
 		},
@@ -83,5 +163,32 @@ func init() {
 	backupCmd.AddCommand(backupCleanupExpired)
 	backupCmd.AddCommand(backupList)
 	backupCmd.AddCommand(backupListExpired)
+	backupCmd.AddCommand(backupDescribe)
+	backupDownload.Flags().BoolVar(
+		&downloadOptions.Decompress,
+		"decompress",
+		false,
+		"extract backup.tar.<method> of an archived backup (the archive is kept)",
+	)
+	backupDownload.Flags().StringVar(
+		&downloadOptions.Cluster,
+		"cluster",
+		"",
+		"the cluster name in remote storage (cluster.clusterName by default)",
+	)
+	backupDownload.Flags().StringVar(
+		&downloadOptions.Datacenter,
+		"datacenter",
+		"",
+		"the datacenter of the host (cluster.datacenters or any datacenter in remote storage by default)",
+	)
+	backupCmd.AddCommand(backupDownload)
+	backupCheckFreshness.Flags().DurationVar(
+		&backupMaxAge,
+		"max-age",
+		0,
+		"the maximum age of the newest backup of every node (overrides backup.maxAge)",
+	)
+	backupCmd.AddCommand(backupCheckFreshness)
 	rootCmd.AddCommand(backupCmd)
 }
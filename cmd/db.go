@@ -2,7 +2,9 @@ package cmd
 
 import (
 	"fmt"
+	"github.com/kolesa-team/scylla-octopus/pkg/entity"
 	"github.com/spf13/cobra"
+	"time"
 )
 
 var (
@@ -20,7 +22,7 @@ var (
 			}
 
 			snapshots, err := env.App.ListSnapshots(cmd.Context())
-			printJson(snapshots)
+			printOutput(snapshots)
 
 			return err
 		},
@@ -29,28 +31,133 @@ var (
 		Use:   "repair",
 		Short: "executes 'nodetool repair -pr' on database nodes",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			_, err := env.App.Healthcheck(cmd.Context())
+			err := healthcheckBefore(cmd, "running repair")
 			if err != nil {
-				env.Notifier.Error(
-					"Could not perform a healthcheck before running repair.",
-					"",
-					err,
-					nil,
-				)
-
 				return err
 			}
 
 			results := env.App.Repair(cmd.Context())
-			fmt.Println(results.Report())
+			printOutput(results)
+
+			return results.Error
+		},
+	}
+	dbRollingRestartCmd = &cobra.Command{
+		Use:   "rolling-restart",
+		Short: "restarts database nodes one by one, waiting for each node to be up and the schema to be in agreement",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := healthcheckBefore(cmd, "a rolling restart")
+			if err != nil {
+				return err
+			}
+
+			results := env.App.RollingRestart(cmd.Context(), env.Config.Restart)
+			printOutput(results)
 
 			return results.Error
 		},
 	}
+	dbRepairHistoryCmd = &cobra.Command{
+		Use:   "repair-history",
+		Short: "prints the history of repair runs with the results of every table",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			history, err := env.App.RepairHistory()
+			if err != nil {
+				return err
+			}
+
+			printOutput(history)
+
+			return nil
+		},
+	}
+	dbRepairDueCmd = &cobra.Command{
+		Use:   "repair-due",
+		Short: "prints the tables whose last repair is older than a fraction of their gc_grace_seconds",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, err := env.App.Healthcheck(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			tables, err := env.App.RepairDue(cmd.Context(), time.Now(), env.Config.Repair.DueFraction)
+			if err != nil {
+				return err
+			}
+
+			printOutput(entity.RepairDueTables(tables))
+
+			return nil
+		},
+	}
 )
 
+// descriptions of maintenance commands
+var maintenanceDescriptions = map[entity.MaintenanceOperation]string{
+	entity.MaintenanceCleanup:         "executes 'nodetool cleanup' on database nodes (removes the data that no longer belongs to a node)",
+	entity.MaintenanceCompact:         "executes 'nodetool compact' on database nodes (forces a major compaction)",
+	entity.MaintenanceScrub:           "executes 'nodetool scrub' on database nodes (rebuilds sstables, discarding the corrupted data)",
+	entity.MaintenanceUpgradeSSTables: "executes 'nodetool upgradesstables' on database nodes (rewrites sstables to the current format)",
+	entity.MaintenanceFlush:           "executes 'nodetool flush' on database nodes (flushes memtables to disk)",
+}
+
+// creates a command that executes a given maintenance operation
+func newMaintenanceCmd(operation entity.MaintenanceOperation) *cobra.Command {
+	var filter entity.MaintenanceFilter
+	var parallel bool
+
+	command := &cobra.Command{
+		Use:   string(operation),
+		Short: maintenanceDescriptions[operation],
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := filter.Validate()
+			if err != nil {
+				return err
+			}
+
+			err = healthcheckBefore(cmd, fmt.Sprintf("running %s", operation))
+			if err != nil {
+				return err
+			}
+
+			results := env.App.Maintenance(cmd.Context(), operation, filter, parallel)
+			printOutput(results)
+
+			return results.Error
+		},
+	}
+
+	command.Flags().StringSliceVar(
+		&filter.Keyspaces,
+		"keyspace",
+		nil,
+		"keyspaces to process (all keyspaces by default)",
+	)
+	command.Flags().StringSliceVar(
+		&filter.Tables,
+		"table",
+		nil,
+		"tables to process (requires a single --keyspace)",
+	)
+	command.Flags().BoolVar(
+		&parallel,
+		"parallel",
+		false,
+		"run on all nodes at once instead of one by one",
+	)
+
+	return command
+}
+
 func init() {
+	for _, operation := range entity.MaintenanceOperations {
+		dbCmd.AddCommand(newMaintenanceCmd(operation))
+	}
+
 	dbCmd.AddCommand(dbListSnapshotsCmd)
 	dbCmd.AddCommand(dbRepairCmd)
+	dbCmd.AddCommand(dbRepairHistoryCmd)
+	dbCmd.AddCommand(dbRepairDueCmd)
+	dbCmd.AddCommand(dbRollingRestartCmd)
 	rootCmd.AddCommand(dbCmd)
 }
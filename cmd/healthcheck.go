@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"fmt"
+	"github.com/kolesa-team/scylla-octopus/pkg/notifier"
 	"github.com/spf13/cobra"
 )
 
@@ -18,6 +20,30 @@ func init() {
 
 func healthcheck(cmd *cobra.Command, _ []string) error {
 	info, err := env.App.Healthcheck(cmd.Context())
-	printJson(info)
+	printOutput(info)
 	return err
 }
+
+// performs a healthcheck before a given action (e.g. "creating backups"), and notifies about the result.
+// a passed healthcheck is only sent to the notification channels if it follows a failed one.
+func healthcheckBefore(cmd *cobra.Command, action string) error {
+	_, err := env.App.Healthcheck(cmd.Context())
+	if err != nil {
+		env.Notifier.Notify(notifier.Message{
+			Operation: notifier.OperationHealthcheck,
+			Severity:  notifier.SeverityError,
+			Header:    fmt.Sprintf("Could not perform a healthcheck before %s.", action),
+			Err:       err,
+		})
+
+		return err
+	}
+
+	env.Notifier.Notify(notifier.Message{
+		Operation: notifier.OperationHealthcheck,
+		Severity:  notifier.SeverityInfo,
+		Header:    fmt.Sprintf("Healthcheck passed before %s.", action),
+	})
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/kolesa-team/scylla-octopus/pkg/entity"
+	"github.com/spf13/cobra"
+	"time"
+)
+
+// filters of operation runs
+var (
+	historyOperation string
+	historyFrom      string
+	historyTo        string
+)
+
+var (
+	historyCmd = &cobra.Command{
+		Use:   "history",
+		Short: "the history of operation runs (backups, repairs, etc.)",
+	}
+	historyListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "prints the recorded runs of operations",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			runs, err := filteredRunHistory()
+			if err != nil {
+				return err
+			}
+
+			printOutput(runs)
+
+			return nil
+		},
+	}
+	historyShowCmd = &cobra.Command{
+		Use:   "show <id>",
+		Short: "prints a run with the outcome of every node",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			runs, err := env.App.RunHistory()
+			if err != nil {
+				return err
+			}
+
+			run, ok := runs.Find(args[0])
+			if !ok {
+				return fmt.Errorf("run %s not found", args[0])
+			}
+
+			printOutput(run)
+
+			return nil
+		},
+	}
+	historyStatsCmd = &cobra.Command{
+		Use:   "stats",
+		Short: "prints the success rate of every operation (e.g. for monthly SLA reports)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			runs, err := filteredRunHistory()
+			if err != nil {
+				return err
+			}
+
+			printOutput(runs.Stats())
+
+			return nil
+		},
+	}
+)
+
+// returns the runs matching the --operation, --from and --to flags
+func filteredRunHistory() (entity.RunHistory, error) {
+	from, err := parseHistoryDate(historyFrom)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := parseHistoryDate(historyTo)
+	if err != nil {
+		return nil, err
+	}
+
+	runs, err := env.App.RunHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	return runs.Filter(historyOperation, from, to), nil
+}
+
+// parses a date like 2021-10-01 or 2021-10-01T15:00:00Z; an empty string is a zero date
+func parseHistoryDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	date, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err == nil {
+		return date, nil
+	}
+
+	date, err = time.Parse(time.RFC3339, value)
+	if err != nil {
+		return date, fmt.Errorf("invalid date %s (expected 2006-01-02 or 2006-01-02T15:04:05Z07:00)", value)
+	}
+
+	return date, nil
+}
+
+func init() {
+	for _, command := range []*cobra.Command{historyListCmd, historyStatsCmd} {
+		command.Flags().StringVar(
+			&historyOperation,
+			"operation",
+			"",
+			"only the runs of a given operation (backup, cleanup, repair, restart, maintenance-compact, etc.)",
+		)
+		command.Flags().StringVar(
+			&historyFrom,
+			"from",
+			"",
+			"only the runs started at or after a given date (e.g. 2021-10-01)",
+		)
+		command.Flags().StringVar(
+			&historyTo,
+			"to",
+			"",
+			"only the runs started before a given date (e.g. 2021-11-01)",
+		)
+	}
+
+	historyCmd.AddCommand(historyListCmd)
+	historyCmd.AddCommand(historyShowCmd)
+	historyCmd.AddCommand(historyStatsCmd)
+	rootCmd.AddCommand(historyCmd)
+}
@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestParseHistoryDate(t *testing.T) {
+	date, err := parseHistoryDate("")
+	require.NoError(t, err)
+	require.True(t, date.IsZero())
+
+	date, err = parseHistoryDate("2021-10-01")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2021, 10, 1, 0, 0, 0, 0, time.Local), date)
+
+	date, err = parseHistoryDate("2021-10-01T15:00:00Z")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2021, 10, 1, 15, 0, 0, 0, time.UTC), date)
+
+	_, err = parseHistoryDate("yesterday")
+	require.Error(t, err)
+}
@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/go-yaml/yaml"
+	"github.com/kolesa-team/scylla-octopus/pkg/entity"
+	"github.com/pkg/errors"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// output formats (see the --output flag)
+const (
+	outputJson  = "json"
+	outputYaml  = "yaml"
+	outputTable = "table"
+	outputText  = "text"
+)
+
+// results with a human-readable report (e.g. entity.BackupResults)
+type reporter interface {
+	Report() string
+}
+
+// results with a tabular view (e.g. entity.RemoteBackupsByHost)
+type tabular interface {
+	Table() entity.OutputTable
+}
+
+func validateOutputFormat(format string) error {
+	switch format {
+	case "", outputJson, outputYaml, outputTable, outputText:
+		return nil
+	default:
+		return fmt.Errorf("unknown output format: %s (expected json, yaml, table or text)", format)
+	}
+}
+
+// prints command results in the format given by the --output flag.
+// by default, the results with a report are printed as text, and the others as json.
+func printOutput(data interface{}) {
+	err := writeOutput(os.Stdout, data, outputFormat)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "could not print results:", err)
+	}
+}
+
+func writeOutput(w io.Writer, data interface{}, format string) error {
+	if format == "" {
+		format = outputJson
+		if _, ok := data.(reporter); ok {
+			format = outputText
+		}
+	}
+
+	switch format {
+	case outputYaml:
+		return writeYaml(w, data)
+	case outputTable:
+		return writeTable(w, data)
+	case outputText:
+		if report, ok := data.(reporter); ok {
+			_, err := fmt.Fprintln(w, report.Report())
+			return err
+		}
+
+		return writeTable(w, data)
+	default:
+		dataJson, err := json.MarshalIndent(data, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "could not encode results")
+		}
+
+		_, err = fmt.Fprintln(w, string(dataJson))
+		return err
+	}
+}
+
+// prints results as yaml. the results are converted to json first, so that they look the same in both formats
+// (e.g. errors are encoded as strings by entity types)
+func writeYaml(w io.Writer, data interface{}) error {
+	value, err := toJsonValue(data)
+	if err != nil {
+		return err
+	}
+
+	dataYaml, err := yaml.Marshal(value)
+	if err != nil {
+		return errors.Wrap(err, "could not encode results")
+	}
+
+	_, err = w.Write(dataYaml)
+	return err
+}
+
+// prints results as a table: a tabular view if the results have one,
+// or every value by its path (e.g. "127.0.0.1.status") otherwise
+func writeTable(w io.Writer, data interface{}) error {
+	var table entity.OutputTable
+
+	if t, ok := data.(tabular); ok {
+		table = t.Table()
+	} else {
+		value, err := toJsonValue(data)
+		if err != nil {
+			return err
+		}
+
+		table.Header = []string{"KEY", "VALUE"}
+		flatten("", value, &table.Rows)
+		sort.Slice(table.Rows, func(i, j int) bool {
+			return table.Rows[i][0] < table.Rows[j][0]
+		})
+	}
+
+	writer := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, strings.Join(table.Header, "\t"))
+	for _, row := range table.Rows {
+		fmt.Fprintln(writer, strings.Join(row, "\t"))
+	}
+
+	return writer.Flush()
+}
+
+// converts results to generic json values (maps, slices, strings, numbers and booleans)
+func toJsonValue(data interface{}) (interface{}, error) {
+	dataJson, err := json.Marshal(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not encode results")
+	}
+
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(dataJson))
+	// keeps large integers (e.g. durations in nanoseconds) out of the exponent notation
+	decoder.UseNumber()
+	err = decoder.Decode(&value)
+
+	return fromJsonNumbers(value), errors.Wrap(err, "could not encode results")
+}
+
+// replaces json numbers with integers or floats
+func fromJsonNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if integer, err := v.Int64(); err == nil {
+			return integer
+		}
+
+		float, _ := v.Float64()
+		return float
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = fromJsonNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = fromJsonNumbers(item)
+		}
+	}
+
+	return value
+}
+
+// adds a row for every scalar json value by its path
+func flatten(path string, value interface{}, rows *[][]string) {
+	join := func(key string) string {
+		if path == "" {
+			return key
+		}
+
+		return path + "." + key
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			flatten(join(key), item, rows)
+		}
+	case []interface{}:
+		for i, item := range v {
+			flatten(join(fmt.Sprintf("%d", i)), item, rows)
+		}
+	case nil:
+		*rows = append(*rows, []string{path, ""})
+	default:
+		*rows = append(*rows, []string{path, fmt.Sprintf("%v", v)})
+	}
+}
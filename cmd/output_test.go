@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"github.com/kolesa-team/scylla-octopus/pkg/entity"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestWriteOutput(t *testing.T) {
+	results := entity.RepairResults{
+		TotalNodes:    2,
+		RepairedNodes: 1,
+		ByHost: map[string]entity.RepairResult{
+			"127.0.0.1": {Duration: time.Second},
+		},
+		Error: errors.New("could not repair 127.0.0.2"),
+	}
+
+	output := func(data interface{}, format string) string {
+		var buf bytes.Buffer
+		require.NoError(t, writeOutput(&buf, data, format))
+
+		return buf.String()
+	}
+
+	// a report by default
+	require.Equal(t, results.Report()+"\n", output(results, ""))
+	require.Equal(t, results.Report()+"\n", output(results, outputText))
+
+	// errors are strings rather than empty objects
+	require.Contains(t, output(results, outputJson), `"Error": "could not repair 127.0.0.2"`)
+	require.Contains(t, output(results, outputYaml), "Error: could not repair 127.0.0.2\n")
+	require.Contains(t, output(results, outputYaml), "Duration: 1000000000\n")
+
+	require.Equal(t, `HOST       DURATION  TABLES  FAILED TABLES
+127.0.0.1  1s        0       0
+`, output(results, outputTable))
+}
+
+// Results without a report are printed as json by default, and as a list of values in a table
+func TestWriteOutput_Generic(t *testing.T) {
+	info := map[string]string{
+		"127.0.0.1": "ok",
+		"127.0.0.2": "connection refused",
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, writeOutput(&buf, info, ""))
+	require.JSONEq(t, `{"127.0.0.1": "ok", "127.0.0.2": "connection refused"}`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, writeOutput(&buf, info, outputTable))
+	require.Equal(t, `KEY        VALUE
+127.0.0.1  ok
+127.0.0.2  connection refused
+`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, writeOutput(&buf, []interface{}{map[string]int{"a": 1}, nil}, outputText))
+	require.Equal(t, "KEY  VALUE\n0.a  1\n1    \n", buf.String())
+}
+
+func TestValidateOutputFormat(t *testing.T) {
+	require.NoError(t, validateOutputFormat(""))
+	require.NoError(t, validateOutputFormat("yaml"))
+	require.Error(t, validateOutputFormat("xml"))
+}
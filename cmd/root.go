@@ -2,24 +2,34 @@ package cmd
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"github.com/kolesa-team/scylla-octopus/pkg/entity"
 	"github.com/kolesa-team/scylla-octopus/pkg/environment"
+	"github.com/kolesa-team/scylla-octopus/pkg/notifier"
 	"github.com/spf13/cobra"
 	"os"
+	"strings"
 )
 
 var (
 	env              environment.Environment
 	configPath       string
 	forceVerboseMode bool
+	datacenters      []string
+	racks            []string
+	dryRun           bool
+	outputFormat     string
 	rootCmd          = &cobra.Command{
 		SilenceUsage: true,
 		Run: func(cmd *cobra.Command, _ []string) {
 			_ = cmd.Help()
 		},
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			err := validateOutputFormat(outputFormat)
+			if err != nil {
+				return err
+			}
+
 			if cmd.Name() == "version" || cmd.Name() == "" {
 				return nil
 			}
@@ -48,10 +58,47 @@ func init() {
 		false,
 		"verbose logging",
 	)
+	rootCmd.PersistentFlags().StringSliceVar(
+		&datacenters,
+		"dc",
+		nil,
+		"run only on the nodes in given datacenters (overrides cluster.datacenters)",
+	)
+	rootCmd.PersistentFlags().StringSliceVar(
+		&racks,
+		"rack",
+		nil,
+		"run only on the nodes in given racks (overrides cluster.racks)",
+	)
+	rootCmd.PersistentFlags().BoolVar(
+		&dryRun,
+		"dry-run",
+		false,
+		"only execute read-only commands and print the others",
+	)
+	rootCmd.PersistentFlags().StringVarP(
+		&outputFormat,
+		"output",
+		"o",
+		"",
+		"output format: json, yaml, table or text (reports are printed as text, and the other results as json by default)",
+	)
 }
 
 func Execute(ctx context.Context) {
 	err := rootCmd.ExecuteContext(ctx)
+
+	// the plan is printed to stderr, so that the results can still be parsed as json or yaml
+	if dryRun && env.Logger != nil {
+		fmt.Fprint(os.Stderr, "\n"+env.CmdFactory.DryRunReport())
+	}
+
+	// connections are closed even if a command fails (PersistentPostRun is skipped on errors)
+	closeErr := env.Close()
+	if closeErr != nil && env.Logger != nil {
+		env.Logger.Warnw("could not close connections", "error", closeErr)
+	}
+
 	if err != nil {
 		os.Exit(1)
 	}
@@ -66,24 +113,34 @@ func initialize(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if len(datacenters) > 0 {
+		config.Cluster.Datacenters = datacenters
+	}
+
+	if len(racks) > 0 {
+		config.Cluster.Racks = racks
+	}
+
+	config.Commands.DryRun = dryRun
+	// e.g. "backup run"
+	config.Commands.Audit.Operation = strings.TrimSpace(
+		strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()),
+	)
+
 	env, err = environment.GetEnvironment(config, entity.BuildInfo{
 		Version: version,
 		Commit:  commit,
 		Date:    buildDate,
 	})
 	if err != nil && env.Notifier != nil {
-		env.Notifier.Error(
-			"could not initialize project environment",
-			"",
-			err,
-			map[string]interface{}{"configPath": configPath},
-		)
+		env.Notifier.Notify(notifier.Message{
+			Operation: notifier.OperationStartup,
+			Severity:  notifier.SeverityError,
+			Header:    "could not initialize project environment",
+			Err:       err,
+			Data:      map[string]interface{}{"configPath": configPath},
+		})
 	}
 
 	return err
 }
-
-func printJson(data interface{}) {
-	dataJson, _ := json.MarshalIndent(data, "", "  ")
-	fmt.Println(string(dataJson))
-}
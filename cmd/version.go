@@ -1,7 +1,7 @@
 package cmd
 
 import (
-	"fmt"
+	"github.com/kolesa-team/scylla-octopus/pkg/entity"
 	"github.com/spf13/cobra"
 )
 
@@ -15,9 +15,11 @@ func init() {
 	versionCmd := &cobra.Command{
 		Use: "version",
 		Run: func(cmd *cobra.Command, args []string) {
-			fmt.Println("Version:", version)
-			fmt.Println("Commit:", commit)
-			fmt.Println("Build date:", buildDate)
+			printOutput(entity.BuildInfo{
+				Version: version,
+				Commit:  commit,
+				Date:    buildDate,
+			})
 		},
 		Short: "prints program version",
 	}
@@ -2,7 +2,6 @@ package archive
 
 import (
 	"context"
-	"fmt"
 	"github.com/kolesa-team/scylla-octopus/pkg/cmd"
 	"github.com/kolesa-team/scylla-octopus/pkg/entity"
 	"github.com/pkg/errors"
@@ -10,20 +9,19 @@ import (
 
 // Compress compression backup before upload to s3
 func Compress(ctx context.Context, node *entity.Node, localPath string, archive entity.Archive) error {
-	options := fmt.Sprintf("-%s -p%s", archive.ArchiveOptions.Compression, archive.ArchiveOptions.Threads)
+	compressionOption := "-" + archive.ArchiveOptions.Compression
+	threadsOption := "-p" + archive.ArchiveOptions.Threads
+	options := compressionOption + " " + threadsOption
 	archiveName := "backup.tar." + archive.Method
 
-	_, err := node.Cmd.Execute(ctx, cmd.Command(
-		"sh",
-		"-c",
-		fmt.Sprintf(
-			`'cd %s && tar --exclude='%s' -cf - ./ | %s %s > %s'`,
-			localPath,
-			archiveName,
-			archive.Method,
-			options,
-			archiveName,
-		),
+	_, err := node.Cmd.Execute(ctx, cmd.Shellf(
+		"cd %s && tar --exclude=%s -cf - ./ | %s %s %s > %s",
+		localPath,
+		archiveName,
+		archive.Method,
+		compressionOption,
+		threadsOption,
+		archiveName,
 	))
 
 	if err != nil {
@@ -40,14 +38,10 @@ func Compress(ctx context.Context, node *entity.Node, localPath string, archive
 
 // clearDirectory cleaning the directory except archive and metadata for uploading to s3
 func clearDirectory(ctx context.Context, node *entity.Node, localPath string, archiveName string) error {
-	_, err := node.Cmd.Execute(ctx, cmd.Command(
-		"sh",
-		"-c",
-		fmt.Sprintf(
-			`'cd %s && find * ! -name "%s" -prune -exec rm -rf {} +'`,
-			localPath,
-			archiveName,
-		),
+	_, err := node.Cmd.Execute(ctx, cmd.Shellf(
+		"cd %s && find * ! -name %s -prune -exec rm -rf {} +",
+		localPath,
+		archiveName,
 	))
 
 	if err != nil {
@@ -61,3 +55,26 @@ func clearDirectory(ctx context.Context, node *entity.Node, localPath string, ar
 
 	return nil
 }
+
+// Extract decompresses a backup archive ("backup.tar.<method>") into the directory it is kept in
+func Extract(ctx context.Context, executor cmd.Executor, localPath string, method string) error {
+	archiveName := "backup.tar." + method
+
+	_, err := executor.Execute(ctx, cmd.Shellf(
+		"cd %s && %s -dc %s | tar -xf -",
+		localPath,
+		method,
+		archiveName,
+	))
+
+	if err != nil {
+		return errors.Wrapf(
+			err,
+			"failed to extract backup. Method: %s. Path: %s.",
+			method,
+			localPath,
+		)
+	}
+
+	return nil
+}
@@ -64,3 +64,24 @@ func isFileExist(file string) bool {
 
 	return true
 }
+
+func Test_Extract(t *testing.T) {
+	executor := local.Executor{}
+	ctx := context.Background()
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(dir+"/source/data", 0700))
+	require.NoError(t, os.WriteFile(dir+"/source/data/file.db", []byte("data"), 0600))
+	require.NoError(t, executor.Run(ctx, cmd.Shellf(
+		"cd %s && tar -cf - ./ | gzip > %s",
+		dir+"/source",
+		dir+"/backup.tar.gzip",
+	)))
+
+	require.NoError(t, Extract(ctx, executor, dir, "gzip"))
+
+	data, err := os.ReadFile(dir + "/data/file.db")
+	require.NoError(t, err)
+	require.Equal(t, "data", string(data))
+
+	require.Error(t, Extract(ctx, executor, dir, "xz"))
+}
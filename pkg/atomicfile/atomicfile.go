@@ -0,0 +1,66 @@
+package atomicfile
+
+// This package writes local files (such as histories and states) that are shared by concurrent runs of the program:
+// a file is replaced atomically, and its readers and writers can hold a lock across processes.
+
+import (
+	"github.com/pkg/errors"
+	"os"
+	"path/filepath"
+)
+
+// Write replaces a file with given data atomically: the data is written into a unique temporary file
+// in the same directory first, so that the file is not corrupted if the program is interrupted,
+// and concurrent writers don't overwrite each other's temporary files.
+func Write(path string, data []byte) error {
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return errors.Wrapf(err, "could not create a directory for %s", path)
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return errors.Wrapf(err, "could not create a temporary file for %s", path)
+	}
+
+	// does nothing after the file is renamed
+	defer os.Remove(tmpFile.Name())
+
+	_, err = tmpFile.Write(data)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "could not write %s", tmpFile.Name())
+	}
+
+	return os.Rename(tmpFile.Name(), path)
+}
+
+// Lock takes an exclusive lock of a file, waiting until other processes release it.
+// A separate "<path>.lock" file is locked, since the file itself is replaced by Write.
+// Returns a function that releases the lock.
+func Lock(path string) (func(), error) {
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not create a directory for %s", path)
+	}
+
+	file, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not open a lock file for %s", path)
+	}
+
+	err = lock(file)
+	if err != nil {
+		_ = file.Close()
+
+		return nil, errors.Wrapf(err, "could not lock %s", path)
+	}
+
+	return func() {
+		_ = unlock(file)
+		_ = file.Close()
+	}, nil
+}
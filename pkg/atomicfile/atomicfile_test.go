@@ -0,0 +1,59 @@
+package atomicfile
+
+import (
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// Concurrent read-modify-write cycles under a lock don't lose each other's changes
+func TestLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "counter")
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			unlock, err := Lock(path)
+			require.NoError(t, err)
+			defer unlock()
+
+			counter := 0
+			data, err := os.ReadFile(path)
+			if err == nil {
+				counter, err = strconv.Atoi(string(data))
+				require.NoError(t, err)
+			}
+
+			require.NoError(t, Write(path, []byte(strconv.Itoa(counter+1))))
+		}()
+	}
+
+	wg.Wait()
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "20", string(data))
+}
+
+// A file is replaced, and no temporary files are left
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "history.json")
+
+	require.NoError(t, Write(path, []byte("old")))
+	require.NoError(t, Write(path, []byte("new")))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "new", string(data))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.tmp"))
+	require.NoError(t, err)
+	require.Empty(t, files)
+}
@@ -0,0 +1,17 @@
+//go:build !windows
+// +build !windows
+
+package atomicfile
+
+import (
+	"os"
+	"syscall"
+)
+
+func lock(file *os.File) error {
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_EX)
+}
+
+func unlock(file *os.File) error {
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+}
@@ -4,11 +4,14 @@ import (
 	"context"
 	"fmt"
 	"github.com/kolesa-team/scylla-octopus/pkg/cmd"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd/shell"
 	"github.com/kolesa-team/scylla-octopus/pkg/entity"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	"os/exec"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type Client struct {
@@ -64,21 +67,15 @@ func (c *Client) Upload(ctx context.Context, cmdExecutor cmd.Executor, source, d
 	return destUrl, err
 }
 
-// ListBackups returns backups from a given directory
+// ListBackups returns backups from a given directory.
+// A missing directory has no backups, while the other errors of `aws s3 ls` are returned.
 func (c *Client) ListBackups(ctx context.Context, cmdExecutor cmd.Executor, basePath string) ([]entity.RemoteBackup, error) {
 	backups := []entity.RemoteBackup{}
 	// TODO the backups are kept at a 3rd leven of hierarchy, e.g. /basePath/datacenter/scylla-node1/09-07-2021-10-29
 	// this probably should not be hardcoded
 	paths, err := c.listDirectoriesRecursive(ctx, cmdExecutor, basePath, 3)
 	if err != nil {
-		c.logger.Errorw(
-			"could not list backups",
-			"error", err,
-		)
-
-		// the error most probably means there are no files at given path,
-		// so we allow ourselves ignore it.
-		return backups, nil
+		return backups, err
 	}
 
 	for _, path := range paths {
@@ -91,17 +88,58 @@ func (c *Client) ListBackups(ctx context.Context, cmdExecutor cmd.Executor, base
 	return backups, nil
 }
 
+// ListHostBackups returns the backups of a host in given datacenters, stored as "basePath/datacenter/host/date".
+// Without datacenters, they are found at basePath first.
+// This takes one `aws s3 ls` per datacenter, instead of walking the backups of every host like ListBackups.
+func (c *Client) ListHostBackups(
+	ctx context.Context,
+	cmdExecutor cmd.Executor,
+	basePath, host string,
+	datacenters []string,
+) ([]entity.RemoteBackup, error) {
+	backups := []entity.RemoteBackup{}
+	datacenterPaths := []string{}
+
+	if len(datacenters) == 0 {
+		var err error
+		datacenterPaths, err = c.listDirectories(ctx, cmdExecutor, basePath)
+		if err != nil {
+			return backups, err
+		}
+	}
+
+	for _, datacenter := range datacenters {
+		datacenterPaths = append(datacenterPaths, basePath+"/"+datacenter)
+	}
+
+	for _, datacenterPath := range datacenterPaths {
+		paths, err := c.listDirectories(ctx, cmdExecutor, datacenterPath+"/"+host)
+		if err != nil {
+			return backups, err
+		}
+
+		for _, path := range paths {
+			backup, err := entity.NewRemoteBackupFromPath(path)
+			if err == nil {
+				backups = append(backups, backup)
+			}
+		}
+	}
+
+	return backups, nil
+}
+
 // RemoveBackup removes given backup directory
 func (c *Client) RemoveBackup(ctx context.Context, cmdExecutor cmd.Executor, path string) error {
 	command := cmd.Command(
 		c.options.Binary,
 		"s3",
 		"rm",
-		fmt.Sprintf("'%s'", c.getDestinationUrl(path)),
+		c.getDestinationUrl(path),
 		"--recursive",
 	)
 	c.addCommandFlags(command)
-	output, err := cmdExecutor.Execute(ctx, command)
+	output, err := cmdExecutor.Execute(cmd.WithCategory(ctx, cmd.CategoryRemove), command)
 	if err != nil {
 		c.logger.Errorw(
 			"could not remove a backup",
@@ -123,6 +161,78 @@ func (c *Client) RemoveBackup(ctx context.Context, cmdExecutor cmd.Executor, pat
 	return nil
 }
 
+// Download syncs a given directory from remote storage to a local directory.
+// The files that are already downloaded (of the same size and not older than in remote storage) are skipped;
+// a partially downloaded file is downloaded again from the start.
+func (c *Client) Download(ctx context.Context, cmdExecutor cmd.Executor, path, localPath string) error {
+	command := cmd.Command(
+		c.options.Binary,
+		"s3",
+		"sync",
+		c.getDestinationUrl(path),
+		localPath,
+	)
+	c.addCommandFlags(command)
+	output, err := cmdExecutor.Execute(cmd.Idempotent(cmd.WithCategory(ctx, cmd.CategoryDownload)), command)
+	if err != nil {
+		return errors.Wrapf(
+			err,
+			"could not download files from %s. output: %s",
+			path,
+			string(output),
+		)
+	}
+
+	return nil
+}
+
+// ListFiles returns all files under a given directory with their sizes
+func (c *Client) ListFiles(ctx context.Context, cmdExecutor cmd.Executor, path string) ([]entity.RemoteFile, error) {
+	command := cmd.Command(
+		c.options.Binary,
+		"s3",
+		"ls",
+		c.getDestinationUrl(path)+"/",
+		"--recursive",
+	)
+	c.addCommandFlags(command)
+	output, err := cmdExecutor.Execute(cmd.ReadOnly(cmd.WithCategory(ctx, cmd.CategoryList)), command)
+	if err != nil {
+		return []entity.RemoteFile{}, errors.Wrapf(
+			err,
+			"could not list files at %s. command: %s\noutput: %s",
+			path,
+			shell.Line(command),
+			string(output),
+		)
+	}
+
+	return c.parseFileList(string(output)), nil
+}
+
+// ReadFile returns the contents of a file in remote storage
+func (c *Client) ReadFile(ctx context.Context, cmdExecutor cmd.Executor, path string) ([]byte, error) {
+	command := cmd.Command(
+		c.options.Binary,
+		"s3",
+		"cp",
+		c.getDestinationUrl(path),
+		"-",
+	)
+	c.addCommandFlags(command)
+	output, err := cmdExecutor.Execute(cmd.ReadOnly(cmd.WithCategory(ctx, cmd.CategoryList)), command)
+	if err != nil {
+		return nil, errors.Wrapf(
+			err,
+			"could not read a file at %s. output: %s",
+			path,
+			string(output),
+		)
+	}
+
+	return output, nil
+}
+
 // Returns a complete url to a destination directory in s3 format
 func (c *Client) getDestinationUrl(dest string) string {
 	url := fmt.Sprintf(
@@ -145,10 +255,10 @@ func (c *Client) sync(ctx context.Context, cmdExecutor cmd.Executor, source, des
 		"s3",
 		"sync",
 		source,
-		fmt.Sprintf("'%s'", dest),
+		dest,
 	)
 	c.addCommandFlags(command)
-	output, err := cmdExecutor.Execute(ctx, command)
+	output, err := cmdExecutor.Execute(cmd.Idempotent(cmd.WithCategory(ctx, cmd.CategoryUpload)), command)
 	if err != nil {
 		return errors.Wrapf(
 			err,
@@ -195,16 +305,21 @@ func (c *Client) listDirectories(ctx context.Context, cmdExecutor cmd.Executor,
 		c.options.Binary,
 		"s3",
 		"ls",
-		fmt.Sprintf("'%s/'", c.getDestinationUrl(path)),
+		c.getDestinationUrl(path)+"/",
 	)
 	c.addCommandFlags(command)
+	ctx = cmd.WithExpectedFailure(cmd.ReadOnly(cmd.WithCategory(ctx, cmd.CategoryList)), isMissingPath)
 	output, err := cmdExecutor.Execute(ctx, command)
+	if isMissingPath(output, err) {
+		return []string{}, nil
+	}
+
 	if err != nil {
 		return []string{}, errors.Wrapf(
 			err,
 			"could not list files at %s. command: %s\noutput: %s",
 			path,
-			command.String(),
+			shell.Line(command),
 			string(output),
 		)
 	}
@@ -212,6 +327,11 @@ func (c *Client) listDirectories(ctx context.Context, cmdExecutor cmd.Executor,
 	return c.parseDirectoryList(path, string(output)), nil
 }
 
+// whether "aws s3 ls" failed because there are no files at a given path: it exits with 1 and prints nothing then
+func isMissingPath(output []byte, err error) bool {
+	return err != nil && cmd.ExitCode(err) == 1 && len(strings.TrimSpace(string(output))) == 0
+}
+
 // Returns a list of directories from "aws s3 ls" output
 func (c *Client) parseDirectoryList(basePath string, output string) []string {
 	var result []string
@@ -236,6 +356,44 @@ func (c *Client) parseDirectoryList(basePath string, output string) []string {
 	return result
 }
 
+// Returns a list of files from "aws s3 ls --recursive" output, e.g.
+// 2021-09-07 10:29:41       1024 cluster/datacenter/scylla-node1/09-07-2021-10-29/metadata.yml
+func (c *Client) parseFileList(output string) []entity.RemoteFile {
+	result := []entity.RemoteFile{}
+	lines := strings.Split(output, "\n")
+
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+
+		dateModified, err := time.Parse("2006-01-02 15:04:05", fields[0]+" "+fields[1])
+		if err != nil {
+			continue
+		}
+
+		size, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		// the file names may contain spaces
+		path := strings.TrimSpace(line)
+		for _, field := range fields[:3] {
+			path = strings.TrimSpace(strings.TrimPrefix(path, field))
+		}
+
+		result = append(result, entity.RemoteFile{
+			Path:         path,
+			Size:         size,
+			DateModified: dateModified,
+		})
+	}
+
+	return result
+}
+
 func (c *Client) addCommandFlags(command *exec.Cmd) {
 	if len(c.options.EndpointUrl) > 0 {
 		command.Args = append(
@@ -3,6 +3,8 @@ package awscli
 import (
 	"context"
 	"github.com/stretchr/testify/require"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd/policy"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd/shell"
 	"github.com/kolesa-team/scylla-octopus/pkg/cmd/test"
 	"github.com/kolesa-team/scylla-octopus/pkg/entity"
 	"go.uber.org/zap"
@@ -28,8 +30,8 @@ func TestClient_Upload(t *testing.T) {
 	require.Equal(t, "s3://test-bucket/dest-dir", url)
 	require.Equal(
 		t,
-		"aws s3 sync source-dir 's3://test-bucket/dest-dir' --endpoint-url test-endpoint --profile test-profile",
-		cmdExecutor.LastCmd.String(),
+		"aws s3 sync source-dir s3://test-bucket/dest-dir --endpoint-url test-endpoint --profile test-profile",
+		shell.Line(cmdExecutor.LastCmd),
 	)
 }
 
@@ -83,3 +85,137 @@ this is an output of "aws s3 ls"
 		backups,
 	)
 }
+
+// "aws s3 ls" exits with 1 when a path is missing, which means there are no backups,
+// while the other errors are returned
+func TestClient_ListBackupsMissingPath(t *testing.T) {
+	missingPathErr := exec.Command("sh", "-c", "exit 1").Run()
+	otherErr := exec.Command("sh", "-c", "exit 255").Run()
+	client := NewClient(Options{Bucket: "test-bucket"}, zap.S())
+
+	backups, err := client.ListBackups(context.Background(), &test.Executor{Err: missingPathErr}, "cluster/dc1/scylla1")
+	require.NoError(t, err)
+	require.Empty(t, backups)
+
+	_, err = client.ListBackups(context.Background(), &test.Executor{Output: "access denied", Err: missingPathErr}, "cluster/dc1/scylla1")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "access denied")
+
+	_, err = client.ListBackups(context.Background(), &test.Executor{Err: otherErr}, "cluster/dc1/scylla1")
+	require.Error(t, err)
+
+	// a missing path is not retried
+	testExecutor := &test.Executor{Err: missingPathErr}
+	retryExecutor := policy.NewExecutor(testExecutor, policy.Timeouts{}, policy.RetryOptions{InitialDelay: time.Millisecond}, zap.S())
+	backups, err = client.ListBackups(context.Background(), retryExecutor, "cluster/dc1/scylla1")
+	require.NoError(t, err)
+	require.Empty(t, backups)
+	require.Equal(t, 1, testExecutor.ExecutedCount)
+}
+
+func TestClient_ListFiles(t *testing.T) {
+	cmdExecutor := &test.Executor{
+		Func: func(cmd *exec.Cmd, executedCount int) (string, error) {
+			return `2021-09-07 10:29:41        512 cluster/dc1/scylla1/09-07-2021-10-29/metadata.yml
+2021-09-07 10:29:42    1048576 cluster/dc1/scylla1/09-07-2021-10-29/data/ks/file with spaces.db
+`, nil
+		},
+	}
+	client := NewClient(Options{Bucket: "test-bucket"}, zap.S())
+
+	files, err := client.ListFiles(context.Background(), cmdExecutor, "cluster/dc1/scylla1/09-07-2021-10-29")
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		"aws s3 ls s3://test-bucket/cluster/dc1/scylla1/09-07-2021-10-29/ --recursive",
+		shell.Line(cmdExecutor.LastCmd),
+	)
+	require.Equal(
+		t,
+		[]entity.RemoteFile{
+			{
+				Path:         "cluster/dc1/scylla1/09-07-2021-10-29/metadata.yml",
+				Size:         512,
+				DateModified: time.Date(2021, 9, 7, 10, 29, 41, 0, time.UTC),
+			},
+			{
+				Path:         "cluster/dc1/scylla1/09-07-2021-10-29/data/ks/file with spaces.db",
+				Size:         1048576,
+				DateModified: time.Date(2021, 9, 7, 10, 29, 42, 0, time.UTC),
+			},
+		},
+		files,
+	)
+}
+
+func TestClient_Download(t *testing.T) {
+	cmdExecutor := &test.Executor{}
+	client := NewClient(Options{Bucket: "test-bucket", Profile: "test-profile"}, zap.S())
+
+	err := client.Download(context.Background(), cmdExecutor, "cluster/dc1/scylla1/09-07-2021-10-29", "/tmp/backup")
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		"aws s3 sync s3://test-bucket/cluster/dc1/scylla1/09-07-2021-10-29 /tmp/backup --profile test-profile",
+		shell.Line(cmdExecutor.LastCmd),
+	)
+}
+
+// The backups of a host are listed once per datacenter, without walking the other hosts
+func TestClient_ListHostBackups(t *testing.T) {
+	outputs := map[string]string{
+		"s3://test-bucket/cluster/":              "PRE dc1/\nPRE dc2/\n",
+		"s3://test-bucket/cluster/dc1/scylla1/": "PRE 09-07-2021-10-29/\nPRE 10-07-2021-10-29/\n",
+	}
+	listed := []string{}
+	cmdExecutor := &test.Executor{
+		Func: func(cmd *exec.Cmd, executedCount int) (string, error) {
+			url := cmd.Args[3]
+			listed = append(listed, url)
+
+			output, ok := outputs[url]
+			if !ok {
+				// a missing path
+				return "", exec.Command("sh", "-c", "exit 1").Run()
+			}
+
+			return output, nil
+		},
+	}
+	client := NewClient(Options{Bucket: "test-bucket"}, zap.S())
+
+	backups, err := client.ListHostBackups(context.Background(), cmdExecutor, "cluster", "scylla1", nil)
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		[]entity.RemoteBackup{
+			{
+				Path:        "cluster/dc1/scylla1/09-07-2021-10-29",
+				HostPrefix:  "scylla1",
+				DateCreated: time.Date(2021, 9, 7, 10, 29, 0, 0, time.UTC),
+			},
+			{
+				Path:        "cluster/dc1/scylla1/10-07-2021-10-29",
+				HostPrefix:  "scylla1",
+				DateCreated: time.Date(2021, 10, 7, 10, 29, 0, 0, time.UTC),
+			},
+		},
+		backups,
+	)
+	require.Equal(
+		t,
+		[]string{
+			"s3://test-bucket/cluster/",
+			"s3://test-bucket/cluster/dc1/scylla1/",
+			"s3://test-bucket/cluster/dc2/scylla1/",
+		},
+		listed,
+	)
+
+	// the datacenters are not listed if they are given
+	listed = []string{}
+	backups, err = client.ListHostBackups(context.Background(), cmdExecutor, "cluster", "scylla1", []string{"dc2"})
+	require.NoError(t, err)
+	require.Empty(t, backups)
+	require.Equal(t, []string{"s3://test-bucket/cluster/dc2/scylla1/"}, listed)
+}
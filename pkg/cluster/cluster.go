@@ -5,23 +5,42 @@ package cluster
 
 import (
 	"context"
+	"github.com/hashicorp/go-multierror"
 	"github.com/kolesa-team/scylla-octopus/pkg/cmd"
 	"github.com/kolesa-team/scylla-octopus/pkg/entity"
+	"github.com/pkg/errors"
 	"go.uber.org/zap"
-	"sync"
+	"sort"
+	"strings"
+	"time"
 )
 
 type Cluster struct {
 	options Options
 	// a "factory" that creates shell-command executors on database nodes
 	cmdFactory cmdFactory
+	// reads `nodetool status` to discover cluster nodes
+	statusReader statusReader
+	// whether the nodes were discovered from seed hosts
+	discovered bool
 	nodes      map[string]*entity.Node
-	logger     *zap.SugaredLogger
+	// the hosts that match the datacenter and rack filters (all hosts by default)
+	selectedHosts []string
+	logger        *zap.SugaredLogger
 }
 
 type Options struct {
-	// a list of hosts where the tool should run
-	Hosts          []string
+	// a list of hosts where the tool should run.
+	// in configuration, the hosts may also contain SSH connection overrides,
+	// so they're parsed separately (see environment.GetConfig)
+	Hosts []string `yaml:"-"`
+	// seed hosts to discover the cluster nodes from (with `nodetool status`).
+	// if given, the discovered nodes are used instead of Hosts.
+	Seeds []string
+	// limits all operations to the nodes in given datacenters and racks.
+	// empty means all datacenters and racks.
+	Datacenters    []string
+	Racks          []string
 	Binaries       entity.NodeBinaries
 	DataPath       string `yaml:"dataPath"`
 	ClusterName    string `yaml:"clusterName"`
@@ -34,9 +53,16 @@ type cmdFactory interface {
 	GetByHost(host string) (cmd.Executor, error)
 }
 
+// An interface that returns the statuses of all cluster nodes from `nodetool status` on a given node.
+// Implemented in pkg/scylla.
+type statusReader interface {
+	ClusterStatus(ctx context.Context, node *entity.Node) ([]entity.NodeStatus, error)
+}
+
 func NewCluster(
 	opts Options,
 	cmdFactory cmdFactory,
+	statusReader statusReader,
 	logger *zap.SugaredLogger,
 ) *Cluster {
 	if opts.DataPath == "" {
@@ -44,41 +70,74 @@ func NewCluster(
 	}
 
 	cluster := Cluster{
-		options:    opts,
-		cmdFactory: cmdFactory,
-		logger:     logger,
-		nodes:      map[string]*entity.Node{},
+		options:      opts,
+		cmdFactory:   cmdFactory,
+		statusReader: statusReader,
+		logger:       logger,
+		nodes:        map[string]*entity.Node{},
 	}
 
+	hosts := []string{}
+
 	for _, host := range opts.Hosts {
 		if _, alreadyExists := cluster.nodes[host]; alreadyExists {
 			cluster.logger.Warnw("duplicate host", "host", host)
 			continue
 		}
 
-		node := entity.Node{
-			Info: entity.NewNodeInfo(
-				host,
-				opts.DataPath,
-				opts.Binaries,
-				// resolve domain names except when running tests
-				opts.SkipDnsResolve == false,
-			),
-		}
+		cluster.nodes[host] = cluster.newNode(host)
+		hosts = append(hosts, host)
+	}
 
-		if len(opts.ClusterName) > 0 {
-			node.Info.ClusterName = opts.ClusterName
-		}
+	cluster.options.Hosts = hosts
+	cluster.selectedHosts = hosts
+
+	return &cluster
+}
 
-		cluster.nodes[host] = &node
+// creates a database node with a given host
+func (c *Cluster) newNode(host string) *entity.Node {
+	node := entity.Node{
+		Info: entity.NewNodeInfo(
+			host,
+			c.options.DataPath,
+			c.options.Binaries,
+			// resolve domain names except when running tests
+			c.options.SkipDnsResolve == false,
+		),
 	}
 
-	return &cluster
+	if len(c.options.ClusterName) > 0 {
+		node.Info.ClusterName = c.options.ClusterName
+	}
+
+	return &node
 }
 
-// Size returns the number of nodes in cluster
+// Size returns the number of selected nodes in cluster
 func (c *Cluster) Size() int {
-	return len(c.nodes)
+	return len(c.selectedHosts)
+}
+
+// TotalSize returns the number of all cluster nodes, regardless of the datacenter and rack filters
+func (c *Cluster) TotalSize() int {
+	return len(c.options.Hosts)
+}
+
+// Peers returns the selected and connected nodes other than a given one
+func (c *Cluster) Peers(host string) []*entity.Node {
+	peers := []*entity.Node{}
+
+	for _, selectedHost := range c.selectedHosts {
+		node := c.nodes[selectedHost]
+		if node.Info.Host == host || node.ConnectionErr != nil || node.Cmd == nil {
+			continue
+		}
+
+		peers = append(peers, node)
+	}
+
+	return peers
 }
 
 // Run executes a given callback on each node consecutively.
@@ -89,7 +148,7 @@ func (c *Cluster) Run(
 ) entity.NodeCallbackResults {
 	results := entity.NodeCallbackResults{}
 
-	for _, host := range c.options.Hosts {
+	for _, host := range c.selectedHosts {
 		node := c.nodes[host]
 		// do not execute a callback if SSH connection couldn't be established
 		if node.ConnectionErr != nil {
@@ -100,8 +159,7 @@ func (c *Cluster) Run(
 			continue
 		}
 
-		result := callback(ctx, node)
-		result.Host = node.Info.Host
+		result := runCallback(ctx, node, callback)
 		results[node.Info.Host] = result
 
 		if ctx.Err() != nil {
@@ -122,46 +180,145 @@ func (c *Cluster) RunParallel(
 	ctx context.Context,
 	callback entity.NodeCallback,
 ) entity.NodeCallbackResults {
-	var wg sync.WaitGroup
-	results := entity.NodeCallbackResults{}
-	wg.Add(len(c.nodes))
+	return c.RunLimited(ctx, entity.Parallelism{}, callback)
+}
 
-	resultsChan := make(chan entity.NodeCallbackResult, len(c.nodes))
+// RunLimited executes a given callback on each node in parallel,
+// with at most a given number of nodes at once (in the cluster, in every datacenter and in every rack).
+// The nodes are started in alphabetical order of hosts; as soon as one node finishes, the next one is started.
+// The execution does not stop even if there's an error on one of the nodes,
+// but the pending nodes are not started when the context is cancelled.
+func (c *Cluster) RunLimited(
+	ctx context.Context,
+	parallelism entity.Parallelism,
+	callback entity.NodeCallback,
+) entity.NodeCallbackResults {
+	results := entity.NodeCallbackResults{}
+	pending := []*entity.Node{}
 
-	for _, host := range c.options.Hosts {
+	for _, host := range c.sortedSelectedHosts() {
 		node := c.nodes[host]
 
 		// do not execute a callback if SSH connection couldn't be established
 		if node.ConnectionErr != nil {
-			resultsChan <- entity.NodeCallbackResult{
+			results[node.Info.Host] = entity.NodeCallbackResult{
 				Host: node.Info.Host,
 				Err:  node.ConnectionErr,
 			}
-			wg.Done()
 			continue
 		}
 
-		go func(node *entity.Node) {
-			result := callback(ctx, node)
-			result.Host = node.Info.Host
-			resultsChan <- result
-			wg.Done()
-		}(node)
+		pending = append(pending, node)
 	}
 
-	wg.Wait()
-	close(resultsChan)
+	resultsChan := make(chan entity.NodeCallbackResult, len(pending))
+	running := 0
+	runningByDatacenter := map[string]int{}
+	runningByRack := map[string]int{}
+
+	for len(pending) > 0 || running > 0 {
+		if ctx.Err() != nil {
+			for _, node := range pending {
+				results[node.Info.Host] = entity.NodeCallbackResult{
+					Host: node.Info.Host,
+					Err:  errors.Wrap(ctx.Err(), "the execution was cancelled before start"),
+				}
+			}
+
+			pending = nil
+		}
 
-	for nodeResult := range resultsChan {
-		results[nodeResult.Host] = nodeResult
+		notStarted := []*entity.Node{}
+
+		for _, node := range pending {
+			if !parallelism.Allows(running, runningByDatacenter[datacenterKey(node)], runningByRack[rackKey(node)]) {
+				notStarted = append(notStarted, node)
+				continue
+			}
+
+			running++
+			runningByDatacenter[datacenterKey(node)]++
+			runningByRack[rackKey(node)]++
+
+			go func(node *entity.Node) {
+				resultsChan <- runCallback(ctx, node, callback)
+			}(node)
+		}
+
+		pending = notStarted
+
+		if running == 0 {
+			break
+		}
+
+		result := <-resultsChan
+		node := c.nodes[result.Host]
+		running--
+		runningByDatacenter[datacenterKey(node)]--
+		runningByRack[rackKey(node)]--
+		results[result.Host] = result
 	}
 
 	return results
 }
 
+// executes a callback on a node and records the timing
+func runCallback(ctx context.Context, node *entity.Node, callback entity.NodeCallback) entity.NodeCallbackResult {
+	dateStarted := time.Now()
+	result := callback(ctx, node)
+	result.Host = node.Info.Host
+	result.DateStarted = dateStarted
+	result.DateFinished = time.Now()
+
+	return result
+}
+
+// returns the selected hosts in alphabetical order
+func (c *Cluster) sortedSelectedHosts() []string {
+	hosts := append([]string{}, c.selectedHosts...)
+	sort.Strings(hosts)
+
+	return hosts
+}
+
+// a key to limit the number of running nodes in a datacenter.
+// if a datacenter is unknown, all such nodes are counted together.
+func datacenterKey(node *entity.Node) string {
+	return node.Info.Datacenter
+}
+
+// a key to limit the number of running nodes in a rack (rack names are only unique within a datacenter)
+func rackKey(node *entity.Node) string {
+	return node.Info.Datacenter + "/" + node.Info.Rack
+}
+
 // Connect creates shell-command executors for each node.
 // If the tool is running over SSH, the SSH connections are established here and the errors are reported.
+// If seed hosts are configured, the cluster nodes are discovered first.
+// If datacenter or rack filters are configured, only the matching nodes are connected to.
 func (c *Cluster) Connect(ctx context.Context) entity.NodeCallbackResults {
+	if len(c.options.Seeds) > 0 && !c.discovered {
+		err := c.discover(ctx)
+		if err != nil {
+			seeds := strings.Join(c.options.Seeds, ",")
+
+			return entity.NodeCallbackResults{
+				seeds: entity.NodeCallbackResult{Host: seeds, Err: err},
+			}
+		}
+	}
+
+	if c.hasFilters() {
+		err := c.selectNodes(ctx)
+		if err != nil {
+			hosts := strings.Join(c.options.Hosts, ",")
+
+			return entity.NodeCallbackResults{
+				hosts: entity.NodeCallbackResult{Host: hosts, Err: err},
+			}
+		}
+	}
+
 	return c.RunParallel(ctx, func(ctx context.Context, node *entity.Node) entity.NodeCallbackResult {
 		node.Cmd, node.ConnectionErr = c.cmdFactory.GetByHost(node.Info.Host)
 		if node.ConnectionErr != nil {
@@ -171,3 +328,191 @@ func (c *Cluster) Connect(ctx context.Context) entity.NodeCallbackResults {
 		return entity.CallbackOk(nil)
 	})
 }
+
+// discover replaces the cluster nodes with the ones listed in `nodetool status` on the first available seed host.
+// Logs a warning if the configured hosts differ from the discovered ones.
+func (c *Cluster) discover(ctx context.Context) error {
+	if c.statusReader == nil {
+		return errors.New("cluster discovery is not supported")
+	}
+
+	var result *multierror.Error
+
+	for _, seed := range c.options.Seeds {
+		executor, err := c.cmdFactory.GetByHost(seed)
+		if err != nil {
+			result = multierror.Append(result, err)
+			continue
+		}
+
+		statuses, err := c.statusReader.ClusterStatus(ctx, entity.NewNode(c.newNode(seed).Info, executor, nil))
+		if err != nil {
+			result = multierror.Append(result, errors.Wrapf(err, "could not discover nodes from %s", seed))
+			continue
+		}
+
+		c.logger.Infow("cluster nodes discovered", "seed", seed, "nodes", len(statuses))
+		c.setDiscoveredNodes(statuses)
+
+		return nil
+	}
+
+	return errors.Wrap(result.ErrorOrNil(), "could not discover cluster nodes from any seed host")
+}
+
+// replaces the cluster nodes with the discovered ones.
+// A configured node that has a discovered address is kept with its configured host,
+// so that its SSH settings, known host keys and backup path stay the same.
+func (c *Cluster) setDiscoveredNodes(statuses []entity.NodeStatus) {
+	configuredHosts := c.options.Hosts
+	configuredNodes := c.nodes
+	c.nodes = map[string]*entity.Node{}
+	c.options.Hosts = []string{}
+
+	for _, status := range statuses {
+		node := findNodeByAddress(configuredHosts, configuredNodes, status.Address)
+		if node == nil {
+			if len(configuredNodes) > 0 {
+				c.logger.Warnw("discovered host is missing in cluster.hosts", "host", status.Address)
+			}
+
+			node = c.newNode(status.Address)
+		}
+
+		node.Info.Datacenter = status.Datacenter
+		node.Info.Rack = status.Rack
+		node.Info.HostId = status.HostId
+
+		c.nodes[node.Info.Host] = node
+		c.options.Hosts = append(c.options.Hosts, node.Info.Host)
+	}
+
+	c.selectedHosts = c.options.Hosts
+	c.discovered = true
+
+	for _, host := range configuredHosts {
+		if _, ok := c.nodes[host]; !ok {
+			c.logger.Warnw("configured host is not a part of the cluster according to `nodetool status`", "host", host)
+		}
+	}
+}
+
+// returns a configured node that has a given address, if any
+func findNodeByAddress(hosts []string, nodes map[string]*entity.Node, address string) *entity.Node {
+	for _, host := range hosts {
+		if nodes[host].Info.HasAddress(address) {
+			return nodes[host]
+		}
+	}
+
+	return nil
+}
+
+// whether the cluster operations are limited to some datacenters or racks
+func (c *Cluster) hasFilters() bool {
+	return len(c.options.Datacenters) > 0 || len(c.options.Racks) > 0
+}
+
+// selectNodes limits the cluster to the nodes that match the datacenter and rack filters.
+// If the datacenters and racks are unknown (the nodes were not discovered),
+// they are read from `nodetool status` on the first available node.
+func (c *Cluster) selectNodes(ctx context.Context) error {
+	if !c.discovered {
+		err := c.locate(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	selectedHosts := []string{}
+
+	for _, host := range c.options.Hosts {
+		if c.isSelected(c.nodes[host].Info) {
+			selectedHosts = append(selectedHosts, host)
+		}
+	}
+
+	if len(selectedHosts) == 0 {
+		return errors.Errorf(
+			"no nodes found in datacenters %v and racks %v",
+			c.options.Datacenters,
+			c.options.Racks,
+		)
+	}
+
+	c.selectedHosts = selectedHosts
+	c.logger.Infow(
+		"nodes selected",
+		"datacenters", c.options.Datacenters,
+		"racks", c.options.Racks,
+		"hosts", selectedHosts,
+	)
+
+	return nil
+}
+
+// whether a node matches the datacenter and rack filters
+func (c *Cluster) isSelected(info entity.NodeInfo) bool {
+	return matchesFilter(c.options.Datacenters, info.Datacenter) && matchesFilter(c.options.Racks, info.Rack)
+}
+
+// whether a value is present in a filter; an empty filter matches everything
+func matchesFilter(filter []string, value string) bool {
+	if len(filter) == 0 {
+		return true
+	}
+
+	for _, item := range filter {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// locate sets the datacenters, racks and host IDs of configured nodes from `nodetool status` on the first available node
+func (c *Cluster) locate(ctx context.Context) error {
+	if c.statusReader == nil {
+		return errors.New("datacenter and rack filters are not supported")
+	}
+
+	var result *multierror.Error
+
+	for _, host := range c.options.Hosts {
+		executor, err := c.cmdFactory.GetByHost(host)
+		if err != nil {
+			result = multierror.Append(result, err)
+			continue
+		}
+
+		statuses, err := c.statusReader.ClusterStatus(ctx, entity.NewNode(c.nodes[host].Info, executor, nil))
+		if err != nil {
+			result = multierror.Append(result, errors.Wrapf(err, "could not read cluster status from %s", host))
+			continue
+		}
+
+		for _, host := range c.options.Hosts {
+			node := c.nodes[host]
+			located := false
+
+			for _, status := range statuses {
+				if node.Info.HasAddress(status.Address) {
+					node.Info.Datacenter = status.Datacenter
+					node.Info.Rack = status.Rack
+					node.Info.HostId = status.HostId
+					located = true
+					break
+				}
+			}
+
+			if !located {
+				c.logger.Warnw("host is not a part of the cluster according to `nodetool status`", "host", host)
+			}
+		}
+
+		return nil
+	}
+
+	return errors.Wrap(result.ErrorOrNil(), "could not determine datacenters and racks of cluster nodes")
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/kolesa-team/scylla-octopus/pkg/entity"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
+	"sync"
 	"testing"
 	"time"
 )
@@ -28,6 +29,7 @@ func TestCluster_RunParallel_Ok(t *testing.T) {
 			SkipDnsResolve: true,
 		},
 		localCmdFactory{},
+		nil,
 		zap.S(),
 	)
 	require.Equal(t, 2, cluster.Size())
@@ -62,6 +64,7 @@ func TestCluster_RunParallel_WithContextTimeout(t *testing.T) {
 			SkipDnsResolve: true,
 		},
 		localCmdFactory{},
+		nil,
 		zap.S(),
 	)
 	require.Equal(t, 2, cluster.Size())
@@ -105,6 +108,7 @@ func TestCluster_RunParallel_WithError(t *testing.T) {
 			SkipDnsResolve: true,
 		},
 		localCmdFactory{},
+		nil,
 		zap.S(),
 	)
 
@@ -148,6 +152,7 @@ func TestCluster_Run_Ok(t *testing.T) {
 			SkipDnsResolve: true,
 		},
 		localCmdFactory{},
+		nil,
 		zap.S(),
 	)
 
@@ -183,6 +188,7 @@ func TestCluster_Run_WithError(t *testing.T) {
 			SkipDnsResolve: true,
 		},
 		localCmdFactory{},
+		nil,
 		zap.S(),
 	)
 	require.Equal(t, 2, cluster.Size())
@@ -211,3 +217,325 @@ func TestCluster_Run_WithError(t *testing.T) {
 		"a callback for host-1 must return an error",
 	)
 }
+
+// a test implementation of `nodetool status` reader
+type testStatusReader struct {
+	statuses []entity.NodeStatus
+	err      error
+}
+
+func (t testStatusReader) ClusterStatus(ctx context.Context, node *entity.Node) ([]entity.NodeStatus, error) {
+	return t.statuses, t.err
+}
+
+// The cluster nodes are discovered from a seed host and replace the configured ones.
+func TestCluster_Connect_Discovery(t *testing.T) {
+	cluster := NewCluster(
+		Options{
+			Hosts:          []string{"10.0.0.1", "10.0.0.9"},
+			Seeds:          []string{"10.0.0.1"},
+			SkipDnsResolve: true,
+		},
+		localCmdFactory{},
+		testStatusReader{
+			statuses: []entity.NodeStatus{
+				{Address: "10.0.0.1", Status: "UN", Datacenter: "DC1", Rack: "R1", HostId: "host-id-1"},
+				{Address: "10.0.0.2", Status: "UN", Datacenter: "DC2", Rack: "R2", HostId: "host-id-2"},
+			},
+		},
+		zap.S(),
+	)
+
+	require.NoError(t, cluster.Connect(context.Background()).Error())
+	require.Equal(t, 2, cluster.Size())
+
+	result := cluster.Run(
+		context.Background(),
+		func(ctx context.Context, node *entity.Node) entity.NodeCallbackResult {
+			return entity.CallbackOk(node.Info)
+		},
+	)
+
+	require.Len(t, result, 2, "a callback must be executed on discovered nodes only")
+	require.Equal(t, "DC2", result["10.0.0.2"].Value.(entity.NodeInfo).Datacenter)
+	require.Equal(t, "R2", result["10.0.0.2"].Value.(entity.NodeInfo).Rack)
+	require.Equal(t, "host-id-2", result["10.0.0.2"].Value.(entity.NodeInfo).HostId)
+}
+
+// Configured nodes with hostnames are kept after discovery, and only the unknown addresses are added
+func TestCluster_Connect_DiscoveryWithHostnames(t *testing.T) {
+	cluster := NewCluster(
+		Options{
+			ClusterName:    "cluster",
+			Hosts:          []string{"scylla-node1", "scylla-node2"},
+			Seeds:          []string{"scylla-node1"},
+			SkipDnsResolve: true,
+		},
+		localCmdFactory{},
+		testStatusReader{
+			statuses: []entity.NodeStatus{
+				{Address: "10.0.0.1", Status: "UN", Datacenter: "DC1", Rack: "R1", HostId: "host-id-1"},
+				{Address: "10.0.0.2", Status: "UN", Datacenter: "DC1", Rack: "R2", HostId: "host-id-2"},
+				{Address: "10.0.0.3", Status: "UN", Datacenter: "DC1", Rack: "R3", HostId: "host-id-3"},
+			},
+		},
+		zap.S(),
+	)
+	// the addresses of the hostnames, as if they were resolved
+	cluster.nodes["scylla-node1"].Info.IpAddress = "10.0.0.1"
+	cluster.nodes["scylla-node2"].Info.IpAddress = "10.0.0.2"
+
+	require.NoError(t, cluster.Connect(context.Background()).Error())
+	require.Equal(t, 3, cluster.Size())
+
+	result := cluster.Run(
+		context.Background(),
+		func(ctx context.Context, node *entity.Node) entity.NodeCallbackResult {
+			return entity.CallbackOk(node.Info)
+		},
+	)
+
+	require.Equal(t, []string{"10.0.0.3", "scylla-node1", "scylla-node2"}, result.Hosts())
+
+	info := result["scylla-node1"].Value.(entity.NodeInfo)
+	require.Equal(t, "10.0.0.1", info.IpAddress)
+	require.Equal(t, "DC1", info.Datacenter)
+	require.Equal(t, "host-id-1", info.HostId)
+	require.Equal(t, "cluster/DC1/scylla-node1", info.RemoteStoragePath(), "the backup path must not change")
+	require.Equal(t, "R3", result["10.0.0.3"].Value.(entity.NodeInfo).Rack)
+}
+
+// A discovery error is reported by Connect
+func TestCluster_Connect_DiscoveryError(t *testing.T) {
+	cluster := NewCluster(
+		Options{
+			Seeds:          []string{"10.0.0.1"},
+			SkipDnsResolve: true,
+		},
+		localCmdFactory{},
+		testStatusReader{err: errors.New("test error")},
+		zap.S(),
+	)
+
+	result := cluster.Connect(context.Background())
+	require.Error(t, result.Error())
+	require.Contains(t, result.Error().Error(), "could not discover nodes from 10.0.0.1: test error")
+}
+
+// Only the nodes in selected datacenters and racks are used after discovery.
+func TestCluster_Connect_DiscoveryWithFilters(t *testing.T) {
+	cluster := NewCluster(
+		Options{
+			Seeds:          []string{"10.0.0.1"},
+			Datacenters:    []string{"DC1"},
+			Racks:          []string{"R2"},
+			SkipDnsResolve: true,
+		},
+		localCmdFactory{},
+		testStatusReader{
+			statuses: []entity.NodeStatus{
+				{Address: "10.0.0.1", Status: "UN", Datacenter: "DC1", Rack: "R1"},
+				{Address: "10.0.0.2", Status: "UN", Datacenter: "DC1", Rack: "R2"},
+				{Address: "10.0.0.3", Status: "UN", Datacenter: "DC2", Rack: "R2"},
+			},
+		},
+		zap.S(),
+	)
+
+	connectResult := cluster.Connect(context.Background())
+	require.NoError(t, connectResult.Error())
+	require.Equal(t, []string{"10.0.0.2"}, connectResult.Hosts(), "only selected nodes must be connected to")
+	require.Equal(t, 1, cluster.Size())
+	require.Equal(t, 3, cluster.TotalSize(), "the total size must include the nodes that are not selected")
+
+	result := cluster.RunParallel(
+		context.Background(),
+		func(ctx context.Context, node *entity.Node) entity.NodeCallbackResult {
+			return entity.CallbackOk(nil)
+		},
+	)
+	require.Equal(t, []string{"10.0.0.2"}, result.Hosts())
+}
+
+// The datacenters of configured hosts are read from `nodetool status` to filter them.
+func TestCluster_Connect_Filters(t *testing.T) {
+	cluster := NewCluster(
+		Options{
+			Hosts:          []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"},
+			Datacenters:    []string{"DC2"},
+			SkipDnsResolve: true,
+		},
+		localCmdFactory{},
+		testStatusReader{
+			statuses: []entity.NodeStatus{
+				{Address: "10.0.0.1", Status: "UN", Datacenter: "DC1", Rack: "R1"},
+				{Address: "10.0.0.2", Status: "UN", Datacenter: "DC2", Rack: "R1"},
+				{Address: "10.0.0.3", Status: "UN", Datacenter: "DC2", Rack: "R2"},
+			},
+		},
+		zap.S(),
+	)
+
+	require.NoError(t, cluster.Connect(context.Background()).Error())
+	require.Equal(t, 2, cluster.Size())
+	require.Equal(t, 3, cluster.TotalSize(), "the total size must include the nodes that are not selected")
+
+	result := cluster.Run(
+		context.Background(),
+		func(ctx context.Context, node *entity.Node) entity.NodeCallbackResult {
+			return entity.CallbackOk(node.Info)
+		},
+	)
+	require.Equal(t, []string{"10.0.0.2", "10.0.0.3"}, result.Hosts())
+	require.Equal(t, "R2", result["10.0.0.3"].Value.(entity.NodeInfo).Rack)
+}
+
+// Connect returns an error when no nodes match the filters
+func TestCluster_Connect_FiltersNoMatch(t *testing.T) {
+	cluster := NewCluster(
+		Options{
+			Hosts:          []string{"10.0.0.1"},
+			Datacenters:    []string{"DC3"},
+			SkipDnsResolve: true,
+		},
+		localCmdFactory{},
+		testStatusReader{
+			statuses: []entity.NodeStatus{
+				{Address: "10.0.0.1", Status: "UN", Datacenter: "DC1", Rack: "R1"},
+			},
+		},
+		zap.S(),
+	)
+
+	result := cluster.Connect(context.Background())
+	require.Error(t, result.Error())
+	require.Contains(t, result.Error().Error(), "no nodes found in datacenters [DC3] and racks []")
+}
+
+// returns a callback that records the maximum number of nodes running at once, in total and by datacenter
+func concurrencyRecorder(maxRunning *int, maxRunningByDc map[string]int) entity.NodeCallback {
+	var mu sync.Mutex
+	running := 0
+	runningByDc := map[string]int{}
+
+	return func(ctx context.Context, node *entity.Node) entity.NodeCallbackResult {
+		mu.Lock()
+		running++
+		runningByDc[node.Info.Datacenter]++
+		if running > *maxRunning {
+			*maxRunning = running
+		}
+		if runningByDc[node.Info.Datacenter] > maxRunningByDc[node.Info.Datacenter] {
+			maxRunningByDc[node.Info.Datacenter] = runningByDc[node.Info.Datacenter]
+		}
+		mu.Unlock()
+
+		time.Sleep(time.Millisecond * 20)
+
+		mu.Lock()
+		running--
+		runningByDc[node.Info.Datacenter]--
+		mu.Unlock()
+
+		return entity.CallbackOk(nil)
+	}
+}
+
+// The number of nodes running at once is limited in the cluster and in every datacenter
+func TestCluster_RunLimited(t *testing.T) {
+	cluster := NewCluster(
+		Options{
+			Seeds:          []string{"10.0.0.1"},
+			SkipDnsResolve: true,
+		},
+		localCmdFactory{},
+		testStatusReader{
+			statuses: []entity.NodeStatus{
+				{Address: "10.0.0.1", Status: "UN", Datacenter: "DC1", Rack: "R1"},
+				{Address: "10.0.0.2", Status: "UN", Datacenter: "DC1", Rack: "R2"},
+				{Address: "10.0.0.3", Status: "UN", Datacenter: "DC1", Rack: "R3"},
+				{Address: "10.0.0.4", Status: "UN", Datacenter: "DC2", Rack: "R1"},
+				{Address: "10.0.0.5", Status: "UN", Datacenter: "DC2", Rack: "R2"},
+				{Address: "10.0.0.6", Status: "UN", Datacenter: "DC2", Rack: "R3"},
+			},
+		},
+		zap.S(),
+	)
+	require.NoError(t, cluster.Connect(context.Background()).Error())
+
+	maxRunning := 0
+	maxRunningByDc := map[string]int{}
+	result := cluster.RunLimited(
+		context.Background(),
+		entity.Parallelism{Max: 3, PerDatacenter: 1},
+		concurrencyRecorder(&maxRunning, maxRunningByDc),
+	)
+
+	require.NoError(t, result.Error())
+	require.Len(t, result, 6, "a callback must be executed on every node")
+	require.Equal(t, 2, maxRunning, "only one node per datacenter must run at once")
+	require.Equal(t, map[string]int{"DC1": 1, "DC2": 1}, maxRunningByDc)
+
+	for _, host := range result.Hosts() {
+		require.False(t, result[host].DateStarted.IsZero(), "a start date must be recorded")
+		require.False(t, result[host].DateFinished.Before(result[host].DateStarted))
+	}
+
+	maxRunning = 0
+	result = cluster.RunLimited(
+		context.Background(),
+		entity.Parallelism{Max: 4},
+		concurrencyRecorder(&maxRunning, map[string]int{}),
+	)
+	require.NoError(t, result.Error())
+	require.Equal(t, 4, maxRunning, "no more than 4 nodes must run at once")
+}
+
+// The pending nodes are not started after the context is cancelled
+func TestCluster_RunLimited_Cancelled(t *testing.T) {
+	cluster := NewCluster(
+		Options{
+			Hosts:          []string{"host-1", "host-2", "host-3"},
+			SkipDnsResolve: true,
+		},
+		localCmdFactory{},
+		nil,
+		zap.S(),
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	result := cluster.RunLimited(
+		ctx,
+		entity.Parallelism{Max: 1},
+		func(ctx context.Context, node *entity.Node) entity.NodeCallbackResult {
+			cancel()
+			return entity.CallbackOk(nil)
+		},
+	)
+
+	require.Len(t, result, 3)
+	require.NoError(t, result["host-1"].Err, "the first node must be processed")
+	require.Error(t, result["host-2"].Err)
+	require.Error(t, result["host-3"].Err)
+	require.True(t, result["host-3"].DateStarted.IsZero(), "the pending node must not be started")
+}
+
+// Peers returns the other connected nodes
+func TestCluster_Peers(t *testing.T) {
+	cluster := NewCluster(
+		Options{
+			Hosts:          []string{"host-1", "host-2", "host-3"},
+			SkipDnsResolve: true,
+		},
+		localCmdFactory{},
+		nil,
+		zap.S(),
+	)
+	require.NoError(t, cluster.Connect(context.Background()).Error())
+
+	peers := cluster.Peers("host-2")
+	require.Len(t, peers, 2)
+	require.Equal(t, "host-1", peers[0].Info.Host)
+	require.Equal(t, "host-3", peers[1].Info.Host)
+}
@@ -0,0 +1,104 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd/shell"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// a replacement of secrets in command lines
+const mask = "******"
+
+// the flags followed by a secret value
+var secretFlags = map[string]bool{
+	"-p":           true,
+	"--password":   true,
+	"--secret-key": true,
+}
+
+// Executor records every command of another executor into the audit log
+type Executor struct {
+	host     string
+	executor cmd.Executor
+	log      *Log
+}
+
+func (e *Executor) Execute(ctx context.Context, command *exec.Cmd) ([]byte, error) {
+	timeStarted := time.Now()
+	output, err := e.executor.Execute(ctx, command)
+	e.record(timeStarted, e.log.maskCommand(command), output, err)
+
+	return output, err
+}
+
+func (e *Executor) Run(ctx context.Context, command *exec.Cmd) error {
+	_, err := e.Execute(ctx, command)
+	return err
+}
+
+func (e *Executor) ReadFile(ctx context.Context, path string) ([]byte, error) {
+	timeStarted := time.Now()
+	data, err := e.executor.ReadFile(ctx, path)
+	e.record(timeStarted, "read file "+path, nil, err)
+
+	return data, err
+}
+
+func (e *Executor) WriteFile(ctx context.Context, path string, data []byte) error {
+	timeStarted := time.Now()
+	err := e.executor.WriteFile(ctx, path, data)
+	e.record(timeStarted, fmt.Sprintf("write %d bytes to file %s", len(data), path), nil, err)
+
+	return err
+}
+
+func (e *Executor) record(timeStarted time.Time, command string, output []byte, err error) {
+	entry := Entry{
+		Time:       timeStarted,
+		Host:       e.host,
+		Command:    command,
+		ExitCode:   cmd.ExitCode(err),
+		DurationMs: time.Since(timeStarted).Milliseconds(),
+		Output:     e.log.maskSecrets(string(output)),
+	}
+
+	if err != nil {
+		entry.Error = e.log.maskSecrets(err.Error())
+	}
+
+	e.log.write(entry)
+}
+
+// returns a command line with the values of secret flags and known secrets replaced by a mask
+func (l *Log) maskCommand(command *exec.Cmd) string {
+	masked := *command
+	masked.Args = make([]string, len(command.Args))
+
+	for i, arg := range command.Args {
+		if i > 0 && secretFlags[command.Args[i-1]] {
+			arg = mask
+		}
+
+		masked.Args[i] = arg
+	}
+
+	return l.maskSecrets(shell.Line(&masked))
+}
+
+// replaces known secrets in a string, including their quoted forms
+func (l *Log) maskSecrets(value string) string {
+	for _, secret := range l.options.Secrets {
+		if len(secret) == 0 {
+			continue
+		}
+
+		value = strings.ReplaceAll(value, shell.Quote(secret), mask)
+		value = strings.ReplaceAll(value, secret, mask)
+	}
+
+	return value
+}
@@ -0,0 +1,112 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd/local"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd/test"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// reads all records from an audit log
+func readEntries(t *testing.T, path string) []Entry {
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	entries := []Entry{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		entry := Entry{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+func TestExecutor(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	log, err := NewLog(Options{
+		File:      path,
+		Operator:  "alice",
+		Operation: "backup run",
+		MaxOutput: 5,
+		Secrets:   []string{"p@ss word"},
+	}, zap.S())
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	localExecutor := log.Executor("node1", local.Executor{})
+
+	output, err := localExecutor.Execute(ctx, cmd.Command("echo", "hello world"))
+	require.NoError(t, err)
+	require.Equal(t, "hello world\n", string(output))
+
+	err = localExecutor.Run(ctx, cmd.Shellf("exit 3"))
+	require.Error(t, err)
+
+	testExecutor := log.Executor("node2", &test.Executor{Output: "denied for p@ss word", Err: errors.New("exit status 1")})
+	_, _ = testExecutor.Execute(ctx, cmd.Command("cqlsh", "-u", "scylla", "-p", "secret", "-e", "DESC SCHEMA"))
+	_, _ = testExecutor.Execute(ctx, cmd.Command("sudo", "sh", "-c", "cqlsh -p 'p@ss word'"))
+	_ = testExecutor.WriteFile(ctx, "/tmp/metadata.yml", []byte("test"))
+	require.NoError(t, log.Close())
+
+	entries := readEntries(t, path)
+	require.Len(t, entries, 5)
+
+	for _, entry := range entries {
+		require.Equal(t, "alice", entry.Operator)
+		require.Equal(t, "backup run", entry.Operation)
+		require.False(t, entry.Time.IsZero())
+	}
+
+	require.Equal(t, "node1", entries[0].Host)
+	require.Equal(t, "echo 'hello world'", entries[0].Command)
+	require.Equal(t, 0, entries[0].ExitCode)
+	require.Equal(t, "hello", entries[0].Output, "output must be truncated")
+	require.True(t, entries[0].OutputTruncated)
+
+	require.Equal(t, 3, entries[1].ExitCode)
+	require.Contains(t, entries[1].Error, "exit status 3")
+
+	require.Equal(t, "node2", entries[2].Host)
+	require.Equal(t, "cqlsh -u scylla -p '******' -e 'DESC SCHEMA'", entries[2].Command)
+	require.Equal(t, -1, entries[2].ExitCode)
+	require.Equal(t, "denie", entries[2].Output)
+
+	require.NotContains(t, entries[3].Command, "p@ss")
+	require.True(t, strings.HasPrefix(entries[3].Command, "sudo sh -c "))
+
+	require.Equal(t, "write 4 bytes to file /tmp/metadata.yml", entries[4].Command)
+}
+
+// Records are appended to an existing log
+func TestLog_Append(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	for i := 0; i < 2; i++ {
+		log, err := NewLog(Options{File: path}, zap.S())
+		require.NoError(t, err)
+		_, err = log.Executor("node1", &test.Executor{}).ReadFile(context.Background(), "/etc/hosts")
+		require.NoError(t, err)
+		require.NoError(t, log.Close())
+	}
+
+	entries := readEntries(t, path)
+	require.Len(t, entries, 2)
+	require.NotEmpty(t, entries[0].Operator, "the operator defaults to a current user")
+	require.Equal(t, "read file /etc/hosts", entries[1].Command)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
@@ -0,0 +1,127 @@
+package audit
+
+// This package records every command executed on every node into a file, as JSON lines.
+
+import (
+	"encoding/json"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"os"
+	"os/user"
+	"sync"
+	"time"
+)
+
+type Options struct {
+	// a file to append the audit records to. the audit is disabled if empty
+	File string
+	// a person or a system that runs the program. defaults to $SUDO_USER or the current OS user
+	Operator string
+	// a maximum length of command output to record. defaults to 4096 bytes
+	MaxOutput int `yaml:"maxOutput"`
+	// an operation being performed, such as "backup run" (set from the command line)
+	Operation string `yaml:"-"`
+	// the values to mask in command lines, such as database password
+	Secrets []string `yaml:"-"`
+}
+
+// Entry is a single audit record
+type Entry struct {
+	Time            time.Time `json:"time"`
+	Host            string    `json:"host"`
+	Operator        string    `json:"operator"`
+	Operation       string    `json:"operation"`
+	Command         string    `json:"command"`
+	ExitCode        int       `json:"exitCode"`
+	DurationMs      int64     `json:"durationMs"`
+	Error           string    `json:"error,omitempty"`
+	Output          string    `json:"output"`
+	OutputTruncated bool      `json:"outputTruncated,omitempty"`
+}
+
+// Log appends audit records to a file
+type Log struct {
+	options Options
+	file    *os.File
+	mu      sync.Mutex
+	logger  *zap.SugaredLogger
+}
+
+func NewLog(options Options, logger *zap.SugaredLogger) (*Log, error) {
+	if options.MaxOutput <= 0 {
+		options.MaxOutput = 4096
+	}
+
+	if len(options.Operator) == 0 {
+		options.Operator = currentOperator()
+	}
+
+	file, err := os.OpenFile(options.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not open audit log %s", options.File)
+	}
+
+	return &Log{
+		options: options,
+		file:    file,
+		logger:  logger,
+	}, nil
+}
+
+// Executor returns a command executor for a given host, that records every command into the log
+func (l *Log) Executor(host string, executor cmd.Executor) cmd.Executor {
+	return &Executor{
+		host:     host,
+		executor: executor,
+		log:      l,
+	}
+}
+
+// Close closes the log file
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.file.Close()
+}
+
+// writes a record as a single JSON line.
+// a failure to write is logged, but doesn't stop the command execution.
+func (l *Log) write(entry Entry) {
+	entry.Operator = l.options.Operator
+	entry.Operation = l.options.Operation
+
+	if len(entry.Output) > l.options.MaxOutput {
+		entry.Output = entry.Output[:l.options.MaxOutput]
+		entry.OutputTruncated = true
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		l.logger.Errorw("could not encode an audit record", "error", err)
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	_, err = l.file.Write(append(data, '\n'))
+	if err != nil {
+		l.logger.Errorw("could not write an audit record", "error", err, "file", l.options.File)
+	}
+}
+
+// returns the name of a person running the program, even if it's run with sudo
+func currentOperator() string {
+	if sudoUser := os.Getenv("SUDO_USER"); len(sudoUser) > 0 {
+		return sudoUser
+	}
+
+	current, err := user.Current()
+	if err != nil {
+		return os.Getenv("USER")
+	}
+
+	return current.Username
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"github.com/pkg/errors"
 	"os/exec"
 )
 
@@ -16,9 +17,30 @@ type Executor interface {
 // Command creates a shell command, almost like a standard `exec.Command()`,
 // except it doesn't try to resolve an absolute path of `name`.
 // This allows to work with local commands as well as with SSH.
+// The arguments are quoted when the command is executed (see shell.Line),
+// so they must not contain shell syntax; use Shellf for pipes, redirects and globs.
+// A name is used as is, so it may be a configured binary of several words (e.g. "docker exec scylla nodetool").
 func Command(name string, arg ...string) *exec.Cmd {
 	return &exec.Cmd{
 		Path: name,
 		Args: append([]string{name}, arg...),
 	}
 }
+
+// ExitCode returns an exit code of a command: 0 on success, -1 if it's unknown (e.g. the command could not be started)
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	switch exitErr := errors.Cause(err).(type) {
+	case interface{ ExitCode() int }:
+		// exec.ExitError of local commands
+		return exitErr.ExitCode()
+	case interface{ ExitStatus() int }:
+		// ssh.ExitError of remote commands
+		return exitErr.ExitStatus()
+	default:
+		return -1
+	}
+}
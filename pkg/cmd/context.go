@@ -0,0 +1,87 @@
+package cmd
+
+import "context"
+
+// a context key type for command properties
+type contextKey int
+
+const (
+	idempotentKey contextKey = iota
+	readOnlyKey
+	categoryKey
+	expectedFailureKey
+)
+
+// Category is a kind of commands that share a timeout (see policy.Timeouts)
+type Category string
+
+const (
+	// short `nodetool` commands, such as status, snapshot or clearsnapshot
+	CategoryNodetool Category = "nodetool"
+	// `cqlsh` queries
+	CategoryCqlsh Category = "cqlsh"
+	// uploads to remote storage
+	CategoryUpload Category = "upload"
+	// downloads from remote storage
+	CategoryDownload Category = "download"
+	// listings of remote storage
+	CategoryList Category = "list"
+	// removal of files and backups
+	CategoryRemove Category = "remove"
+)
+
+// Idempotent marks the commands executed with a returned context as safe to repeat,
+// e.g. after a lost SSH connection
+func Idempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey, true)
+}
+
+// IsIdempotent whether the commands executed with a given context are safe to repeat.
+// Read-only commands are always idempotent.
+func IsIdempotent(ctx context.Context) bool {
+	idempotent, _ := ctx.Value(idempotentKey).(bool)
+
+	return idempotent || IsReadOnly(ctx)
+}
+
+// ReadOnly marks the commands executed with a returned context as the ones that don't change anything,
+// e.g. `nodetool status`. Such commands are executed even in dry-run mode.
+func ReadOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readOnlyKey, true)
+}
+
+// IsReadOnly whether the commands executed with a given context don't change anything
+func IsReadOnly(ctx context.Context) bool {
+	readOnly, _ := ctx.Value(readOnlyKey).(bool)
+
+	return readOnly
+}
+
+// WithCategory marks the commands executed with a returned context as belonging to a given category
+func WithCategory(ctx context.Context, category Category) context.Context {
+	return context.WithValue(ctx, categoryKey, category)
+}
+
+// CategoryOf returns the category of the commands executed with a given context, or an empty string
+func CategoryOf(ctx context.Context) Category {
+	category, _ := ctx.Value(categoryKey).(Category)
+
+	return category
+}
+
+// ExpectedFailure whether a failure of a command is an answer rather than an error,
+// e.g. "aws s3 ls" of a missing path
+type ExpectedFailure func(output []byte, err error) bool
+
+// WithExpectedFailure marks some failures of the commands executed with a returned context as expected,
+// so that such commands are not repeated (see policy.Executor)
+func WithExpectedFailure(ctx context.Context, isExpected ExpectedFailure) context.Context {
+	return context.WithValue(ctx, expectedFailureKey, isExpected)
+}
+
+// IsExpectedFailure whether a failure of a command executed with a given context is expected
+func IsExpectedFailure(ctx context.Context, output []byte, err error) bool {
+	isExpected, _ := ctx.Value(expectedFailureKey).(ExpectedFailure)
+
+	return err != nil && isExpected != nil && isExpected(output, err)
+}
@@ -0,0 +1,129 @@
+package dryrun
+
+// This package implements a dry-run mode: read-only commands (see cmd.ReadOnly) are executed,
+// while the others are only recorded, so that the whole execution plan can be printed.
+
+import (
+	"context"
+	"fmt"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd/shell"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Step is a command that was not executed in dry-run mode
+type Step struct {
+	Host    string
+	Command string
+}
+
+// Recorder collects the commands that were not executed on all hosts
+type Recorder struct {
+	mu    sync.Mutex
+	steps []Step
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Executor returns a command executor for a given host,
+// that only executes read-only commands with another executor, and records the others
+func (r *Recorder) Executor(host string, executor cmd.Executor) cmd.Executor {
+	return &Executor{
+		host:     host,
+		executor: executor,
+		recorder: r,
+	}
+}
+
+// Steps returns all recorded commands in order of their execution
+func (r *Recorder) Steps() []Step {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Step{}, r.steps...)
+}
+
+// Report returns a human-readable plan: the recorded commands per host, in order, with a summary
+func (r *Recorder) Report() string {
+	steps := r.Steps()
+	if len(steps) == 0 {
+		return "Dry run: no commands would change anything.\n"
+	}
+
+	hosts := []string{}
+	stepsByHost := map[string][]string{}
+	for _, step := range steps {
+		if _, ok := stepsByHost[step.Host]; !ok {
+			hosts = append(hosts, step.Host)
+		}
+
+		stepsByHost[step.Host] = append(stepsByHost[step.Host], step.Command)
+	}
+
+	sort.Strings(hosts)
+
+	var report strings.Builder
+	report.WriteString("Dry run: the following commands were not executed.\n")
+
+	for _, host := range hosts {
+		report.WriteString(fmt.Sprintf("\n%s:\n", host))
+		for i, command := range stepsByHost[host] {
+			report.WriteString(fmt.Sprintf("  %d. %s\n", i+1, command))
+		}
+	}
+
+	report.WriteString(fmt.Sprintf(
+		"\nSummary: %d commands on %d hosts would be executed.\n",
+		len(steps),
+		len(hosts),
+	))
+
+	return report.String()
+}
+
+func (r *Recorder) record(host, command string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.steps = append(r.steps, Step{Host: host, Command: command})
+}
+
+// Executor executes read-only commands and records the others
+type Executor struct {
+	host     string
+	executor cmd.Executor
+	recorder *Recorder
+}
+
+// Execute executes a read-only command; other commands are recorded and are considered successful
+func (e *Executor) Execute(ctx context.Context, command *exec.Cmd) ([]byte, error) {
+	if cmd.IsReadOnly(ctx) {
+		return e.executor.Execute(ctx, command)
+	}
+
+	e.recorder.record(e.host, shell.Line(command))
+
+	return nil, nil
+}
+
+func (e *Executor) Run(ctx context.Context, command *exec.Cmd) error {
+	_, err := e.Execute(ctx, command)
+	return err
+}
+
+// ReadFile reads a file, since it doesn't change anything
+func (e *Executor) ReadFile(ctx context.Context, path string) ([]byte, error) {
+	return e.executor.ReadFile(ctx, path)
+}
+
+// WriteFile only records a file write
+func (e *Executor) WriteFile(ctx context.Context, path string, data []byte) error {
+	e.recorder.record(e.host, fmt.Sprintf("write %d bytes to %s", len(data), path))
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package dryrun
+
+import (
+	"context"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd/test"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// Read-only commands are executed, the others are recorded per host
+func TestRecorder(t *testing.T) {
+	ctx := context.Background()
+	recorder := NewRecorder()
+	node1Executor := &test.Executor{Output: "UN", FileToRead: []byte("metadata")}
+	node1 := recorder.Executor("node1", node1Executor)
+	node2 := recorder.Executor("node2", &test.Executor{})
+
+	output, err := node1.Execute(cmd.ReadOnly(ctx), cmd.Command("nodetool", "status"))
+	require.NoError(t, err)
+	require.Equal(t, "UN", string(output))
+	require.Equal(t, 1, node1Executor.ExecutedCount)
+
+	require.NoError(t, node2.Run(ctx, cmd.Command("nodetool", "clearsnapshot", "-t", "old snapshot")))
+	require.NoError(t, node1.Run(cmd.Idempotent(ctx), cmd.Command("aws", "s3", "rm", "s3://bucket/node1")))
+	require.NoError(t, node1.WriteFile(ctx, "/tmp/metadata.yml", []byte("test")))
+
+	data, err := node1.ReadFile(ctx, "/tmp/metadata.yml")
+	require.NoError(t, err)
+	require.Equal(t, "metadata", string(data))
+
+	require.Equal(t, 1, node1Executor.ExecutedCount, "mutating commands must not be executed")
+	require.Nil(t, node1Executor.WrittenFileBytes, "files must not be written")
+
+	require.Equal(t, []Step{
+		{Host: "node2", Command: "nodetool clearsnapshot -t 'old snapshot'"},
+		{Host: "node1", Command: "aws s3 rm s3://bucket/node1"},
+		{Host: "node1", Command: "write 4 bytes to /tmp/metadata.yml"},
+	}, recorder.Steps())
+
+	require.Equal(t, `Dry run: the following commands were not executed.
+
+node1:
+  1. aws s3 rm s3://bucket/node1
+  2. write 4 bytes to /tmp/metadata.yml
+
+node2:
+  1. nodetool clearsnapshot -t 'old snapshot'
+
+Summary: 3 commands on 2 hosts would be executed.
+`, recorder.Report())
+}
+
+func TestRecorder_Empty(t *testing.T) {
+	require.Equal(t, "Dry run: no commands would change anything.\n", NewRecorder().Report())
+}
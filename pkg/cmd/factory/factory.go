@@ -1,40 +1,138 @@
 package factory
 
 import (
+	"github.com/hashicorp/go-multierror"
 	"github.com/kolesa-team/scylla-octopus/pkg/cmd"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd/audit"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd/dryrun"
 	"github.com/kolesa-team/scylla-octopus/pkg/cmd/local"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd/policy"
 	"github.com/kolesa-team/scylla-octopus/pkg/cmd/ssh"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd/sudo"
 	"go.uber.org/zap"
 )
 
+// a host name of the local machine in the audit log and dry-run reports
+const localHost = "localhost"
+
 // Factory of shell commands executors.
 // Depending on the options, will create local or SSH executors.
 type Factory struct {
 	options   Options
 	sshClient *ssh.Client
+	logger    *zap.SugaredLogger
+	// records every executed command, if enabled
+	auditLog *audit.Log
+	// records the commands that are not executed in dry-run mode
+	dryRun *dryrun.Recorder
 }
 
 type Options struct {
 	UseSSH bool
 	SSH    ssh.Options `yaml:"ssh"`
-	Debug  bool
+	// run the commands with sudo
+	Sudo sudo.Options `yaml:"sudo"`
+	// command timeouts by category
+	Timeouts policy.Timeouts `yaml:"timeouts"`
+	// retries of failed idempotent commands
+	Retry policy.RetryOptions `yaml:"retry"`
+	// an audit log of all executed commands
+	Audit audit.Options `yaml:"audit"`
+	Debug bool
+	// only execute read-only commands, and record the others (see cmd.ReadOnly)
+	DryRun bool `yaml:"-"`
 }
 
 // GetByHost returns a command executor for a given host.
 func (f Factory) GetByHost(host string) (cmd.Executor, error) {
-	if !f.options.UseSSH {
-		return local.Executor{
-			Debug: f.options.Debug,
-		}, nil
+	var executor cmd.Executor = local.Executor{
+		Debug: f.options.Debug,
+	}
+
+	if f.options.UseSSH {
+		sshExecutor, err := f.sshClient.GetByHost(host)
+		if err != nil {
+			return nil, err
+		}
+
+		executor = sshExecutor
+	}
+
+	if f.auditLog != nil {
+		executor = f.auditLog.Executor(host, executor)
+	}
+
+	if f.options.Sudo.Enabled {
+		executor = sudo.NewExecutor(executor, f.options.Sudo)
+	}
+
+	return f.withPolicy(host, executor), nil
+}
+
+// Local returns a command executor for the machine scylla-octopus runs on (e.g. to download backups),
+// regardless of the SSH options. The commands are not run with sudo.
+func (f Factory) Local() cmd.Executor {
+	var executor cmd.Executor = local.Executor{
+		Debug: f.options.Debug,
+	}
+
+	if f.auditLog != nil {
+		executor = f.auditLog.Executor(localHost, executor)
+	}
+
+	return f.withPolicy(localHost, executor)
+}
+
+// applies timeouts, retries and dry-run mode to a given executor
+func (f Factory) withPolicy(host string, executor cmd.Executor) cmd.Executor {
+	executor = policy.NewExecutor(executor, f.options.Timeouts, f.options.Retry, f.logger)
+
+	if f.dryRun != nil {
+		executor = f.dryRun.Executor(host, executor)
 	}
 
-	return f.sshClient.GetByHost(host)
+	return executor
+}
+
+// DryRunReport returns the commands that were not executed in dry-run mode, by host
+func (f Factory) DryRunReport() string {
+	if f.dryRun == nil {
+		return ""
+	}
+
+	return f.dryRun.Report()
+}
+
+// Close closes SSH connections and the audit log, if any
+func (f Factory) Close() error {
+	var result *multierror.Error
+
+	if f.sshClient != nil {
+		result = multierror.Append(result, f.sshClient.Close())
+	}
+
+	if f.auditLog != nil {
+		result = multierror.Append(result, f.auditLog.Close())
+	}
+
+	return result.ErrorOrNil()
 }
 
 func NewFactory(opts Options, logger *zap.SugaredLogger) (Factory, error) {
-	factory := Factory{options: opts}
+	factory := Factory{options: opts, logger: logger}
 	var err error
 
+	if opts.DryRun {
+		factory.dryRun = dryrun.NewRecorder()
+	}
+
+	if len(opts.Audit.File) > 0 {
+		factory.auditLog, err = audit.NewLog(opts.Audit, logger)
+		if err != nil {
+			return factory, err
+		}
+	}
+
 	if opts.UseSSH {
 		opts.SSH.Debug = opts.Debug
 		factory.sshClient, err = ssh.NewClient(opts.SSH, logger)
@@ -50,5 +148,6 @@ func NewTestFactory() Factory {
 		options: Options{
 			Debug: true,
 		},
+		logger: zap.S(),
 	}
 }
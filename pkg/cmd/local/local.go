@@ -1,8 +1,10 @@
 package local
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd/shell"
 	"os"
 	"os/exec"
 	"time"
@@ -16,9 +18,11 @@ type Executor struct {
 }
 
 func (r Executor) Execute(ctx context.Context, cmd *exec.Cmd) ([]byte, error) {
-	// wrap the command into "sh -c '...command...'",
-	// so that special things like output redirection work well.
-	wrapperCmd := exec.CommandContext(ctx, "sh", "-c", cmd.String())
+	// wrap the command into "sh -c '...command...'", the same way it runs over SSH
+	wrapperCmd := exec.Command("sh", "-c", shell.Line(cmd))
+	wrapperCmd.Stdin = cmd.Stdin
+	// the command runs in its own process group, so that its child processes are stopped, too
+	setProcessGroup(wrapperCmd)
 	timeStarted := time.Now()
 
 	if r.Debug {
@@ -28,7 +32,7 @@ func (r Executor) Execute(ctx context.Context, cmd *exec.Cmd) ([]byte, error) {
 		)
 	}
 
-	output, err := wrapperCmd.CombinedOutput()
+	output, err := run(ctx, wrapperCmd)
 	if r.Debug {
 		fmt.Printf(
 			"\n---[CMD] command done in %s, output:---\n%s\n",
@@ -40,6 +44,33 @@ func (r Executor) Execute(ctx context.Context, cmd *exec.Cmd) ([]byte, error) {
 	return output, err
 }
 
+// runs a command and kills its process group when the context is done
+func run(ctx context.Context, cmd *exec.Cmd) ([]byte, error) {
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	err := cmd.Start()
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			killProcessGroup(cmd)
+		case <-done:
+		}
+	}()
+
+	err = cmd.Wait()
+
+	return output.Bytes(), err
+}
+
 func (r Executor) Run(ctx context.Context, cmd *exec.Cmd) error {
 	_, err := r.Execute(ctx, cmd)
 
@@ -0,0 +1,16 @@
+//go:build windows
+// +build windows
+
+package local
+
+import (
+	"os/exec"
+)
+
+// process groups are not supported on windows
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// kills a command; its child processes are not stopped on windows
+func killProcessGroup(cmd *exec.Cmd) {
+	_ = cmd.Process.Kill()
+}
@@ -0,0 +1,175 @@
+package policy
+
+// This package limits the execution time of shell commands and retries the failed ones.
+// It wraps another executor (local or SSH); the commands are classified with cmd.WithCategory and cmd.Idempotent.
+
+import (
+	"context"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd/shell"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"os/exec"
+	"time"
+)
+
+// Timeouts limit the execution time of commands by category. Zero means no limit.
+type Timeouts struct {
+	Nodetool time.Duration
+	Cqlsh    time.Duration
+	Upload   time.Duration
+	Download time.Duration
+	List     time.Duration
+	Remove   time.Duration
+}
+
+// RetryOptions configure the retries of failed idempotent commands
+type RetryOptions struct {
+	// a total number of attempts. defaults to 3; 1 disables the retries
+	Attempts int
+	// a delay before the first retry, doubled after every attempt. defaults to 1s
+	InitialDelay time.Duration `yaml:"initialDelay"`
+	// a maximum delay between attempts. defaults to 30s
+	MaxDelay time.Duration `yaml:"maxDelay"`
+}
+
+// Executor applies timeouts and retries to the commands of another executor
+type Executor struct {
+	executor cmd.Executor
+	timeouts Timeouts
+	retry    RetryOptions
+	logger   *zap.SugaredLogger
+	// waits between attempts; replaced in tests
+	sleep func(ctx context.Context, delay time.Duration) error
+}
+
+func NewExecutor(
+	executor cmd.Executor,
+	timeouts Timeouts,
+	retry RetryOptions,
+	logger *zap.SugaredLogger,
+) *Executor {
+	if retry.Attempts <= 0 {
+		retry.Attempts = 3
+	}
+
+	if retry.InitialDelay <= 0 {
+		retry.InitialDelay = time.Second
+	}
+
+	if retry.MaxDelay <= 0 {
+		retry.MaxDelay = time.Second * 30
+	}
+
+	return &Executor{
+		executor: executor,
+		timeouts: timeouts,
+		retry:    retry,
+		logger:   logger,
+		sleep:    sleep,
+	}
+}
+
+// Execute executes a command with a timeout of its category.
+// Failed idempotent commands of any category are repeated with exponential backoff.
+// Uncategorized commands (such as `test -x`) are not repeated, since their failure is usually an answer, not an error;
+// neither are the expected failures (see cmd.WithExpectedFailure) and the commands that timed out, since they would most likely hang again.
+func (e *Executor) Execute(ctx context.Context, command *exec.Cmd) ([]byte, error) {
+	attempts := 1
+	if cmd.IsIdempotent(ctx) && len(cmd.CategoryOf(ctx)) > 0 {
+		attempts = e.retry.Attempts
+	}
+
+	delay := e.retry.InitialDelay
+
+	for attempt := 1; ; attempt++ {
+		output, timedOut, err := e.executeWithTimeout(ctx, command)
+		if err == nil || attempt >= attempts || ctx.Err() != nil || timedOut || cmd.IsExpectedFailure(ctx, output, err) {
+			return output, err
+		}
+
+		e.logger.Warnw(
+			"command failed; retrying",
+			"command", shell.Line(command),
+			"attempt", attempt,
+			"delay", delay,
+			"error", err,
+			"output", string(output),
+		)
+
+		if e.sleep(ctx, delay) != nil {
+			return output, err
+		}
+
+		delay *= 2
+		if delay > e.retry.MaxDelay {
+			delay = e.retry.MaxDelay
+		}
+	}
+}
+
+// executes a command with a timeout of its category; returns whether the command timed out
+func (e *Executor) executeWithTimeout(ctx context.Context, command *exec.Cmd) ([]byte, bool, error) {
+	timeout := e.timeout(cmd.CategoryOf(ctx))
+	if timeout <= 0 {
+		output, err := e.executor.Execute(ctx, command)
+
+		return output, false, err
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	output, err := e.executor.Execute(timeoutCtx, command)
+	if err != nil && ctx.Err() == nil && timeoutCtx.Err() == context.DeadlineExceeded {
+		return output, true, errors.Wrapf(err, "command timed out after %s", timeout)
+	}
+
+	return output, false, err
+}
+
+func (e *Executor) Run(ctx context.Context, command *exec.Cmd) error {
+	_, err := e.Execute(ctx, command)
+	return err
+}
+
+func (e *Executor) ReadFile(ctx context.Context, path string) ([]byte, error) {
+	return e.executor.ReadFile(ctx, path)
+}
+
+func (e *Executor) WriteFile(ctx context.Context, path string, data []byte) error {
+	return e.executor.WriteFile(ctx, path, data)
+}
+
+// returns a timeout of a given command category
+func (e *Executor) timeout(category cmd.Category) time.Duration {
+	switch category {
+	case cmd.CategoryNodetool:
+		return e.timeouts.Nodetool
+	case cmd.CategoryCqlsh:
+		return e.timeouts.Cqlsh
+	case cmd.CategoryUpload:
+		return e.timeouts.Upload
+	case cmd.CategoryDownload:
+		return e.timeouts.Download
+	case cmd.CategoryList:
+		return e.timeouts.List
+	case cmd.CategoryRemove:
+		return e.timeouts.Remove
+	default:
+		return 0
+	}
+}
+
+// waits for a given delay, unless the context is cancelled
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,129 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd/local"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd/test"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+// returns an executor that records the delays between attempts instead of waiting
+func newTestExecutor(executor cmd.Executor, timeouts Timeouts, retry RetryOptions) (*Executor, *[]time.Duration) {
+	delays := []time.Duration{}
+	policyExecutor := NewExecutor(executor, timeouts, retry, zap.S())
+	policyExecutor.sleep = func(ctx context.Context, delay time.Duration) error {
+		delays = append(delays, delay)
+		return nil
+	}
+
+	return policyExecutor, &delays
+}
+
+// Idempotent commands are repeated with exponential backoff until they succeed
+func TestExecutor_Retry(t *testing.T) {
+	testExecutor := &test.Executor{
+		Func: func(_ *exec.Cmd, executedCount int) (string, error) {
+			if executedCount < 3 {
+				return "connection refused", errors.New("exit status 1")
+			}
+
+			return "UN", nil
+		},
+	}
+	executor, delays := newTestExecutor(testExecutor, Timeouts{}, RetryOptions{
+		Attempts:     5,
+		InitialDelay: time.Second,
+		MaxDelay:     time.Second * 3,
+	})
+
+	ctx := cmd.ReadOnly(cmd.WithCategory(context.Background(), cmd.CategoryNodetool))
+	output, err := executor.Execute(ctx, cmd.Command("nodetool", "status"))
+	require.NoError(t, err)
+	require.Equal(t, "UN", string(output))
+	require.Equal(t, 4, testExecutor.ExecutedCount)
+	require.Equal(t, []time.Duration{time.Second, time.Second * 2, time.Second * 3}, *delays)
+}
+
+// The last error is returned when all attempts fail
+func TestExecutor_RetryAttemptsExceeded(t *testing.T) {
+	testExecutor := &test.Executor{Output: "error", Err: errors.New("exit status 1")}
+	executor, delays := newTestExecutor(testExecutor, Timeouts{}, RetryOptions{})
+
+	ctx := cmd.Idempotent(cmd.WithCategory(context.Background(), cmd.CategoryList))
+	_, err := executor.Execute(ctx, cmd.Command("aws", "s3", "ls"))
+	require.Error(t, err)
+	require.Equal(t, 3, testExecutor.ExecutedCount, "3 attempts are made by default")
+	require.Equal(t, []time.Duration{time.Second, time.Second * 2}, *delays)
+}
+
+// Other commands are not repeated
+func TestExecutor_NoRetry(t *testing.T) {
+	testExecutor := &test.Executor{Err: errors.New("exit status 1")}
+	executor, delays := newTestExecutor(testExecutor, Timeouts{}, RetryOptions{})
+
+	_, err := executor.Execute(
+		cmd.WithCategory(context.Background(), cmd.CategoryNodetool),
+		cmd.Command("nodetool", "snapshot"),
+	)
+	require.Error(t, err)
+
+	_, err = executor.Execute(cmd.ReadOnly(context.Background()), cmd.Command("test", "-x", "/usr/bin/pigz"))
+	require.Error(t, err)
+
+	require.Equal(t, 2, testExecutor.ExecutedCount)
+	require.Empty(t, *delays)
+}
+
+// Expected failures are not repeated
+func TestExecutor_ExpectedFailure(t *testing.T) {
+	testExecutor := &test.Executor{Err: errors.New("exit status 1")}
+	executor, delays := newTestExecutor(testExecutor, Timeouts{}, RetryOptions{})
+
+	ctx := cmd.WithExpectedFailure(
+		cmd.ReadOnly(cmd.WithCategory(context.Background(), cmd.CategoryList)),
+		func(output []byte, err error) bool {
+			return len(output) == 0
+		},
+	)
+	_, err := executor.Execute(ctx, cmd.Command("aws", "s3", "ls", "s3://bucket/missing/"))
+	require.Error(t, err)
+	require.Equal(t, 1, testExecutor.ExecutedCount)
+	require.Empty(t, *delays)
+}
+
+// A command that timed out is not repeated
+func TestExecutor_TimeoutNoRetry(t *testing.T) {
+	executor, delays := newTestExecutor(local.Executor{}, Timeouts{Upload: time.Millisecond * 100}, RetryOptions{})
+	ctx := cmd.Idempotent(cmd.WithCategory(context.Background(), cmd.CategoryUpload))
+
+	_, err := executor.Execute(ctx, cmd.Command("sleep", "5"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "command timed out after 100ms")
+	require.Empty(t, *delays, "a hung command would most likely hang again")
+}
+
+// A hung command is stopped after a timeout of its category
+func TestExecutor_Timeout(t *testing.T) {
+	executor, _ := newTestExecutor(local.Executor{}, Timeouts{Cqlsh: time.Millisecond * 100}, RetryOptions{Attempts: 1})
+	ctx := cmd.WithCategory(context.Background(), cmd.CategoryCqlsh)
+
+	timeStarted := time.Now()
+	_, err := executor.Execute(ctx, cmd.Command("sleep", "5"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "command timed out after 100ms")
+	require.Less(t, time.Since(timeStarted), time.Second*4)
+
+	// the commands of other categories are not limited
+	output, err := executor.Execute(
+		cmd.WithCategory(context.Background(), cmd.CategoryUpload),
+		cmd.Shellf("sleep 0.2 && echo %s", "done"),
+	)
+	require.NoError(t, err)
+	require.Equal(t, "done\n", string(output))
+}
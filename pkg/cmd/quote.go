@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd/shell"
+	"os/exec"
+)
+
+// Shellf creates a `sh -c` command from a shell script template, where every argument is quoted.
+// Use it for pipes, redirects and globs, e.g. Shellf("cd %s && rm -rf ./*", path)
+func Shellf(format string, args ...string) *exec.Cmd {
+	quotedArgs := make([]interface{}, len(args))
+	for i, arg := range args {
+		quotedArgs[i] = shell.Quote(arg)
+	}
+
+	return Command("sh", "-c", fmt.Sprintf(format, quotedArgs...))
+}
+
+// WithOutputFile returns a command that writes the output of a given command to a file
+func WithOutputFile(cmd *exec.Cmd, path string) *exec.Cmd {
+	return Command("sh", "-c", shell.Line(cmd)+" > "+shell.Quote(path))
+}
+
+// WithoutStderr returns a command that discards the standard error of a given command,
+// for the executors that combine it with the standard output
+func WithoutStderr(cmd *exec.Cmd) *exec.Cmd {
+	wrapped := Command("sh", "-c", shell.Line(cmd)+" 2>/dev/null")
+	wrapped.Stdin = cmd.Stdin
+
+	return wrapped
+}
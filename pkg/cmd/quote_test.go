@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"context"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd/local"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd/shell"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestShellf(t *testing.T) {
+	require.Equal(
+		t,
+		`sh -c 'cd '\''/my dir'\'' && rm -rf ./*'`,
+		shell.Line(Shellf("cd %s && rm -rf ./*", "/my dir")),
+	)
+	require.Equal(
+		t,
+		`sh -c 'cqlsh -e '\''DESC SCHEMA'\'' > /tmp/schema.cql'`,
+		shell.Line(WithOutputFile(Command("cqlsh", "-e", "DESC SCHEMA"), "/tmp/schema.cql")),
+	)
+}
+
+// Hostile arguments are passed as is, without being interpreted by the shell
+func TestShellf_Execute(t *testing.T) {
+	executor := local.Executor{}
+	dir := t.TempDir()
+	hostile := `a b'; touch ` + filepath.Join(dir, "injected") + `; echo '$(id) "quoted"`
+
+	output, err := executor.Execute(context.Background(), Command("echo", hostile))
+	require.NoError(t, err)
+	require.Equal(t, hostile+"\n", string(output))
+
+	output, err = executor.Execute(context.Background(), Shellf("echo %s | cat > %s", hostile, filepath.Join(dir, "out file")))
+	require.NoError(t, err, string(output))
+
+	written, err := ioutil.ReadFile(filepath.Join(dir, "out file"))
+	require.NoError(t, err)
+	require.Equal(t, hostile+"\n", string(written))
+
+	_, err = os.Stat(filepath.Join(dir, "injected"))
+	require.True(t, os.IsNotExist(err), "a command must not be injected")
+}
+
+// The standard error is discarded, while the standard input is kept
+func TestWithoutStderr(t *testing.T) {
+	command := Command("sh", "-c", "cat; echo warning >&2")
+	command.Stdin = strings.NewReader("output")
+
+	output, err := local.Executor{}.Execute(context.Background(), WithoutStderr(command))
+	require.NoError(t, err)
+	require.Equal(t, "output", string(output))
+}
@@ -0,0 +1,37 @@
+package shell
+
+// Quoting of shell command lines, shared by local and SSH executors.
+
+import (
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// characters that never need quoting in a shell
+var safeChars = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// Quote returns a string quoted for a POSIX shell, so that it's always passed as a single argument
+func Quote(s string) string {
+	if safeChars.MatchString(s) {
+		return s
+	}
+
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Line returns a shell command line with every argument quoted.
+// This is how the executors run a command, both locally and over SSH.
+// A binary is not quoted: it comes from the configuration and may consist of several words,
+// e.g. "docker exec scylla nodetool".
+func Line(cmd *exec.Cmd) string {
+	parts := []string{cmd.Path}
+
+	if len(cmd.Args) > 1 {
+		for _, arg := range cmd.Args[1:] {
+			parts = append(parts, Quote(arg))
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,29 @@
+package shell
+
+import (
+	"github.com/stretchr/testify/require"
+	"os/exec"
+	"testing"
+)
+
+func TestQuote(t *testing.T) {
+	require.Equal(t, "/var/lib/scylla", Quote("/var/lib/scylla"))
+	require.Equal(t, "''", Quote(""))
+	require.Equal(t, "'a b'", Quote("a b"))
+	require.Equal(t, `'it'\''s; rm -rf /'`, Quote("it's; rm -rf /"))
+}
+
+func TestLine(t *testing.T) {
+	require.Equal(
+		t,
+		`nodetool snapshot -t 'tag with spaces'`,
+		Line(&exec.Cmd{Path: "nodetool", Args: []string{"nodetool", "snapshot", "-t", "tag with spaces"}}),
+	)
+	require.Equal(t, "nodetool", Line(&exec.Cmd{Path: "nodetool"}))
+	require.Equal(
+		t,
+		"docker exec scylla nodetool status",
+		Line(&exec.Cmd{Path: "docker exec scylla nodetool", Args: []string{"docker exec scylla nodetool", "status"}}),
+		"a binary of several words is not quoted",
+	)
+}
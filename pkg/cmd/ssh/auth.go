@@ -0,0 +1,163 @@
+package ssh
+
+// SSH connection settings and authentication methods for every host.
+
+import (
+	"github.com/melbahja/goph"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+	"os"
+	"sync"
+)
+
+// HostOptions SSH connection overrides for a single host (given in cluster.hosts).
+// Empty values fall back to ~/.ssh/config and then to global options.
+type HostOptions struct {
+	Host    string
+	User    string
+	Port    uint
+	KeyFile string `yaml:"keyFile"`
+}
+
+// UnmarshalYAML allows a host to be given either as a plain address or as a mapping with overrides
+func (h *HostOptions) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var address string
+	if err := unmarshal(&address); err == nil {
+		*h = HostOptions{Host: address}
+
+		return nil
+	}
+
+	type plain HostOptions
+
+	return unmarshal((*plain)(h))
+}
+
+// connection settings for a host, resolved from global options, ssh config and host overrides
+type hostConnection struct {
+	Addr     string
+	User     string
+	Port     uint
+	KeyFiles []string
+	// key files that may be missing (e.g. from ssh config)
+	OptionalKeyFiles []string
+}
+
+// resolves connection settings for a host.
+// Host overrides take precedence over ~/.ssh/config, which takes precedence over global options.
+func (c *Client) resolveHost(host string) hostConnection {
+	conn := hostConnection{
+		Addr: host,
+		User: c.options.Username,
+		Port: c.options.Port,
+	}
+
+	sshConfig := c.sshConfig.lookup(host)
+	if len(sshConfig.HostName) > 0 {
+		conn.Addr = sshConfig.HostName
+	}
+	if len(sshConfig.User) > 0 {
+		conn.User = sshConfig.User
+	}
+	if sshConfig.Port > 0 {
+		conn.Port = sshConfig.Port
+	}
+	conn.OptionalKeyFiles = sshConfig.IdentityFiles
+
+	override := c.options.Hosts[host]
+	if len(override.User) > 0 {
+		conn.User = override.User
+	}
+	if override.Port > 0 {
+		conn.Port = override.Port
+	}
+	if len(override.KeyFile) > 0 {
+		conn.KeyFiles = append(conn.KeyFiles, override.KeyFile)
+	}
+
+	return conn
+}
+
+// keyring loads private keys once and keeps them in memory
+type keyring struct {
+	passphrase string
+	mu         sync.Mutex
+	signers    map[string]ssh.Signer
+}
+
+// returns a signer for a private key file
+func (k *keyring) signer(path string) (ssh.Signer, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	path = expandHome(path)
+	if signer, ok := k.signers[path]; ok {
+		return signer, nil
+	}
+
+	signer, err := goph.GetSigner(path, k.passphrase)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read SSH key from %s", path)
+	}
+
+	k.signers[path] = signer
+
+	return signer, nil
+}
+
+// returns authentication methods for a host: its own keys, global keys, ssh config keys, ssh-agent keys and a password
+func (c *Client) authMethods(conn hostConnection) (goph.Auth, error) {
+	signers := []ssh.Signer{}
+
+	keyFiles := append(append([]string{}, conn.KeyFiles...), c.options.KeyFiles...)
+	if len(c.options.KeyFile) > 0 {
+		keyFiles = append(keyFiles, c.options.KeyFile)
+	}
+
+	for _, keyFile := range keyFiles {
+		signer, err := c.keyring.signer(keyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		signers = append(signers, signer)
+	}
+
+	for _, keyFile := range conn.OptionalKeyFiles {
+		if _, err := os.Stat(keyFile); os.IsNotExist(err) {
+			continue
+		}
+
+		signer, err := c.keyring.signer(keyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		signers = append(signers, signer)
+	}
+
+	auth := goph.Auth{}
+
+	if len(signers) > 0 || c.agentSigners != nil {
+		auth = append(auth, ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+			if c.agentSigners == nil {
+				return signers, nil
+			}
+
+			agentSigners, err := c.agentSigners()
+			if err != nil {
+				c.logger.Warnw("could not read keys from ssh-agent", "error", err)
+				return signers, nil
+			}
+
+			return append(append([]ssh.Signer{}, signers...), agentSigners...), nil
+		}))
+	}
+
+	// a password is also used when there are no keys at all, even if it's empty
+	if len(c.options.Password) > 0 || len(auth) == 0 {
+		auth = append(auth, ssh.Password(c.options.Password))
+	}
+
+	return auth, nil
+}
@@ -0,0 +1,116 @@
+package ssh
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/go-yaml/yaml"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Hosts can be given as addresses or as mappings with overrides
+func TestHostOptions_UnmarshalYAML(t *testing.T) {
+	hosts := []HostOptions{}
+	err := yaml.Unmarshal([]byte(`
+- 10.0.0.1
+- host: 10.0.0.2
+  user: admin
+  port: 2222
+  keyFile: /keys/admin
+`), &hosts)
+
+	require.NoError(t, err)
+	require.Equal(t, []HostOptions{
+		{Host: "10.0.0.1"},
+		{Host: "10.0.0.2", User: "admin", Port: 2222, KeyFile: "/keys/admin"},
+	}, hosts)
+}
+
+// Host overrides take precedence over ssh config, which takes precedence over global options
+func TestClient_ResolveHost(t *testing.T) {
+	sshConfig, err := parseSSHConfig(strings.NewReader(`
+Host db-*
+    HostName %h.example.com
+    User config-user
+    Port 2200
+    IdentityFile /keys/config
+`))
+	require.NoError(t, err)
+
+	client := &Client{
+		options: Options{
+			Username: "root",
+			Port:     22,
+			Hosts: map[string]HostOptions{
+				"db-2": {Host: "db-2", User: "admin", KeyFile: "/keys/admin"},
+			},
+		},
+		sshConfig: sshConfig,
+	}
+
+	require.Equal(t, hostConnection{
+		Addr: "10.0.0.1",
+		User: "root",
+		Port: 22,
+	}, client.resolveHost("10.0.0.1"), "global options are used by default")
+
+	require.Equal(t, hostConnection{
+		Addr:             "db-1.example.com",
+		User:             "config-user",
+		Port:             2200,
+		OptionalKeyFiles: []string{"/keys/config"},
+	}, client.resolveHost("db-1"))
+
+	require.Equal(t, hostConnection{
+		Addr:             "db-2.example.com",
+		User:             "admin",
+		Port:             2200,
+		KeyFiles:         []string{"/keys/admin"},
+		OptionalKeyFiles: []string{"/keys/config"},
+	}, client.resolveHost("db-2"))
+}
+
+// writes a new private key to a temporary file
+func testKeyFile(t *testing.T) string {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "id_rsa")
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	require.NoError(t, ioutil.WriteFile(path, keyPem, 0600))
+
+	return path
+}
+
+// Keys from all sources are used; missing keys from ssh config are skipped
+func TestClient_AuthMethods(t *testing.T) {
+	client, err := NewClient(Options{
+		KeyFile:             testKeyFile(t),
+		KeyFiles:            []string{testKeyFile(t)},
+		InsecureSkipHostKey: true,
+	}, zap.S())
+	require.NoError(t, err)
+
+	auth, err := client.authMethods(hostConnection{
+		KeyFiles:         []string{testKeyFile(t)},
+		OptionalKeyFiles: []string{"/missing/key"},
+	})
+	require.NoError(t, err)
+	require.Len(t, auth, 1, "all keys must be combined in a single method")
+	require.Len(t, client.keyring.signers, 3)
+
+	_, err = client.authMethods(hostConnection{KeyFiles: []string{"/missing/key"}})
+	require.Error(t, err, "a missing host key file is an error")
+
+	_, err = NewClient(Options{
+		KeyFiles:            []string{"/missing/key"},
+		InsecureSkipHostKey: true,
+	}, zap.S())
+	require.Error(t, err, "a missing global key file is an error")
+}
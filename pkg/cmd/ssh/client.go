@@ -7,37 +7,85 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	cmdpkg "github.com/kolesa-team/scylla-octopus/pkg/cmd"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd/shell"
 	"github.com/melbahja/goph"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"io"
+	"net"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"sync"
 	"time"
 )
 
 type Options struct {
-	Port        uint
-	Username    string
-	Password    string
-	KeyFile     string `yaml:"keyFile"`
+	Port     uint
+	Username string
+	Password string
+	KeyFile  string `yaml:"keyFile"`
+	// more private keys to try
+	KeyFiles []string `yaml:"keyFiles"`
+	// a password for all private keys
 	KeyPassword string `yaml:"keyPassword"`
-	Debug       bool
+	// use the keys from ssh-agent (SSH_AUTH_SOCK)
+	UseAgent bool `yaml:"useAgent"`
+	// an OpenSSH client configuration file (e.g. ~/.ssh/config) to read HostName, User, Port and IdentityFile from.
+	// not used by default.
+	ConfigFile string `yaml:"configFile"`
+	// connection overrides by host (from cluster.hosts)
+	Hosts map[string]HostOptions `yaml:"-"`
+	// a jump host (bastion) to reach the database nodes through
+	JumpHost *JumpHostOptions `yaml:"jumpHost"`
+	// a file with known host keys. defaults to ~/.ssh/known_hosts
+	KnownHostsFile string `yaml:"knownHostsFile"`
+	// add the keys of unknown hosts to KnownHostsFile instead of failing.
+	// a changed key is still an error.
+	TrustOnFirstUse bool `yaml:"trustOnFirstUse"`
+	// do not verify host keys at all (for test environments only)
+	InsecureSkipHostKey bool `yaml:"insecureSkipHostKey"`
+	// a timeout to establish a connection. defaults to 10s
+	DialTimeout time.Duration `yaml:"dialTimeout"`
+	// how often to send TCP and SSH keepalives. defaults to 30s; a negative value disables SSH keepalives
+	KeepaliveInterval time.Duration `yaml:"keepaliveInterval"`
+	// a connection is considered dead after this number of unanswered keepalives. defaults to 3
+	KeepaliveMaxFailures int `yaml:"keepaliveMaxFailures"`
+	Debug                bool
 }
 
 type Client struct {
 	options   Options
 	executors sync.Map
 	logger    *zap.SugaredLogger
-	auth      goph.Auth
+	keyring   *keyring
+	// returns the keys from ssh-agent, if enabled
+	agentSigners func() ([]ssh.Signer, error)
+	sshConfig    sshConfig
+	// verifies the keys of remote hosts
+	hostKeyCallback ssh.HostKeyCallback
+	// nil if the host keys are not verified
+	hostKeyVerifier *hostKeyVerifier
+	// connections to every jump host of a chain, shared by all nodes.
+	// the last one is used to reach the nodes.
+	jumpClients []*ssh.Client
+	jumpMu      sync.Mutex
 }
 
-// HostExecutor implements a shell command executor on a remote machine over SSH
+// HostExecutor implements a shell command executor on a remote machine over SSH.
+// A lost connection is re-established on the next command;
+// idempotent commands (see cmd.Idempotent) are repeated once after reconnecting.
 type HostExecutor struct {
-	host    string
-	debug   bool
-	sshConn *goph.Client
-	logger  *zap.SugaredLogger
+	host   string
+	debug  bool
+	client *Client
+	mu     sync.Mutex
+	conn   *connection
+	closed bool
+	logger *zap.SugaredLogger
 }
 
 func NewClient(opts Options, logger *zap.SugaredLogger) (client *Client, err error) {
@@ -45,83 +93,269 @@ func NewClient(opts Options, logger *zap.SugaredLogger) (client *Client, err err
 		opts.Port = 22
 	}
 
+	if opts.DialTimeout <= 0 {
+		opts.DialTimeout = time.Second * 10
+	}
+
+	if opts.KeepaliveInterval == 0 {
+		opts.KeepaliveInterval = time.Second * 30
+	}
+
+	if opts.KeepaliveMaxFailures <= 0 {
+		opts.KeepaliveMaxFailures = 3
+	}
+
+	if len(opts.KnownHostsFile) == 0 {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, errors.Wrap(err, "could not find a default known_hosts file")
+		}
+
+		opts.KnownHostsFile = filepath.Join(home, ".ssh", "known_hosts")
+	}
+
 	client = &Client{
 		options:   opts,
 		logger:    logger,
 		executors: sync.Map{},
 	}
 
-	if len(opts.KeyFile) > 0 {
-		client.auth, err = goph.Key(opts.KeyFile, opts.KeyPassword)
+	client.keyring = &keyring{
+		passphrase: opts.KeyPassword,
+		signers:    map[string]ssh.Signer{},
+	}
+
+	// the global keys are loaded beforehand to report errors early
+	for _, keyFile := range append([]string{opts.KeyFile}, opts.KeyFiles...) {
+		if len(keyFile) == 0 {
+			continue
+		}
+
+		_, err = client.keyring.signer(keyFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.UseAgent {
+		agentConn, err := net.Dial("unix", os.Getenv("SSH_AUTH_SOCK"))
 		if err != nil {
-			return nil, errors.Wrapf(
-				err,
-				"could not read SSH key from %s",
-				opts.KeyFile,
-			)
+			return nil, errors.Wrap(err, "could not connect to ssh-agent (is SSH_AUTH_SOCK set?)")
+		}
+
+		client.agentSigners = agent.NewClient(agentConn).Signers
+	}
+
+	if len(opts.ConfigFile) > 0 {
+		client.sshConfig, err = readSSHConfig(opts.ConfigFile)
+		if err != nil {
+			return nil, err
 		}
-	} else {
-		client.auth = goph.Password(opts.Password)
+	}
+
+	client.hostKeyVerifier, err = newHostKeyVerifier(opts, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	client.hostKeyCallback = ssh.InsecureIgnoreHostKey()
+	if client.hostKeyVerifier != nil {
+		client.hostKeyCallback = client.hostKeyVerifier.verify
 	}
 
 	return client, nil
 }
 
 // GetByHost returns a shell command executor on a remote host.
-// Attempts to only create SSH connections once, keeping them in a `sync.Map`.
+// Only creates one SSH connection per host, even if called concurrently:
+// the executors are kept in a `sync.Map`, and an executor connects under its own lock.
 func (c *Client) GetByHost(host string) (*HostExecutor, error) {
-	_, ok := c.executors.Load(host)
-	if !ok {
-		logger := c.logger.With("host", host)
+	newExecutor := &HostExecutor{
+		host:   host,
+		debug:  c.options.Debug,
+		client: c,
+		logger: c.logger.With("host", host),
+	}
+
+	stored, _ := c.executors.LoadOrStore(host, newExecutor)
+	executor := stored.(*HostExecutor)
+
+	// an executor that could not connect is kept as well, and connects again on the next call
+	_, err := executor.getConnection()
+	if err != nil {
+		return nil, err
+	}
+
+	return executor, nil
+}
+
+// Close closes the connections to all hosts
+func (c *Client) Close() error {
+	c.executors.Range(func(_, executor interface{}) bool {
+		executor.(*HostExecutor).Close()
+		return true
+	})
+
+	c.jumpMu.Lock()
+	defer c.jumpMu.Unlock()
+
+	err := closeJumpClients(c.jumpClients)
+	c.jumpClients = nil
+
+	return err
+}
+
+// creates a new SSH connection to a host
+func (c *Client) dial(host string, logger *zap.SugaredLogger) (*connection, error) {
+	conn := c.resolveHost(host)
+	logger.Debugw(
+		"creating SSH connection",
+		"host",
+		host,
+		"addr",
+		conn.Addr,
+		"port",
+		conn.Port,
+		"user",
+		conn.User,
+	)
+
+	auth, err := c.authMethods(conn)
+	if err != nil {
+		return nil, err
+	}
 
-		logger.Debugw(
-			"creating SSH connection",
-			"host",
+	sshConn, err := c.connect(&goph.Config{
+		Auth:     auth,
+		User:     conn.User,
+		Addr:     conn.Addr,
+		Port:     conn.Port,
+		Timeout:  c.options.DialTimeout,
+		Callback: c.hostKeyCallback,
+	})
+
+	if err != nil {
+		return nil, errors.Wrapf(
+			err,
+			"could not create SSH connection to %s as %s",
 			host,
+			conn.User,
 		)
-		sshConn, err := goph.NewConn(&goph.Config{
-			Auth:     c.auth,
-			User:     c.options.Username,
-			Addr:     host,
-			Port:     c.options.Port,
-			Timeout:  time.Second * 2,
-			Callback: ssh.InsecureIgnoreHostKey(),
-		})
+	}
 
-		if err != nil {
-			return nil, errors.Wrapf(
-				err,
-				"could not create SSH connection to %s as %s",
-				host,
-				c.options.Username,
-			)
-		}
+	result := newConnection(sshConn)
+	if c.options.KeepaliveInterval > 0 {
+		go result.keepalive(c.options.KeepaliveInterval, c.options.KeepaliveMaxFailures, logger)
+	}
 
-		c.executors.Store(host, &HostExecutor{
-			host:    host,
-			debug:   c.options.Debug,
-			sshConn: sshConn,
-			logger:  logger,
-		})
+	return result, nil
+}
+
+// returns a working connection, re-establishing it if it's dead
+func (h *HostExecutor) getConnection() (*connection, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, fmt.Errorf("SSH connection to %s is closed", h.host)
+	}
+
+	if h.conn != nil && !h.conn.isDead() {
+		return h.conn, nil
 	}
 
-	result, _ := c.executors.Load(host)
+	if h.conn != nil {
+		h.logger.Info("reconnecting over SSH")
+	}
 
-	return result.(*HostExecutor), nil
+	conn, err := h.client.dial(h.host, h.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	h.conn = conn
+
+	return conn, nil
+}
+
+// Close closes the connection to a host; the executor cannot be used afterwards
+func (h *HostExecutor) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	if h.conn != nil {
+		h.conn.close()
+		h.conn = nil
+	}
+}
+
+// executes an operation over SSH connection.
+// if the operation fails because the connection is lost, the connection is re-established,
+// and the operation is repeated once if it's safe to do so.
+func (h *HostExecutor) withConnection(
+	ctx context.Context,
+	idempotent bool,
+	operation func(conn *connection) error,
+) error {
+	conn, err := h.getConnection()
+	if err != nil {
+		return err
+	}
+
+	err = operation(conn)
+	if err == nil || ctx.Err() != nil || conn.checkAlive(h.client.options.DialTimeout) {
+		return err
+	}
+
+	if !idempotent {
+		return errors.Wrapf(err, "SSH connection to %s was lost", h.host)
+	}
+
+	h.logger.Warnw("SSH connection was lost; retrying", "error", err)
+
+	conn, err = h.getConnection()
+	if err != nil {
+		return err
+	}
+
+	return operation(conn)
 }
 
 func (h *HostExecutor) Execute(ctx context.Context, cmd *exec.Cmd) ([]byte, error) {
+	var output []byte
+
+	err := h.withConnection(ctx, cmdpkg.IsIdempotent(ctx), func(conn *connection) error {
+		var err error
+		output, err = h.execute(ctx, conn, cmd)
+
+		return err
+	})
+
+	return output, err
+}
+
+func (h *HostExecutor) execute(ctx context.Context, conn *connection, cmd *exec.Cmd) ([]byte, error) {
 	timeStarted := time.Now()
 
 	if h.debug {
-		fmt.Printf("\n---[SSH] executing command at %s:---\n%s\n", h.host, cmd.String())
+		fmt.Printf("\n---[SSH] executing command at %s:---\n%s\n", h.host, shell.Line(cmd))
 	}
 
-	sshCmd, err := h.sshConn.CommandContext(ctx, cmd.String())
+	sshCmd, err := conn.client.CommandContext(ctx, shell.Line(cmd))
 	if err != nil {
 		return nil, err
 	}
 
+	if cmd.Stdin != nil {
+		// the input is read from the start if a command is repeated
+		if seeker, ok := cmd.Stdin.(io.Seeker); ok {
+			_, _ = seeker.Seek(0, io.SeekStart)
+		}
+
+		sshCmd.Stdin = cmd.Stdin
+	}
+
 	output, err := sshCmd.CombinedOutput()
 
 	if h.debug {
@@ -141,15 +375,31 @@ func (h *HostExecutor) Run(ctx context.Context, cmd *exec.Cmd) error {
 	return err
 }
 
+// ReadFile reads a file over SFTP. Reading is always repeated after a lost connection.
 func (h *HostExecutor) ReadFile(ctx context.Context, path string) ([]byte, error) {
 	var data bytes.Buffer
-	err := h.sshConn.ReadFile(path, &data)
+
+	err := h.withConnection(ctx, true, func(conn *connection) error {
+		data.Reset()
+
+		return conn.client.ReadFile(path, &data)
+	})
 
 	return data.Bytes(), err
 }
 
+// WriteFile writes a file over SFTP. The file is overwritten, so writing is repeated after a lost connection.
 func (h *HostExecutor) WriteFile(ctx context.Context, path string, data []byte) error {
-	source := bytes.NewReader(data)
+	return h.withConnection(ctx, true, func(conn *connection) error {
+		return conn.client.WriteFile(path, bytes.NewReader(data))
+	})
+}
+
+// returns the host key algorithms to negotiate with an address ("host:port"): the ones of its known keys, or any
+func (c *Client) hostKeyAlgorithms(address string) []string {
+	if c.hostKeyVerifier == nil {
+		return nil
+	}
 
-	return h.sshConn.WriteFile(path, source)
+	return c.hostKeyVerifier.algorithms(address)
 }
@@ -21,6 +21,8 @@ func _ExampleSSHClient() {
 		Username: "root",
 		KeyFile:  "./../../../test/ssh/id_rsa",
 		Debug:    false,
+		// the host keys of docker-compose containers are not known
+		InsecureSkipHostKey: true,
 	}
 	client, err := NewClient(opts, logger.Sugar())
 	if err != nil {
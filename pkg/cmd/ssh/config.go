@@ -0,0 +1,167 @@
+package ssh
+
+// A minimal parser of OpenSSH client configuration (~/.ssh/config).
+// Only `Host` sections with HostName, User, Port and IdentityFile are supported;
+// `Match` sections and other keywords are ignored.
+
+import (
+	"bufio"
+	"github.com/pkg/errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// sshConfig a parsed ssh client configuration
+type sshConfig struct {
+	sections []sshConfigSection
+}
+
+// sshConfigSection a `Host` section of ssh client configuration
+type sshConfigSection struct {
+	patterns []string
+	// lower-cased keywords with their values
+	options map[string][]string
+}
+
+// sshHostConfig connection settings for a host from ssh client configuration
+type sshHostConfig struct {
+	HostName      string
+	User          string
+	Port          uint
+	IdentityFiles []string
+}
+
+// reads ssh client configuration from a file
+func readSSHConfig(path string) (sshConfig, error) {
+	file, err := os.Open(expandHome(path))
+	if err != nil {
+		return sshConfig{}, errors.Wrapf(err, "could not read ssh config %s", path)
+	}
+	defer file.Close()
+
+	config, err := parseSSHConfig(file)
+	if err != nil {
+		return config, errors.Wrapf(err, "could not parse ssh config %s", path)
+	}
+
+	return config, nil
+}
+
+// parses ssh client configuration
+func parseSSHConfig(reader io.Reader) (sshConfig, error) {
+	config := sshConfig{}
+	// the options before the first `Host` keyword apply to all hosts
+	section := &sshConfigSection{patterns: []string{"*"}, options: map[string][]string{}}
+	scanner := bufio.NewScanner(reader)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		keyword, value := splitSSHConfigLine(line)
+		keyword = strings.ToLower(keyword)
+
+		switch keyword {
+		case "host":
+			config.sections = append(config.sections, *section)
+			section = &sshConfigSection{patterns: strings.Fields(value), options: map[string][]string{}}
+		case "match":
+			// `Match` conditions are not supported, so its options are skipped
+			config.sections = append(config.sections, *section)
+			section = &sshConfigSection{options: map[string][]string{}}
+		default:
+			section.options[keyword] = append(section.options[keyword], value)
+		}
+	}
+
+	config.sections = append(config.sections, *section)
+
+	return config, scanner.Err()
+}
+
+// splits a line into a keyword and a value, separated by whitespace or "="
+func splitSSHConfigLine(line string) (keyword, value string) {
+	index := strings.IndexAny(line, " \t=")
+	if index < 0 {
+		return line, ""
+	}
+
+	keyword = line[:index]
+	value = strings.TrimSpace(line[index:])
+	value = strings.TrimSpace(strings.TrimPrefix(value, "="))
+
+	return keyword, strings.Trim(value, `"`)
+}
+
+// lookup returns the settings for a given host.
+// Like in OpenSSH, the first obtained value of every keyword is used (except IdentityFile, which accumulates).
+func (c sshConfig) lookup(host string) sshHostConfig {
+	result := sshHostConfig{}
+
+	for _, section := range c.sections {
+		if !section.matches(host) {
+			continue
+		}
+
+		if values := section.options["hostname"]; len(values) > 0 && result.HostName == "" {
+			result.HostName = strings.ReplaceAll(values[0], "%h", host)
+		}
+
+		if values := section.options["user"]; len(values) > 0 && result.User == "" {
+			result.User = values[0]
+		}
+
+		if values := section.options["port"]; len(values) > 0 && result.Port == 0 {
+			port, err := strconv.ParseUint(values[0], 10, 16)
+			if err == nil {
+				result.Port = uint(port)
+			}
+		}
+
+		for _, identityFile := range section.options["identityfile"] {
+			result.IdentityFiles = append(result.IdentityFiles, expandHome(identityFile))
+		}
+	}
+
+	return result
+}
+
+// whether a section applies to a given host.
+// A host must match at least one pattern and none of negated ("!") patterns.
+func (s sshConfigSection) matches(host string) bool {
+	matched := false
+
+	for _, pattern := range s.patterns {
+		if strings.HasPrefix(pattern, "!") {
+			if ok, _ := filepath.Match(pattern[1:], host); ok {
+				return false
+			}
+			continue
+		}
+
+		if ok, _ := filepath.Match(pattern, host); ok {
+			matched = true
+		}
+	}
+
+	return matched
+}
+
+// replaces a leading "~/" in a path with the user's home directory
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+
+	return filepath.Join(home, path[2:])
+}
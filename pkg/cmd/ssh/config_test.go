@@ -0,0 +1,41 @@
+package ssh
+
+import (
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestParseSSHConfig(t *testing.T) {
+	config, err := parseSSHConfig(strings.NewReader(`
+# defaults
+IdentityFile /keys/default
+
+Host db-*.prod !db-9.prod
+    HostName %h.example.com
+    User admin
+    Port 2222
+    IdentityFile=/keys/prod
+
+Match user root
+    User ignored
+
+Host *
+    User fallback
+    Port 22
+`))
+	require.NoError(t, err)
+
+	require.Equal(t, sshHostConfig{
+		HostName:      "db-1.prod.example.com",
+		User:          "admin",
+		Port:          2222,
+		IdentityFiles: []string{"/keys/default", "/keys/prod"},
+	}, config.lookup("db-1.prod"))
+
+	require.Equal(t, sshHostConfig{
+		User:          "fallback",
+		Port:          22,
+		IdentityFiles: []string{"/keys/default"},
+	}, config.lookup("db-9.prod"), "a negated pattern must exclude a host")
+}
@@ -0,0 +1,114 @@
+package ssh
+
+// SSH connection health: keepalives and detection of dead connections.
+
+import (
+	"github.com/melbahja/goph"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/ssh"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// a request that OpenSSH servers answer without side effects (like ServerAliveInterval in OpenSSH client)
+const keepaliveRequest = "keepalive@openssh.com"
+
+// connection an SSH connection to a node with its health state
+type connection struct {
+	client *goph.Client
+	// 1 if the connection is known to be dead
+	dead      int32
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newConnection(client *goph.Client) *connection {
+	return &connection{
+		client: client,
+		done:   make(chan struct{}),
+	}
+}
+
+func (c *connection) isDead() bool {
+	return atomic.LoadInt32(&c.dead) == 1
+}
+
+// marks a connection as dead and closes it
+func (c *connection) kill() {
+	atomic.StoreInt32(&c.dead, 1)
+	c.close()
+}
+
+// closes a connection and stops the keepalives
+func (c *connection) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		_ = c.client.Close()
+	})
+}
+
+// checks whether a connection still works after an error, and kills it otherwise
+func (c *connection) checkAlive(timeout time.Duration) bool {
+	if c.isDead() {
+		return false
+	}
+
+	if !isAlive(c.client.Client, timeout) {
+		c.kill()
+		return false
+	}
+
+	return true
+}
+
+// sends keepalive requests every interval, and kills a connection after a number of consecutive failures
+func (c *connection) keepalive(interval time.Duration, maxFailures int, logger *zap.SugaredLogger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	failures := 0
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+		}
+
+		if isAlive(c.client.Client, interval) {
+			failures = 0
+			continue
+		}
+
+		failures++
+		logger.Warnw("SSH keepalive failed", "failures", failures)
+
+		if failures >= maxFailures {
+			logger.Warn("SSH connection is dead; reconnecting on the next command")
+			c.kill()
+
+			return
+		}
+	}
+}
+
+// whether an SSH connection answers a keepalive request within a timeout.
+// a rejected request still means the connection is alive.
+func isAlive(client *ssh.Client, timeout time.Duration) bool {
+	result := make(chan error, 1)
+
+	go func() {
+		_, _, err := client.SendRequest(keepaliveRequest, true, nil)
+		result <- err
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-result:
+		return err == nil
+	case <-timer.C:
+		return false
+	}
+}
@@ -0,0 +1,111 @@
+package ssh
+
+import (
+	"context"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+// creates an SSH client for a test server
+func newTestClient(t *testing.T, server *testServer, opts Options) (*Client, string) {
+	host, port := server.addr()
+	opts.Username = "test"
+	opts.Password = "test"
+	opts.Port = port
+	opts.InsecureSkipHostKey = true
+
+	client, err := NewClient(opts, zap.S())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	return client, host
+}
+
+// An idempotent command is repeated after reconnecting; other commands fail, but the next one reconnects
+func TestHostExecutor_Reconnect(t *testing.T) {
+	server := newTestServer(t, "node")
+	client, host := newTestClient(t, server, Options{KeepaliveInterval: -1})
+
+	executor, err := client.GetByHost(host)
+	require.NoError(t, err)
+
+	server.dropConnections()
+	output, err := executor.Execute(cmd.Idempotent(context.Background()), cmd.Command("nodetool", "status"))
+	require.NoError(t, err, "an idempotent command must be repeated after reconnecting")
+	require.Equal(t, "ok from node", string(output))
+
+	server.dropConnections()
+	_, err = executor.Execute(context.Background(), cmd.Command("nodetool", "repair"))
+	require.Error(t, err, "a non-idempotent command must not be repeated")
+	require.Contains(t, err.Error(), "SSH connection to "+host+" was lost")
+
+	output, err = executor.Execute(context.Background(), cmd.Command("nodetool", "repair"))
+	require.NoError(t, err, "the next command must reconnect")
+	require.Equal(t, "ok from node", string(output))
+
+	connections, _ := server.counters()
+	require.Equal(t, 3, connections)
+}
+
+// A dead connection is detected by keepalives
+func TestHostExecutor_Keepalive(t *testing.T) {
+	server := newTestServer(t, "node")
+	client, host := newTestClient(t, server, Options{
+		KeepaliveInterval:    time.Millisecond * 10,
+		KeepaliveMaxFailures: 1,
+	})
+
+	executor, err := client.GetByHost(host)
+	require.NoError(t, err)
+	conn := executor.conn
+
+	time.Sleep(time.Millisecond * 50)
+	require.False(t, conn.isDead(), "a working connection must stay alive")
+
+	server.dropConnections()
+	require.Eventually(t, conn.isDead, time.Second, time.Millisecond*10, "a dropped connection must be detected")
+
+	_, err = executor.Execute(context.Background(), cmd.Command("nodetool", "repair"))
+	require.NoError(t, err, "a command must be executed over a new connection")
+}
+
+// The connections cannot be used after Close
+func TestClient_Close(t *testing.T) {
+	server := newTestServer(t, "node")
+	client, host := newTestClient(t, server, Options{})
+
+	executor, err := client.GetByHost(host)
+	require.NoError(t, err)
+
+	require.NoError(t, client.Close())
+
+	_, err = executor.Execute(context.Background(), cmd.Command("nodetool", "status"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "is closed")
+}
+
+// Concurrent calls for the same host share a single connection
+func TestClient_GetByHostConcurrent(t *testing.T) {
+	server := newTestServer(t, "node")
+	client, host := newTestClient(t, server, Options{KeepaliveInterval: -1})
+
+	executors := make(chan *HostExecutor, 10)
+	for i := 0; i < cap(executors); i++ {
+		go func() {
+			executor, err := client.GetByHost(host)
+			require.NoError(t, err)
+			executors <- executor
+		}()
+	}
+
+	first := <-executors
+	for i := 1; i < cap(executors); i++ {
+		require.Same(t, first, <-executors)
+	}
+
+	connections, _ := server.counters()
+	require.Equal(t, 1, connections)
+}
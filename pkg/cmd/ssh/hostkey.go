@@ -0,0 +1,225 @@
+package ssh
+
+// Host key verification against a known_hosts file.
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// hostKeyVerifier checks the host keys of remote servers against a known_hosts file.
+// In trust-on-first-use mode, the keys of unknown hosts are appended to the file.
+type hostKeyVerifier struct {
+	knownHostsFile  string
+	trustOnFirstUse bool
+	// the file is re-read on every connection, because new keys may be added concurrently
+	mu     sync.Mutex
+	logger *zap.SugaredLogger
+}
+
+// creates a host key callback according to options
+func newHostKeyCallback(opts Options, logger *zap.SugaredLogger) (ssh.HostKeyCallback, error) {
+	verifier, err := newHostKeyVerifier(opts, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	if verifier == nil {
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+
+	return verifier.verify, nil
+}
+
+// creates a host key verifier according to options; nil if the verification is disabled
+func newHostKeyVerifier(opts Options, logger *zap.SugaredLogger) (*hostKeyVerifier, error) {
+	if opts.InsecureSkipHostKey {
+		logger.Warn("SSH host key verification is disabled (insecureSkipHostKey=true)")
+
+		return nil, nil
+	}
+
+	verifier := &hostKeyVerifier{
+		knownHostsFile:  opts.KnownHostsFile,
+		trustOnFirstUse: opts.TrustOnFirstUse,
+		logger:          logger,
+	}
+
+	err := verifier.init()
+	if err != nil {
+		return nil, err
+	}
+
+	return verifier, nil
+}
+
+// checks that a known_hosts file exists, or creates an empty one in trust-on-first-use mode
+func (v *hostKeyVerifier) init() error {
+	_, err := os.Stat(v.knownHostsFile)
+	if err == nil {
+		return nil
+	}
+
+	if !os.IsNotExist(err) || !v.trustOnFirstUse {
+		return errors.Wrapf(
+			err,
+			"could not read known_hosts file %s (set ssh.trustOnFirstUse=true to create it)",
+			v.knownHostsFile,
+		)
+	}
+
+	err = os.MkdirAll(filepath.Dir(v.knownHostsFile), 0700)
+	if err != nil {
+		return errors.Wrapf(err, "could not create a directory for known_hosts file %s", v.knownHostsFile)
+	}
+
+	file, err := os.OpenFile(v.knownHostsFile, os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "could not create known_hosts file %s", v.knownHostsFile)
+	}
+
+	return file.Close()
+}
+
+// verify implements ssh.HostKeyCallback
+func (v *hostKeyVerifier) verify(hostname string, remote net.Addr, key ssh.PublicKey) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	callback, err := knownhosts.New(v.knownHostsFile)
+	if err != nil {
+		return errors.Wrapf(err, "could not read known_hosts file %s", v.knownHostsFile)
+	}
+
+	err = callback(hostname, remote, key)
+	if err == nil {
+		return nil
+	}
+
+	keyErr, ok := err.(*knownhosts.KeyError)
+	if !ok {
+		return err
+	}
+
+	// the host is known, but its key is different
+	if len(keyErr.Want) > 0 {
+		knownKeys := []string{}
+		for _, knownKey := range keyErr.Want {
+			knownKeys = append(knownKeys, fmt.Sprintf(
+				"%s (%s:%d)",
+				ssh.FingerprintSHA256(knownKey.Key),
+				knownKey.Filename,
+				knownKey.Line,
+			))
+		}
+
+		return fmt.Errorf(
+			"SSH host key for %s has changed: got %s, expected %s. "+
+				"It may be a man-in-the-middle attack; if the key was changed intentionally, remove the old key from known_hosts",
+			hostname,
+			ssh.FingerprintSHA256(key),
+			strings.Join(knownKeys, ", "),
+		)
+	}
+
+	if !v.trustOnFirstUse {
+		return fmt.Errorf(
+			"SSH host key for %s (%s) is unknown: add it to %s (e.g. with ssh-keyscan) or set ssh.trustOnFirstUse=true",
+			hostname,
+			ssh.FingerprintSHA256(key),
+			v.knownHostsFile,
+		)
+	}
+
+	return v.addKey(hostname, remote, key)
+}
+
+// returns the algorithms of the keys known for an address ("host:port"), like OpenSSH does,
+// so that a server with several host keys presents a known one. Empty if the host is unknown.
+func (v *hostKeyVerifier) algorithms(address string) []string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	callback, err := knownhosts.New(v.knownHostsFile)
+	if err != nil {
+		return nil
+	}
+
+	// a placeholder key never matches, so the error lists all known keys of the host
+	err = callback(address, &net.TCPAddr{}, placeholderKey{})
+	keyErr, ok := err.(*knownhosts.KeyError)
+	if !ok {
+		return nil
+	}
+
+	algorithms := []string{}
+	for _, knownKey := range keyErr.Want {
+		algorithm := knownKey.Key.Type()
+		if !containsString(algorithms, algorithm) {
+			algorithms = append(algorithms, algorithm)
+		}
+	}
+
+	return algorithms
+}
+
+// placeholderKey a public key that is not equal to any real one
+type placeholderKey struct{}
+
+func (placeholderKey) Type() string {
+	return "placeholder"
+}
+
+func (placeholderKey) Marshal() []byte {
+	return []byte("placeholder")
+}
+
+func (placeholderKey) Verify([]byte, *ssh.Signature) error {
+	return errors.New("a placeholder key cannot verify signatures")
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// appends a host key to known_hosts file
+func (v *hostKeyVerifier) addKey(hostname string, remote net.Addr, key ssh.PublicKey) error {
+	addresses := []string{knownhosts.Normalize(hostname)}
+	if remote != nil && knownhosts.Normalize(remote.String()) != addresses[0] {
+		addresses = append(addresses, knownhosts.Normalize(remote.String()))
+	}
+
+	file, err := os.OpenFile(v.knownHostsFile, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "could not open known_hosts file %s", v.knownHostsFile)
+	}
+	defer file.Close()
+
+	_, err = file.WriteString(knownhosts.Line(addresses, key) + "\n")
+	if err != nil {
+		return errors.Wrapf(err, "could not add a host key to %s", v.knownHostsFile)
+	}
+
+	v.logger.Warnw(
+		"trusting a new SSH host key",
+		"host", hostname,
+		"fingerprint", ssh.FingerprintSHA256(key),
+		"knownHostsFile", v.knownHostsFile,
+	)
+
+	return nil
+}
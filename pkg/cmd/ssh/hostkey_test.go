@@ -0,0 +1,110 @@
+package ssh
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"testing"
+)
+
+// generates a random host key
+func testHostKey(t *testing.T) ssh.PublicKey {
+	publicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	key, err := ssh.NewPublicKey(publicKey)
+	require.NoError(t, err)
+
+	return key
+}
+
+// The keys of unknown hosts are recorded in trust-on-first-use mode, and a changed key is rejected
+func TestHostKeyCallback_TrustOnFirstUse(t *testing.T) {
+	knownHostsFile := filepath.Join(t.TempDir(), ".ssh", "known_hosts")
+	callback, err := newHostKeyCallback(Options{
+		KnownHostsFile:  knownHostsFile,
+		TrustOnFirstUse: true,
+	}, zap.S())
+	require.NoError(t, err, "a missing known_hosts file must be created")
+
+	remote := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 22}
+	key := testHostKey(t)
+
+	require.NoError(t, callback("10.0.0.1:22", remote, key), "a new key must be trusted")
+	require.NoError(t, callback("10.0.0.1:22", remote, key), "a recorded key must be accepted")
+
+	knownHosts, err := ioutil.ReadFile(knownHostsFile)
+	require.NoError(t, err)
+	require.Contains(t, string(knownHosts), "10.0.0.1 ssh-ed25519 ")
+
+	err = callback("10.0.0.1:22", remote, testHostKey(t))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "SSH host key for 10.0.0.1:22 has changed")
+}
+
+// Unknown hosts are rejected unless trust-on-first-use mode is enabled
+func TestHostKeyCallback_UnknownHost(t *testing.T) {
+	knownHostsFile := filepath.Join(t.TempDir(), "known_hosts")
+
+	_, err := newHostKeyCallback(Options{KnownHostsFile: knownHostsFile}, zap.S())
+	require.Error(t, err, "a missing known_hosts file is an error")
+
+	require.NoError(t, ioutil.WriteFile(knownHostsFile, nil, 0600))
+	callback, err := newHostKeyCallback(Options{KnownHostsFile: knownHostsFile}, zap.S())
+	require.NoError(t, err)
+
+	err = callback("10.0.0.1:22", &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 22}, testHostKey(t))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "SSH host key for 10.0.0.1:22")
+	require.Contains(t, err.Error(), "is unknown")
+}
+
+// Host key verification can be disabled explicitly
+func TestHostKeyCallback_Insecure(t *testing.T) {
+	callback, err := newHostKeyCallback(Options{
+		KnownHostsFile:      filepath.Join(t.TempDir(), "missing"),
+		InsecureSkipHostKey: true,
+	}, zap.S())
+	require.NoError(t, err)
+	require.NoError(t, callback("10.0.0.1:22", &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 22}, testHostKey(t)))
+}
+
+// Only the algorithms of the known keys are negotiated:
+// a server with an ecdsa key, which is preferred by default, must present its known ed25519 key
+func TestClient_HostKeyAlgorithms(t *testing.T) {
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ecdsaSigner, err := ssh.NewSignerFromKey(ecdsaKey)
+	require.NoError(t, err)
+
+	server := newTestServer(t, "node", ecdsaSigner)
+	host, port := server.addr()
+
+	knownHostsFile := filepath.Join(t.TempDir(), "known_hosts")
+	address := knownhosts.Normalize(net.JoinHostPort(host, fmt.Sprint(port)))
+	require.NoError(t, ioutil.WriteFile(knownHostsFile, []byte(knownhosts.Line([]string{address}, server.hostKey)+"\n"), 0600))
+
+	client, err := NewClient(Options{
+		Username:       "test",
+		Password:       "test",
+		Port:           port,
+		KnownHostsFile: knownHostsFile,
+	}, zap.S())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	require.Equal(t, []string{ssh.KeyAlgoED25519}, client.hostKeyAlgorithms(net.JoinHostPort(host, fmt.Sprint(port))))
+	require.Empty(t, client.hostKeyAlgorithms("10.0.0.1:22"), "any algorithm is negotiated with an unknown host")
+
+	_, err = client.GetByHost(host)
+	require.NoError(t, err, "a known key must be accepted instead of reporting a changed one")
+}
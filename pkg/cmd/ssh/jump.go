@@ -0,0 +1,229 @@
+package ssh
+
+// Connections through jump hosts (bastions), like OpenSSH ProxyJump.
+
+import (
+	"fmt"
+	"github.com/melbahja/goph"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+	"net"
+	"time"
+)
+
+// JumpHostOptions a jump host (bastion) that the database nodes are reached through.
+// Empty credentials fall back to global options.
+type JumpHostOptions struct {
+	Host        string
+	Port        uint
+	Username    string
+	Password    string
+	KeyFile     string `yaml:"keyFile"`
+	KeyPassword string `yaml:"keyPassword"`
+	// another jump host that this one is reached through (for chained jumps)
+	JumpHost *JumpHostOptions `yaml:"jumpHost"`
+}
+
+// returns a connection to the jump host, creating it once
+func (c *Client) getJumpClient() (*ssh.Client, error) {
+	c.jumpMu.Lock()
+	defer c.jumpMu.Unlock()
+
+	if len(c.jumpClients) > 0 {
+		jumpClient := c.jumpClients[len(c.jumpClients)-1]
+		if isAlive(jumpClient, c.options.DialTimeout) {
+			return jumpClient, nil
+		}
+
+		c.logger.Warnw("connection to jump host is lost; reconnecting", "host", c.options.JumpHost.Host)
+		_ = closeJumpClients(c.jumpClients)
+		c.jumpClients = nil
+	}
+
+	jumpClients, err := c.dialJumpHost(c.options.JumpHost)
+	if err != nil {
+		return nil, err
+	}
+
+	c.jumpClients = jumpClients
+
+	return jumpClients[len(jumpClients)-1], nil
+}
+
+// connects to a jump host, going through the preceding jump hosts first.
+// Returns the connections to every hop of a chain, the requested jump host being the last one.
+func (c *Client) dialJumpHost(opts *JumpHostOptions) ([]*ssh.Client, error) {
+	var chain []*ssh.Client
+	var through *ssh.Client
+	var err error
+
+	if opts.JumpHost != nil {
+		chain, err = c.dialJumpHost(opts.JumpHost)
+		if err != nil {
+			return nil, err
+		}
+
+		through = chain[len(chain)-1]
+	}
+
+	port := opts.Port
+	if port == 0 {
+		port = 22
+	}
+
+	user := opts.Username
+	if len(user) == 0 {
+		user = c.options.Username
+	}
+
+	auth, err := c.jumpHostAuthMethods(opts)
+	if err != nil {
+		_ = closeJumpClients(chain)
+		return nil, err
+	}
+
+	addr := net.JoinHostPort(opts.Host, fmt.Sprint(port))
+	client, err := c.dialThrough(through, addr, &ssh.ClientConfig{
+		User:              user,
+		Auth:              auth,
+		Timeout:           c.options.DialTimeout,
+		HostKeyCallback:   c.hostKeyCallback,
+		HostKeyAlgorithms: c.hostKeyAlgorithms(addr),
+	})
+	if err != nil {
+		_ = closeJumpClients(chain)
+		return nil, errors.Wrapf(err, "could not connect to jump host %s as %s", opts.Host, user)
+	}
+
+	return append(chain, client), nil
+}
+
+// closes the connections to a chain of jump hosts, starting from the last one
+func closeJumpClients(chain []*ssh.Client) error {
+	var result error
+
+	for i := len(chain) - 1; i >= 0; i-- {
+		err := chain[i].Close()
+		if err != nil && result == nil {
+			result = err
+		}
+	}
+
+	return result
+}
+
+// returns authentication methods for a jump host: its own key or password, or the global ones
+func (c *Client) jumpHostAuthMethods(opts *JumpHostOptions) (goph.Auth, error) {
+	if len(opts.KeyFile) == 0 && len(opts.Password) == 0 {
+		return c.authMethods(hostConnection{})
+	}
+
+	auth := goph.Auth{}
+
+	if len(opts.KeyFile) > 0 {
+		signer, err := goph.GetSigner(expandHome(opts.KeyFile), opts.KeyPassword)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not read SSH key from %s", opts.KeyFile)
+		}
+
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+
+	if len(opts.Password) > 0 {
+		auth = append(auth, ssh.Password(opts.Password))
+	}
+
+	return auth, nil
+}
+
+// establishes an SSH connection to a given address, either directly or through another SSH connection
+func (c *Client) dialThrough(through *ssh.Client, addr string, config *ssh.ClientConfig) (*ssh.Client, error) {
+	var conn net.Conn
+	var err error
+
+	if through == nil {
+		dialer := net.Dialer{Timeout: config.Timeout}
+		if c.options.KeepaliveInterval > 0 {
+			dialer.KeepAlive = c.options.KeepaliveInterval
+		}
+
+		conn, err = dialer.Dial("tcp", addr)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		conn, err = through.Dial("tcp", addr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	clientConn, channels, requests, err := handshake(conn, addr, config)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	return ssh.NewClient(clientConn, channels, requests), nil
+}
+
+// performs an SSH handshake over a given connection, which must complete within config.Timeout (0 means no limit).
+// A connection forwarded through a jump host doesn't support deadlines, so it is closed when the time is up.
+func handshake(conn net.Conn, addr string, config *ssh.ClientConfig) (ssh.Conn, <-chan ssh.NewChannel, <-chan *ssh.Request, error) {
+	if config.Timeout <= 0 {
+		return ssh.NewClientConn(conn, addr, config)
+	}
+
+	type result struct {
+		conn     ssh.Conn
+		channels <-chan ssh.NewChannel
+		requests <-chan *ssh.Request
+		err      error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		clientConn, channels, requests, err := ssh.NewClientConn(conn, addr, config)
+		done <- result{clientConn, channels, requests, err}
+	}()
+
+	timer := time.NewTimer(config.Timeout)
+	defer timer.Stop()
+
+	select {
+	case r := <-done:
+		return r.conn, r.channels, r.requests, r.err
+	case <-timer.C:
+		// unblocks the handshake
+		_ = conn.Close()
+
+		return nil, nil, nil, errors.Errorf("SSH handshake with %s timed out after %s", addr, config.Timeout)
+	}
+}
+
+// connects to a database node, through a jump host if configured
+func (c *Client) connect(config *goph.Config) (*goph.Client, error) {
+	var jumpClient *ssh.Client
+	var err error
+
+	if c.options.JumpHost != nil {
+		jumpClient, err = c.getJumpClient()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	addr := net.JoinHostPort(config.Addr, fmt.Sprint(config.Port))
+	client, err := c.dialThrough(jumpClient, addr, &ssh.ClientConfig{
+		User:              config.User,
+		Auth:              config.Auth,
+		Timeout:           config.Timeout,
+		HostKeyCallback:   config.Callback,
+		HostKeyAlgorithms: c.hostKeyAlgorithms(addr),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &goph.Client{Client: client, Config: config}, nil
+}
@@ -0,0 +1,138 @@
+package ssh
+
+import (
+	"context"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"net"
+	"testing"
+	"time"
+)
+
+// A node is reached through a chain of two jump hosts
+func TestClient_JumpHost(t *testing.T) {
+	firstJump := newTestServer(t, "first-jump")
+	secondJump := newTestServer(t, "second-jump")
+	node := newTestServer(t, "node")
+
+	firstJumpHost, firstJumpPort := firstJump.addr()
+	secondJumpHost, secondJumpPort := secondJump.addr()
+	nodeHost, nodePort := node.addr()
+
+	client, err := NewClient(Options{
+		Username:            "test",
+		Password:            "test",
+		Port:                nodePort,
+		InsecureSkipHostKey: true,
+		JumpHost: &JumpHostOptions{
+			Host:     secondJumpHost,
+			Port:     secondJumpPort,
+			Username: "bastion",
+			JumpHost: &JumpHostOptions{
+				Host:     firstJumpHost,
+				Port:     firstJumpPort,
+				Password: "test",
+			},
+		},
+	}, zap.S())
+	require.NoError(t, err)
+
+	executor, err := client.GetByHost(nodeHost)
+	require.NoError(t, err)
+
+	output, err := executor.Execute(context.Background(), cmd.Command("hostname"))
+	require.NoError(t, err)
+	require.Equal(t, "ok from node", string(output))
+
+	connections, forwards := firstJump.counters()
+	require.Equal(t, 1, connections)
+	require.Equal(t, 1, forwards, "the second jump host must be reached through the first one")
+
+	connections, forwards = secondJump.counters()
+	require.Equal(t, 1, connections)
+	require.Equal(t, 1, forwards, "the node must be reached through the second jump host")
+
+	connections, _ = node.counters()
+	require.Equal(t, 1, connections)
+
+	// every hop of the chain is kept to be closed
+	jumpClients := client.jumpClients
+	require.Len(t, jumpClients, 2)
+	require.NoError(t, client.Close())
+	require.Nil(t, client.jumpClients)
+
+	for _, jumpClient := range jumpClients {
+		_, _, err = jumpClient.SendRequest("keepalive@openssh.com", true, nil)
+		require.Error(t, err, "a jump host connection must be closed")
+	}
+}
+
+// A jump host connection error is reported
+func TestClient_JumpHostError(t *testing.T) {
+	jump := newTestServer(t, "jump")
+	jumpHost, jumpPort := jump.addr()
+
+	client, err := NewClient(Options{
+		Username:            "test",
+		Password:            "test",
+		InsecureSkipHostKey: true,
+		JumpHost: &JumpHostOptions{
+			Host:     jumpHost,
+			Port:     jumpPort,
+			Password: "wrong",
+		},
+	}, zap.S())
+	require.NoError(t, err)
+
+	_, err = client.GetByHost("10.0.0.1")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "could not connect to jump host "+jumpHost)
+}
+
+// A node that accepts a connection behind a jump host, but never completes the handshake, fails in time
+func TestClient_JumpHostHandshakeTimeout(t *testing.T) {
+	jump := newTestServer(t, "jump")
+	jumpHost, jumpPort := jump.addr()
+
+	// a TCP server that doesn't speak SSH
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { _ = conn.Close() })
+		}
+	}()
+
+	nodeHost, nodePort, err := net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+
+	port, err := net.LookupPort("tcp", nodePort)
+	require.NoError(t, err)
+
+	client, err := NewClient(Options{
+		Username:            "test",
+		Password:            "test",
+		Port:                uint(port),
+		DialTimeout:         time.Millisecond * 200,
+		InsecureSkipHostKey: true,
+		JumpHost: &JumpHostOptions{
+			Host: jumpHost,
+			Port: jumpPort,
+		},
+	}, zap.S())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	started := time.Now()
+	_, err = client.GetByHost(nodeHost)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "timed out")
+	require.Less(t, time.Since(started), time.Second*5)
+}
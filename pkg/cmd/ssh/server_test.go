@@ -0,0 +1,187 @@
+package ssh
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"io"
+	"net"
+	"sync"
+	"testing"
+)
+
+// testServer a minimal in-process SSH server for tests.
+// It accepts a password "test", executes any command by printing its own name,
+// and forwards TCP connections (for jump hosts).
+type testServer struct {
+	name     string
+	listener net.Listener
+	config   *ssh.ServerConfig
+	mu       sync.Mutex
+	// the number of established SSH connections and forwarded TCP connections
+	connections int
+	forwards    int
+	// accepted network connections
+	conns []net.Conn
+	// the default ed25519 host key
+	hostKey ssh.PublicKey
+}
+
+// creates a test server with an ed25519 host key and, optionally, host keys of other types
+func newTestServer(t *testing.T, name string, extraHostKeys ...ssh.Signer) *testServer {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	require.NoError(t, err)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := &testServer{
+		name:     name,
+		listener: listener,
+		config: &ssh.ServerConfig{
+			PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+				if string(password) != "test" {
+					return nil, fmt.Errorf("invalid password for %s", conn.User())
+				}
+
+				return nil, nil
+			},
+		},
+	}
+	server.hostKey = signer.PublicKey()
+	server.config.AddHostKey(signer)
+	for _, extraHostKey := range extraHostKeys {
+		server.config.AddHostKey(extraHostKey)
+	}
+
+	go server.serve()
+	t.Cleanup(func() { _ = listener.Close() })
+
+	return server
+}
+
+// host and port of the server
+func (s *testServer) addr() (string, uint) {
+	addr := s.listener.Addr().(*net.TCPAddr)
+
+	return addr.IP.String(), uint(addr.Port)
+}
+
+func (s *testServer) counters() (connections, forwards int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.connections, s.forwards
+}
+
+func (s *testServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go s.handleConn(conn)
+	}
+}
+
+// closes all client connections, like a network failure would
+func (s *testServer) dropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, conn := range s.conns {
+		_ = conn.Close()
+	}
+
+	s.conns = nil
+}
+
+func (s *testServer) handleConn(conn net.Conn) {
+	s.mu.Lock()
+	s.conns = append(s.conns, conn)
+	s.mu.Unlock()
+
+	_, channels, requests, err := ssh.NewServerConn(conn, s.config)
+	if err != nil {
+		_ = conn.Close()
+		return
+	}
+
+	s.mu.Lock()
+	s.connections++
+	s.mu.Unlock()
+
+	go ssh.DiscardRequests(requests)
+
+	for newChannel := range channels {
+		switch newChannel.ChannelType() {
+		case "session":
+			go s.handleSession(newChannel)
+		case "direct-tcpip":
+			go s.handleForward(newChannel)
+		default:
+			_ = newChannel.Reject(ssh.UnknownChannelType, "unsupported channel type")
+		}
+	}
+}
+
+// replies to every "exec" request with the server name
+func (s *testServer) handleSession(newChannel ssh.NewChannel) {
+	channel, requests, err := newChannel.Accept()
+	if err != nil {
+		return
+	}
+	defer channel.Close()
+
+	for request := range requests {
+		if request.Type != "exec" {
+			_ = request.Reply(false, nil)
+			continue
+		}
+
+		_ = request.Reply(true, nil)
+		_, _ = channel.Write([]byte("ok from " + s.name))
+		_, _ = channel.SendRequest("exit-status", false, []byte{0, 0, 0, 0})
+
+		return
+	}
+}
+
+// forwards a TCP connection to a requested address
+func (s *testServer) handleForward(newChannel ssh.NewChannel) {
+	// RFC 4254 7.2: host to connect, port to connect, originator address, originator port
+	data := newChannel.ExtraData()
+	hostLength := binary.BigEndian.Uint32(data)
+	host := string(data[4 : 4+hostLength])
+	port := binary.BigEndian.Uint32(data[4+hostLength:])
+
+	target, err := net.Dial("tcp", net.JoinHostPort(host, fmt.Sprint(port)))
+	if err != nil {
+		_ = newChannel.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+
+	channel, requests, err := newChannel.Accept()
+	if err != nil {
+		_ = target.Close()
+		return
+	}
+	go ssh.DiscardRequests(requests)
+
+	s.mu.Lock()
+	s.forwards++
+	s.mu.Unlock()
+
+	go func() {
+		_, _ = io.Copy(target, channel)
+		_ = target.Close()
+	}()
+	_, _ = io.Copy(channel, target)
+	_ = channel.Close()
+}
@@ -0,0 +1,121 @@
+package sudo
+
+// This package runs shell commands with elevated privileges using `sudo`.
+// It wraps another executor (local or SSH), so that the login user doesn't have to be root.
+
+import (
+	"context"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd/shell"
+	"github.com/pkg/errors"
+	"os/exec"
+	"strings"
+)
+
+type Options struct {
+	Enabled bool
+	// a user to run the commands as (`sudo -u`). root by default
+	User string
+	// a password for sudo. if empty, sudo must not require a password (`sudo -n`)
+	Password string
+}
+
+// Executor runs commands, reads and writes files with sudo through another executor
+type Executor struct {
+	executor cmd.Executor
+	options  Options
+}
+
+func NewExecutor(executor cmd.Executor, options Options) *Executor {
+	return &Executor{
+		executor: executor,
+		options:  options,
+	}
+}
+
+func (e *Executor) Execute(ctx context.Context, command *exec.Cmd) ([]byte, error) {
+	return e.executor.Execute(ctx, e.wrap(command, e.options.User))
+}
+
+func (e *Executor) Run(ctx context.Context, command *exec.Cmd) error {
+	_, err := e.Execute(ctx, command)
+
+	return err
+}
+
+// ReadFile reads a file with `sudo cat`.
+// The warnings of sudo (e.g. "unable to resolve host") are not a part of the file, so stderr is only read on failure.
+func (e *Executor) ReadFile(ctx context.Context, path string) ([]byte, error) {
+	ctx = cmd.ReadOnly(ctx)
+	output, err := e.executor.Execute(ctx, cmd.WithoutStderr(e.wrap(cmd.Command("cat", path), e.options.User)))
+	if err != nil {
+		// the file is read again to report the error
+		output, _ = e.Execute(ctx, cmd.Command("cat", path))
+
+		return nil, errors.Wrapf(err, "could not read %s with sudo. output:\n%s", path, string(output))
+	}
+
+	return output, nil
+}
+
+// WriteFile writes a file to a temporary location as the login user first, and then copies it with sudo.
+// (the standard input is reserved for the sudo password, so the data cannot be piped)
+// The temporary file is created with `mktemp`, so that its name is unpredictable and it is only accessible to the login user;
+// that's why it is copied as root, and the file is then given to the target user.
+func (e *Executor) WriteFile(ctx context.Context, path string, data []byte) error {
+	output, err := e.executor.Execute(ctx, cmd.Command("mktemp"))
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	tmpPath := strings.TrimSpace(lines[len(lines)-1])
+	if err != nil || len(tmpPath) == 0 {
+		return errors.Errorf("could not create a temporary file: %v. output:\n%s", err, string(output))
+	}
+
+	defer func() {
+		_ = e.executor.Run(ctx, cmd.Command("rm", "-f", tmpPath))
+	}()
+
+	err = e.executor.WriteFile(ctx, tmpPath, data)
+	if err != nil {
+		return errors.Wrapf(err, "could not write a temporary file %s", tmpPath)
+	}
+
+	copyCmd := cmd.Shellf("cat %s > %s", tmpPath, path)
+	if len(e.options.User) > 0 {
+		copyCmd = cmd.Shellf("cat %s > %s && chown %s %s", tmpPath, path, e.options.User, path)
+	}
+
+	output, err = e.executor.Execute(ctx, e.wrap(copyCmd, ""))
+	if err != nil {
+		return errors.Wrapf(err, "could not write %s with sudo. output:\n%s", path, string(output))
+	}
+
+	return nil
+}
+
+// wraps a command into `sudo [-u user] sh -c '...'`, so that redirects and pipes also run with sudo.
+// An empty user means root.
+func (e *Executor) wrap(command *exec.Cmd, user string) *exec.Cmd {
+	args := []string{}
+
+	if len(e.options.Password) > 0 {
+		// read the password from stdin without a prompt.
+		// cached credentials are ignored (-k), so that sudo always reads the password, and the command never receives it
+		args = append(args, "-S", "-k", "-p", "")
+	} else {
+		// fail instead of asking for a password
+		args = append(args, "-n")
+	}
+
+	if len(user) > 0 {
+		args = append(args, "-u", user)
+	}
+
+	args = append(args, "sh", "-c", shell.Line(command))
+
+	wrapped := cmd.Command("sudo", args...)
+	if len(e.options.Password) > 0 {
+		wrapped.Stdin = strings.NewReader(e.options.Password + "\n")
+	}
+
+	return wrapped
+}
@@ -0,0 +1,128 @@
+package sudo
+
+import (
+	"context"
+	"errors"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd/shell"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd/test"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os/exec"
+	"testing"
+)
+
+// Commands are wrapped in `sudo -n` without a password
+func TestExecutor_Execute(t *testing.T) {
+	testExecutor := &test.Executor{Output: "ok"}
+	executor := NewExecutor(testExecutor, Options{Enabled: true})
+
+	output, err := executor.Execute(context.Background(), cmd.Shellf("nodetool status > %s", "/tmp/status"))
+	require.NoError(t, err)
+	require.Equal(t, "ok", string(output))
+	require.Equal(t, `sudo -n sh -c 'sh -c '\''nodetool status > /tmp/status'\'''`, shell.Line(testExecutor.LastCmd))
+	require.Nil(t, testExecutor.LastCmd.Stdin)
+}
+
+// A password is passed through stdin
+func TestExecutor_ExecuteWithPassword(t *testing.T) {
+	testExecutor := &test.Executor{}
+	executor := NewExecutor(testExecutor, Options{Enabled: true, User: "scylla", Password: "secret"})
+
+	require.NoError(t, executor.Run(context.Background(), cmd.Command("echo", "it's")))
+	require.Equal(
+		t,
+		`sudo -S -k -p '' -u scylla sh -c 'echo '\''it'\''\'\'''\''s'\'''`,
+		shell.Line(testExecutor.LastCmd),
+		"the password must not be a part of the command",
+	)
+
+	stdin, err := ioutil.ReadAll(testExecutor.LastCmd.Stdin)
+	require.NoError(t, err)
+	require.Equal(t, "secret\n", string(stdin))
+}
+
+// Files are read with `sudo cat` and written through a temporary file
+func TestExecutor_Files(t *testing.T) {
+	executed := []string{}
+	testExecutor := &test.Executor{
+		Func: func(command *exec.Cmd, _ int) (string, error) {
+			executed = append(executed, shell.Line(command))
+			if command.Path == "mktemp" {
+				return "/tmp/tmp.Xa8kP2q0Zs\n", nil
+			}
+
+			return "file contents", nil
+		},
+	}
+	executor := NewExecutor(testExecutor, Options{Enabled: true})
+
+	data, err := executor.ReadFile(context.Background(), "/var/lib/scylla/my file")
+	require.NoError(t, err)
+	require.Equal(t, "file contents", string(data))
+	require.Equal(
+		t,
+		[]string{shell.Line(cmd.WithoutStderr(cmd.Command("sudo", "-n", "sh", "-c", "cat '/var/lib/scylla/my file'")))},
+		executed,
+		"sudo warnings must not be read as a part of the file",
+	)
+
+	executed = []string{}
+	require.NoError(t, executor.WriteFile(context.Background(), "/var/lib/scylla/metadata.yml", []byte("data")))
+	require.Equal(t, []byte("data"), testExecutor.WrittenFileBytes)
+	require.Equal(t, "/tmp/tmp.Xa8kP2q0Zs", testExecutor.WrittenFilePath, "a temporary file must be created with mktemp")
+	require.Len(t, executed, 2)
+	require.Equal(t, "mktemp", executed[0], "a temporary file must be created as the login user")
+	require.Contains(t, executed[1], "cat /tmp/tmp.Xa8kP2q0Zs > /var/lib/scylla/metadata.yml")
+	require.Equal(t, "rm -f "+testExecutor.WrittenFilePath, shell.Line(testExecutor.LastCmd), "a temporary file must be removed")
+}
+
+// A file is read again with stderr to report an error
+func TestExecutor_ReadFileError(t *testing.T) {
+	testExecutor := &test.Executor{Output: "cat: /etc/missing: No such file or directory", Err: errors.New("exit status 1")}
+	executor := NewExecutor(testExecutor, Options{Enabled: true})
+
+	_, err := executor.ReadFile(context.Background(), "/etc/missing")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "No such file or directory")
+	require.Equal(t, 2, testExecutor.ExecutedCount)
+	require.Equal(t, `sudo -n sh -c 'cat /etc/missing'`, shell.Line(testExecutor.LastCmd))
+}
+
+// With a target user, a temporary file of the login user is copied as root, and then given to the target user
+func TestExecutor_WriteFileAsUser(t *testing.T) {
+	executed := []string{}
+	testExecutor := &test.Executor{
+		Func: func(command *exec.Cmd, _ int) (string, error) {
+			executed = append(executed, shell.Line(command))
+			if command.Path == "mktemp" {
+				return "/tmp/tmp.Xa8kP2q0Zs\n", nil
+			}
+
+			return "", nil
+		},
+	}
+	executor := NewExecutor(testExecutor, Options{Enabled: true, User: "scylla", Password: "secret"})
+
+	require.NoError(t, executor.WriteFile(context.Background(), "/var/lib/scylla/metadata.yml", []byte("data")))
+	require.Equal(t, []string{
+		"mktemp",
+		`sudo -S -k -p '' sh -c 'sh -c '\''cat /tmp/tmp.Xa8kP2q0Zs > /var/lib/scylla/metadata.yml && chown scylla /var/lib/scylla/metadata.yml'\'''`,
+	}, executed, "the temporary file is only readable by the login user, so it must be copied as root")
+	require.Equal(t, "rm -f /tmp/tmp.Xa8kP2q0Zs", shell.Line(testExecutor.LastCmd), "the login user must remove its temporary file")
+}
+
+// A file is not written if a temporary file could not be created
+func TestExecutor_WriteFileMktempError(t *testing.T) {
+	testExecutor := &test.Executor{
+		Func: func(command *exec.Cmd, _ int) (string, error) {
+			return "mktemp: failed to create file", errors.New("exit status 1")
+		},
+	}
+	executor := NewExecutor(testExecutor, Options{Enabled: true})
+
+	err := executor.WriteFile(context.Background(), "/var/lib/scylla/metadata.yml", []byte("data"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "could not create a temporary file")
+	require.Empty(t, testExecutor.WrittenFilePath)
+}
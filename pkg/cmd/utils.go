@@ -3,6 +3,8 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"github.com/pkg/errors"
+	"strconv"
 	"strings"
 )
 
@@ -10,13 +12,13 @@ import (
 
 // ExecutableFileExists checks if an executable file exists
 func ExecutableFileExists(ctx context.Context, executor Executor, file string) error {
-	err := executor.Run(ctx, Command("test", "-x", file))
+	err := executor.Run(ReadOnly(ctx), Command("test", "-x", file))
 	if err != nil {
 		// if `test` failed, try `whereis` instead.
 		// `whereis` always exits with code 0, so we have to parse its output.
 		// it is successful if it prints "file: path-to-file",
 		// and is not successful if it prints "file:".
-		whereisOutput, _ := executor.Execute(ctx, Command("whereis", file))
+		whereisOutput, _ := executor.Execute(ReadOnly(ctx), Command("whereis", file))
 		whereisLines := strings.Split(strings.TrimSpace(string(whereisOutput)), "\n")
 		whereisParts := strings.SplitN(whereisLines[len(whereisLines)-1], ":", 2)
 
@@ -33,7 +35,7 @@ func ExecutableFileExists(ctx context.Context, executor Executor, file string) e
 
 // DirectoryExists checks if a directory exists
 func DirectoryExists(ctx context.Context, executor Executor, path string) bool {
-	err := executor.Run(ctx, Command("test", "-d", path))
+	err := executor.Run(ReadOnly(ctx), Command("test", "-d", path))
 	if err != nil {
 		return false
 	}
@@ -51,14 +53,63 @@ func EnsureDirectoryIsEmpty(ctx context.Context, executor Executor, path string)
 	}
 }
 
+// DirectorySize returns the total size of files in a directory in bytes
+func DirectorySize(ctx context.Context, executor Executor, path string) (int64, error) {
+	output, err := executor.Execute(ReadOnly(ctx), Command("du", "-sb", path))
+	if err != nil {
+		return 0, err
+	}
+
+	// du prints "size<tab>path"
+	fields := strings.Fields(string(output))
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("could not parse du output: %s", output)
+	}
+
+	return strconv.ParseInt(fields[0], 10, 64)
+}
+
+// FileSizes returns the sizes of all files in a directory (recursively) by their paths relative to it.
+// Only POSIX `find` and `wc` options are used, so that it works on linux, macOS and BSD.
+func FileSizes(ctx context.Context, executor Executor, path string) (map[string]int64, error) {
+	path = strings.TrimRight(path, "/")
+	output, err := executor.Execute(ReadOnly(ctx), Command("find", path, "-type", "f", "-exec", "wc", "-c", "{}", "+"))
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not list files in %s. output: %s", path, output)
+	}
+
+	result := map[string]int64{}
+	for _, line := range strings.Split(string(output), "\n") {
+		// wc prints "size /path/to/file", and a "size total" line for several files
+		parts := strings.SplitN(strings.TrimSpace(line), " ", 2)
+		if len(parts) < 2 {
+			continue
+		}
+
+		name := strings.TrimLeft(parts[1], " ")
+		if !strings.HasPrefix(name, path+"/") {
+			continue
+		}
+
+		size, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse wc output: %s", line)
+		}
+
+		result[strings.TrimPrefix(name, path+"/")] = size
+	}
+
+	return result, nil
+}
+
 func CreateDirectory(ctx context.Context, executor Executor, path string) error {
-	return executor.Run(ctx, Command("mkdir", "-p", path))
+	return executor.Run(Idempotent(ctx), Command("mkdir", "-p", path))
 }
 
 func RemoveDirectory(ctx context.Context, executor Executor, path string) error {
-	return executor.Run(ctx, Command("rm", "-r", path))
+	return executor.Run(WithCategory(ctx, CategoryRemove), Command("rm", "-r", path))
 }
 
 func ClearDirectory(ctx context.Context, executor Executor, path string) error {
-	return executor.Run(ctx, Command("rm", "-rf", path+"/*"))
+	return executor.Run(WithCategory(ctx, CategoryRemove), Shellf("rm -rf %s/*", path))
 }
@@ -39,6 +39,20 @@ func Test_CreateDirectory(t *testing.T) {
 	require.False(t, DirectoryExists(ctx, executor, path), "директория не должна существовать")
 }
 
+func Test_DirectorySize(t *testing.T) {
+	executor := local.Executor{}
+	ctx := context.Background()
+	path := t.TempDir()
+	require.NoError(t, os.WriteFile(path+"/file", make([]byte, 1000), 0600))
+
+	size, err := DirectorySize(ctx, executor, path)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, size, int64(1000))
+
+	_, err = DirectorySize(ctx, executor, "/ololo")
+	require.Error(t, err)
+}
+
 // Проверка отмены выполнения команды.
 // Ожидается, что тест завершится быстро, а команда sleep 10 будет прервана.
 func Test_CtxCancel(t *testing.T) {
@@ -57,3 +71,24 @@ func Test_CtxCancel(t *testing.T) {
 
 	require.Error(t, err, "контекст должен быть отменён")
 }
+
+func Test_FileSizes(t *testing.T) {
+	executor := local.Executor{}
+	ctx := context.Background()
+	path := t.TempDir()
+	require.NoError(t, os.MkdirAll(path+"/dir with spaces", 0700))
+	require.NoError(t, os.WriteFile(path+"/file", make([]byte, 1000), 0600))
+	require.NoError(t, os.WriteFile(path+"/dir with spaces/file 2", make([]byte, 10), 0600))
+
+	sizes, err := FileSizes(ctx, executor, path)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{"file": 1000, "dir with spaces/file 2": 10}, sizes)
+
+	// wc doesn't print a total for a single file
+	sizes, err = FileSizes(ctx, executor, path+"/dir with spaces/")
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{"file 2": 10}, sizes)
+
+	_, err = FileSizes(ctx, executor, "/ololo")
+	require.Error(t, err)
+}
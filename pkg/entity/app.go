@@ -1,8 +1,15 @@
 package entity
 
+import "fmt"
+
 // BuildInfo app build info
 type BuildInfo struct {
 	Version string
 	Commit  string
 	Date    string
 }
+
+// Report creates a human-readable build info
+func (b BuildInfo) Report() string {
+	return fmt.Sprintf("Version: %s\nCommit: %s\nBuild date: %s", b.Version, b.Commit, b.Date)
+}
@@ -3,8 +3,9 @@ package entity
 import (
 	"errors"
 	"fmt"
-	"html"
+	"github.com/hashicorp/go-multierror"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 )
@@ -53,17 +54,30 @@ func (r RemoteBackup) IsExpired(now time.Time, retention time.Duration) bool {
 	return r.DateCreated.Before(now.Add(-retention))
 }
 
+// RootPath returns the path of a backup directory (.../host-prefix/MM-DD-YYYY-HH-mm),
+// even if Path points to one of its subdirectories
+func (r RemoteBackup) RootPath() string {
+	location := remoteBackupFromPathRegexp.FindStringIndex(r.Path)
+	if location == nil {
+		return r.Path
+	}
+
+	return r.Path[:location[1]]
+}
+
 func (r RemoteBackup) String() string {
 	return r.Path
 }
 
 // BackupResult a result of running a backup on a single database node
 type BackupResult struct {
-	Error         error
-	DateStarted   time.Time
-	Duration      time.Duration
-	SnapshotTag   string
-	Keyspaces     []string
+	Error       error
+	DateStarted time.Time
+	Duration    time.Duration
+	SnapshotTag string
+	Keyspaces   []string
+	// the size of a local backup in bytes (unknown if 0)
+	Size          int64
 	Uploaded      bool
 	CleanupResult CleanupResult
 }
@@ -95,7 +109,7 @@ func (b BackupResults) Report() string {
 				"Error:",
 				fmt.Sprintf(
 					"%s",
-					html.EscapeString(result.Error.Error()),
+					result.Error.Error(),
 				),
 			)
 		}
@@ -117,17 +131,21 @@ func (b BackupResults) Report() string {
 			len(result.CleanupResult.RemovedRemoteBackups),
 		))
 
+		if result.Size > 0 {
+			lines = append(lines, fmt.Sprintf("Size: %s", FormatBytes(result.Size)))
+		}
+
 		if result.CleanupResult.RemoteError != nil {
 			lines = append(lines, fmt.Sprintf(
 				"error while removing expired backups: %s",
-				html.EscapeString(result.CleanupResult.RemoteError.Error()),
+				result.CleanupResult.RemoteError.Error(),
 			))
 		}
 
 		if result.CleanupResult.LocalError != nil {
 			lines = append(lines, fmt.Sprintf(
 				"error while removing a snapshot on database node: %s",
-				html.EscapeString(result.CleanupResult.LocalError.Error()),
+				result.CleanupResult.LocalError.Error(),
 			))
 		}
 
@@ -137,6 +155,43 @@ func (b BackupResults) Report() string {
 	return strings.Join(lines, "\n")
 }
 
+// CleanupErrors returns the errors of cleaning up after successful backups as a single error, if any.
+// A backup with a cleanup error is a partial failure: the data is backed up, but the disk or remote storage may fill up.
+func (b BackupResults) CleanupErrors() error {
+	hosts := make([]string, 0, len(b.ByHost))
+	for host := range b.ByHost {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	var err *multierror.Error
+
+	for _, host := range hosts {
+		result := b.ByHost[host]
+		if result.Error != nil {
+			continue
+		}
+
+		if result.CleanupResult.RemoteError != nil {
+			err = multierror.Append(err, fmt.Errorf(
+				"could not remove expired backups of %s: %s",
+				host,
+				result.CleanupResult.RemoteError,
+			))
+		}
+
+		if result.CleanupResult.LocalError != nil {
+			err = multierror.Append(err, fmt.Errorf(
+				"could not clean up %s: %s",
+				host,
+				result.CleanupResult.LocalError,
+			))
+		}
+	}
+
+	return err.ErrorOrNil()
+}
+
 type CleanupResult struct {
 	// an error that occurred while cleaning up files on a database node, if any
 	LocalError error
@@ -144,3 +199,19 @@ type CleanupResult struct {
 	RemoteError          error
 	RemovedRemoteBackups []RemoteBackup
 }
+
+// FormatBytes returns a human-readable size, e.g. "1.5 GiB"
+func FormatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
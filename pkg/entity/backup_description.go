@@ -0,0 +1,196 @@
+package entity
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// the files written along with every backup
+const (
+	BackupMetadataFilename = "metadata.yml"
+	BackupSchemaFilename   = "db_schema.cql"
+)
+
+// a suffix of a table directory, e.g. "users-3f2a4b5c6d7e8f9a0b1c2d3e4f5a6b7c"
+var tableDirSuffixRegexp = regexp.MustCompile(`-[0-9a-f]{32}$`)
+
+// the statements of a schema exported with `DESCRIBE SCHEMA`
+var (
+	createKeyspaceRegexp = regexp.MustCompile(`(?i)CREATE KEYSPACE (?:IF NOT EXISTS )?([\w"]+)`)
+	createTableRegexp    = regexp.MustCompile(`(?i)CREATE TABLE (?:IF NOT EXISTS )?([\w"]+\.[\w"]+)`)
+	createViewRegexp     = regexp.MustCompile(`(?i)CREATE MATERIALIZED VIEW (?:IF NOT EXISTS )?([\w"]+\.[\w"]+)`)
+	createIndexRegexp    = regexp.MustCompile(`(?i)CREATE (?:CUSTOM )?INDEX `)
+	createTypeRegexp     = regexp.MustCompile(`(?i)CREATE TYPE `)
+)
+
+// RemoteFile a file in remote storage
+type RemoteFile struct {
+	Path         string
+	Size         int64
+	DateModified time.Time
+}
+
+// SchemaSummary the contents of a database schema exported with a backup
+type SchemaSummary struct {
+	Keyspaces []string
+	// "keyspace.table"
+	Tables            []string
+	MaterializedViews []string
+	Indexes           int
+	Types             int
+}
+
+// BackupDescription the contents of a backup in remote storage
+type BackupDescription struct {
+	Host        string
+	Path        string
+	DateCreated time.Time
+	// the metadata written along with a backup (empty if it could not be read)
+	Metadata      *BackupMetadata `json:",omitempty"`
+	MetadataError string          `json:",omitempty"`
+	// the exported schema (empty if it is archived or could not be read)
+	Schema      *SchemaSummary `json:",omitempty"`
+	SchemaError string         `json:",omitempty"`
+	Files       int
+	Size        int64
+	// "keyspace.table" of the tables with data files (empty if the data is archived)
+	DataTables []string
+}
+
+// ParseBackupMetadata parses the metadata of a backup
+func ParseBackupMetadata(data []byte) (BackupMetadata, error) {
+	metadata := BackupMetadata{}
+	err := yaml.Unmarshal(data, &metadata)
+
+	return metadata, err
+}
+
+// ParseSchemaSummary returns the keyspaces, tables, etc. created by a given CQL schema
+func ParseSchemaSummary(cql string) SchemaSummary {
+	names := func(re *regexp.Regexp) []string {
+		result := []string{}
+		for _, match := range re.FindAllStringSubmatch(cql, -1) {
+			result = append(result, strings.ReplaceAll(match[1], `"`, ""))
+		}
+		sort.Strings(result)
+
+		return result
+	}
+
+	return SchemaSummary{
+		Keyspaces:         names(createKeyspaceRegexp),
+		Tables:            names(createTableRegexp),
+		MaterializedViews: names(createViewRegexp),
+		Indexes:           len(createIndexRegexp.FindAllString(cql, -1)),
+		Types:             len(createTypeRegexp.FindAllString(cql, -1)),
+	}
+}
+
+// NewBackupDescription describes a backup by its files: the total size, and the tables with data
+func NewBackupDescription(host string, backup RemoteBackup, files []RemoteFile) BackupDescription {
+	description := BackupDescription{
+		Host:        host,
+		Path:        backup.Path,
+		DateCreated: backup.DateCreated,
+		Files:       len(files),
+		DataTables:  []string{},
+	}
+
+	tables := map[string]bool{}
+	dataPath := strings.Trim(backup.Path, "/") + "/data/"
+
+	for _, file := range files {
+		description.Size += file.Size
+
+		// data/keyspace/table-uuid/snapshots/tag/file
+		if !strings.HasPrefix(file.Path, dataPath) {
+			continue
+		}
+
+		parts := strings.Split(strings.TrimPrefix(file.Path, dataPath), "/")
+		if len(parts) < 3 {
+			continue
+		}
+
+		tables[parts[0]+"."+tableDirSuffixRegexp.ReplaceAllString(parts[1], "")] = true
+	}
+
+	for table := range tables {
+		description.DataTables = append(description.DataTables, table)
+	}
+	sort.Strings(description.DataTables)
+
+	return description
+}
+
+// HasFile whether a backup contains a file with a given name
+func HasFile(files []RemoteFile, backupPath, name string) bool {
+	for _, file := range files {
+		if file.Path == strings.Trim(backupPath, "/")+"/"+name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Report creates a human-readable description of a backup
+func (d BackupDescription) Report() string {
+	lines := []string{
+		fmt.Sprintf("Host: %s", d.Host),
+		fmt.Sprintf("Path: %s", d.Path),
+		fmt.Sprintf("Date created: %s", formatDate(d.DateCreated)),
+		fmt.Sprintf("Files: %d", d.Files),
+		fmt.Sprintf("Size: %s", FormatBytes(d.Size)),
+		"",
+	}
+
+	if d.Metadata != nil {
+		keyspaces := "all"
+		if len(d.Metadata.Keyspaces) > 0 {
+			keyspaces = strings.Join(d.Metadata.Keyspaces, ", ")
+		}
+
+		archive := "none"
+		if d.Metadata.Archive.Method != "" {
+			archive = d.Metadata.Archive.Method
+		}
+
+		lines = append(
+			lines,
+			fmt.Sprintf("Snapshot tag: %s", d.Metadata.SnapshotTag),
+			fmt.Sprintf("Backed up keyspaces: %s", keyspaces),
+			fmt.Sprintf("Archive: %s", archive),
+			fmt.Sprintf("Created by: scylla-octopus %s (commit %s)", d.Metadata.BuildInfo.Version, d.Metadata.BuildInfo.Commit),
+			"",
+		)
+	} else {
+		lines = append(lines, fmt.Sprintf("Metadata: %s", d.MetadataError), "")
+	}
+
+	if d.Schema != nil {
+		lines = append(
+			lines,
+			fmt.Sprintf("Schema: %d keyspaces, %d tables, %d materialized views, %d indexes, %d types",
+				len(d.Schema.Keyspaces),
+				len(d.Schema.Tables),
+				len(d.Schema.MaterializedViews),
+				d.Schema.Indexes,
+				d.Schema.Types,
+			),
+			fmt.Sprintf("Keyspaces: %s", strings.Join(d.Schema.Keyspaces, ", ")),
+			"",
+		)
+	} else {
+		lines = append(lines, fmt.Sprintf("Schema: %s", d.SchemaError), "")
+	}
+
+	lines = append(lines, fmt.Sprintf("Tables with data: %d", len(d.DataTables)))
+	lines = append(lines, d.DataTables...)
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,87 @@
+package entity
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestParseSchemaSummary(t *testing.T) {
+	cql := `
+CREATE KEYSPACE shop WITH replication = {'class': 'NetworkTopologyStrategy', 'dc1': '3'}  AND durable_writes = true;
+
+CREATE TYPE shop.address (
+    city text
+);
+
+CREATE TABLE shop.users (
+    id uuid PRIMARY KEY,
+    email text
+);
+
+CREATE TABLE IF NOT EXISTS shop."Orders" (
+    id uuid PRIMARY KEY
+);
+
+CREATE INDEX users_email_idx ON shop.users (email);
+
+CREATE MATERIALIZED VIEW shop.users_by_email AS
+    SELECT * FROM shop.users;
+
+CREATE KEYSPACE "Analytics" WITH replication = {'class': 'SimpleStrategy', 'replication_factor': '1'};
+`
+
+	require.Equal(
+		t,
+		SchemaSummary{
+			Keyspaces:         []string{"Analytics", "shop"},
+			Tables:            []string{"shop.Orders", "shop.users"},
+			MaterializedViews: []string{"shop.users_by_email"},
+			Indexes:           1,
+			Types:             1,
+		},
+		ParseSchemaSummary(cql),
+	)
+}
+
+func TestNewBackupDescription(t *testing.T) {
+	backup := RemoteBackup{
+		Path:        "/cluster/dc1/scylla1/09-07-2021-10-29",
+		DateCreated: time.Date(2021, 9, 7, 10, 29, 0, 0, time.UTC),
+	}
+	files := []RemoteFile{
+		{Path: "cluster/dc1/scylla1/09-07-2021-10-29/metadata.yml", Size: 100},
+		{Path: "cluster/dc1/scylla1/09-07-2021-10-29/db_schema.cql", Size: 200},
+		{Path: "cluster/dc1/scylla1/09-07-2021-10-29/data/shop/users-3f2a4b5c6d7e8f9a0b1c2d3e4f5a6b7c/snapshots/tag/md-1-big-Data.db", Size: 1000},
+		{Path: "cluster/dc1/scylla1/09-07-2021-10-29/data/shop/users-3f2a4b5c6d7e8f9a0b1c2d3e4f5a6b7c/snapshots/tag/md-1-big-Index.db", Size: 500},
+		{Path: "cluster/dc1/scylla1/09-07-2021-10-29/data/shop/orders-0a1b2c3d4e5f60718293a4b5c6d7e8f9/snapshots/tag/md-1-big-Data.db", Size: 300},
+	}
+
+	description := NewBackupDescription("127.0.0.1", backup, files)
+	require.Equal(t, 5, description.Files)
+	require.Equal(t, int64(2100), description.Size)
+	require.Equal(t, []string{"shop.orders", "shop.users"}, description.DataTables)
+
+	require.True(t, HasFile(files, backup.Path, BackupSchemaFilename))
+	require.False(t, HasFile(files, backup.Path, "backup.tar.gz"))
+}
+
+func TestParseBackupMetadata(t *testing.T) {
+	metadata := BackupMetadata{
+		Host:        "127.0.0.1",
+		Keyspaces:   []string{"shop"},
+		SnapshotTag: "tag",
+		BuildInfo:   BuildInfo{Version: "1.2.3", Commit: "abc"},
+		Archive:     Archive{Method: "gzip"},
+	}
+
+	parsed, err := ParseBackupMetadata(metadata.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, metadata.Host, parsed.Host)
+	require.Equal(t, metadata.Keyspaces, parsed.Keyspaces)
+	require.Equal(t, metadata.BuildInfo, parsed.BuildInfo)
+	require.Equal(t, metadata.Archive.Method, parsed.Archive.Method)
+
+	_, err = ParseBackupMetadata([]byte("not: [valid"))
+	require.Error(t, err)
+}
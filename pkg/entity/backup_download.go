@@ -0,0 +1,117 @@
+package entity
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// a list of sstable files written by scylla into every snapshot directory
+const snapshotManifestFilename = "manifest.json"
+
+// BackupDownload the result of downloading a backup from remote storage to a local directory
+type BackupDownload struct {
+	Host        string
+	Path        string
+	LocalPath   string
+	DateCreated time.Time
+	Files       int
+	Size        int64
+	// whether the downloaded files were checked against remote storage and snapshot manifests
+	Verified bool
+	// missing or incomplete files
+	Problems []string
+	// the name of an extracted archive, if any
+	Extracted string `json:",omitempty"`
+}
+
+// snapshot manifest format, e.g. {"files":["md-1-big-Data.db","md-1-big-Index.db"]}
+type snapshotManifest struct {
+	Files []string `json:"files"`
+}
+
+// VerifyDownload compares the sizes of downloaded files (by paths relative to a local directory)
+// with the files of a backup in remote storage, and returns the missing or incomplete ones
+func VerifyDownload(backupPath string, remote []RemoteFile, local map[string]int64) []string {
+	problems := []string{}
+	prefix := strings.Trim(backupPath, "/") + "/"
+
+	for _, file := range remote {
+		name := strings.TrimPrefix(file.Path, prefix)
+		size, ok := local[name]
+
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s is missing", name))
+		} else if size != file.Size {
+			problems = append(problems, fmt.Sprintf("%s is %d bytes instead of %d", name, size, file.Size))
+		}
+	}
+
+	return problems
+}
+
+// SnapshotManifests returns the paths of snapshot manifests among given local files
+func SnapshotManifests(local map[string]int64) []string {
+	result := []string{}
+
+	for name := range local {
+		if path.Base(name) == snapshotManifestFilename && strings.Contains(name, "/snapshots/") {
+			result = append(result, name)
+		}
+	}
+	sort.Strings(result)
+
+	return result
+}
+
+// VerifySnapshotManifest checks that all files listed in a snapshot manifest exist among given local files
+func VerifySnapshotManifest(manifestPath string, data []byte, local map[string]int64) []string {
+	manifest := snapshotManifest{}
+	err := json.Unmarshal(data, &manifest)
+	if err != nil {
+		return []string{fmt.Sprintf("%s could not be parsed: %s", manifestPath, err)}
+	}
+
+	problems := []string{}
+	dir := path.Dir(manifestPath)
+
+	for _, file := range manifest.Files {
+		name := dir + "/" + file
+		if _, ok := local[name]; !ok {
+			problems = append(problems, fmt.Sprintf("%s is listed in %s, but missing", name, manifestPath))
+		}
+	}
+
+	return problems
+}
+
+// Report creates a human-readable report of a download
+func (d BackupDownload) Report() string {
+	lines := []string{
+		fmt.Sprintf("Host: %s", d.Host),
+		fmt.Sprintf("Path: %s", d.Path),
+		fmt.Sprintf("Date created: %s", formatDate(d.DateCreated)),
+		fmt.Sprintf("Downloaded to: %s", d.LocalPath),
+		fmt.Sprintf("Files: %d", d.Files),
+		fmt.Sprintf("Size: %s", FormatBytes(d.Size)),
+	}
+
+	switch {
+	case !d.Verified:
+		lines = append(lines, "Verification: skipped")
+	case len(d.Problems) == 0:
+		lines = append(lines, "Verification: OK")
+	default:
+		lines = append(lines, fmt.Sprintf("Verification: %d problems", len(d.Problems)))
+		lines = append(lines, d.Problems...)
+	}
+
+	if len(d.Extracted) > 0 {
+		lines = append(lines, fmt.Sprintf("Extracted: %s", d.Extracted))
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,50 @@
+package entity
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestVerifyDownload(t *testing.T) {
+	remote := []RemoteFile{
+		{Path: "cluster/dc1/scylla1/09-07-2021-10-29/metadata.yml", Size: 100},
+		{Path: "cluster/dc1/scylla1/09-07-2021-10-29/data/shop/users/md-1-big-Data.db", Size: 1000},
+		{Path: "cluster/dc1/scylla1/09-07-2021-10-29/data/shop/users/md-1-big-Index.db", Size: 500},
+	}
+	local := map[string]int64{
+		"metadata.yml":                     100,
+		"data/shop/users/md-1-big-Data.db": 600,
+	}
+
+	require.Equal(
+		t,
+		[]string{
+			"data/shop/users/md-1-big-Data.db is 600 bytes instead of 1000",
+			"data/shop/users/md-1-big-Index.db is missing",
+		},
+		VerifyDownload("/cluster/dc1/scylla1/09-07-2021-10-29", remote, local),
+	)
+
+	local["data/shop/users/md-1-big-Data.db"] = 1000
+	local["data/shop/users/md-1-big-Index.db"] = 500
+	require.Empty(t, VerifyDownload("cluster/dc1/scylla1/09-07-2021-10-29", remote, local))
+}
+
+func TestVerifySnapshotManifest(t *testing.T) {
+	local := map[string]int64{
+		"metadata.yml": 100,
+		"data/shop/users-3f2a/snapshots/tag/manifest.json":    50,
+		"data/shop/users-3f2a/snapshots/tag/md-1-big-Data.db": 1000,
+	}
+
+	manifests := SnapshotManifests(local)
+	require.Equal(t, []string{"data/shop/users-3f2a/snapshots/tag/manifest.json"}, manifests)
+
+	require.Empty(t, VerifySnapshotManifest(manifests[0], []byte(`{"files":["md-1-big-Data.db"]}`), local))
+	require.Equal(
+		t,
+		[]string{"data/shop/users-3f2a/snapshots/tag/md-1-big-Index.db is listed in data/shop/users-3f2a/snapshots/tag/manifest.json, but missing"},
+		VerifySnapshotManifest(manifests[0], []byte(`{"files":["md-1-big-Data.db","md-1-big-Index.db"]}`), local),
+	)
+	require.Len(t, VerifySnapshotManifest(manifests[0], []byte(`not json`), local), 1)
+}
@@ -114,6 +114,15 @@ func TestNewRemoteBackupFromPath(t *testing.T) {
 	}
 }
 
+func TestRemoteBackup_RootPath(t *testing.T) {
+	backup, err := NewRemoteBackupFromPath("cluster/dc1/scylla1/09-07-2021-10-29/data/keyspace")
+	require.NoError(t, err)
+	require.Equal(t, "cluster/dc1/scylla1/09-07-2021-10-29", backup.RootPath())
+
+	backup = RemoteBackup{Path: "cluster/dc1/scylla1/09-07-2021-10-29"}
+	require.Equal(t, backup.Path, backup.RootPath())
+}
+
 func TestRemoteBackup_IsExpired(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -153,3 +162,37 @@ func TestRemoteBackup_IsExpired(t *testing.T) {
 		})
 	}
 }
+
+// Cleanup errors of successful backups are partial failures
+func TestBackupResults_CleanupErrors(t *testing.T) {
+	results := BackupResults{
+		ByHost: map[string]BackupResult{
+			"127.0.0.1": {},
+			"127.0.0.2": {
+				Error:         errors.New("could not backup a node"),
+				CleanupResult: CleanupResult{LocalError: errors.New("ignored")},
+			},
+			"127.0.0.3": {
+				CleanupResult: CleanupResult{
+					RemoteError: errors.New("access denied"),
+					LocalError:  errors.New("no space left"),
+				},
+			},
+		},
+	}
+
+	err := results.CleanupErrors()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "could not remove expired backups of 127.0.0.3: access denied")
+	require.Contains(t, err.Error(), "could not clean up 127.0.0.3: no space left")
+	require.NotContains(t, err.Error(), "ignored", "the cleanup errors of failed backups are not warnings")
+
+	require.NoError(t, BackupResults{ByHost: map[string]BackupResult{"127.0.0.1": {}}}.CleanupErrors())
+}
+
+func TestFormatBytes(t *testing.T) {
+	require.Equal(t, "0 B", FormatBytes(0))
+	require.Equal(t, "1023 B", FormatBytes(1023))
+	require.Equal(t, "1.5 KiB", FormatBytes(1536))
+	require.Equal(t, "2.0 GiB", FormatBytes(2*1024*1024*1024))
+}
@@ -0,0 +1,120 @@
+package entity
+
+import (
+	"fmt"
+	"github.com/hashicorp/go-multierror"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BackupFreshness the newest backup of a single database node in remote storage
+type BackupFreshness struct {
+	// the date of the newest backup (empty if there are no backups)
+	LastBackup time.Time
+	// how long ago the newest backup was created
+	Age time.Duration
+	// whether the newest backup is older than the maximum age, or there are no backups at all
+	Stale bool
+	// an error while listing backups, if any
+	Error error
+}
+
+// BackupFreshnessResults the newest backups of multiple database nodes
+type BackupFreshnessResults struct {
+	MaxAge     time.Duration
+	TotalNodes int
+	FreshNodes int
+	ByHost     map[string]BackupFreshness
+	Error      error
+}
+
+// NewBackupFreshness finds the newest of given backups, and checks whether it's older than a maximum age
+func NewBackupFreshness(backups []RemoteBackup, now time.Time, maxAge time.Duration) BackupFreshness {
+	freshness := BackupFreshness{Stale: true}
+
+	for _, backup := range backups {
+		if backup.DateCreated.After(freshness.LastBackup) {
+			freshness.LastBackup = backup.DateCreated
+		}
+	}
+
+	if !freshness.LastBackup.IsZero() {
+		freshness.Age = now.Sub(freshness.LastBackup)
+		freshness.Stale = freshness.Age > maxAge
+	}
+
+	return freshness
+}
+
+// Errors returns stale backups and listing errors as a single error, in alphabetical order of hosts
+func (r BackupFreshnessResults) Errors() error {
+	var err *multierror.Error
+
+	for _, host := range r.hosts() {
+		freshness := r.ByHost[host]
+
+		switch {
+		case freshness.Error != nil:
+			err = multierror.Append(err, fmt.Errorf("could not list backups of %s: %s", host, freshness.Error))
+		case freshness.LastBackup.IsZero():
+			err = multierror.Append(err, fmt.Errorf("%s has no backups", host))
+		case freshness.Stale:
+			err = multierror.Append(err, fmt.Errorf(
+				"the newest backup of %s is %s old (more than %s)",
+				host,
+				freshness.Age.Round(time.Minute),
+				r.MaxAge,
+			))
+		}
+	}
+
+	return err.ErrorOrNil()
+}
+
+// Report creates a human-readable report about the newest backups to be used in a notification
+func (r BackupFreshnessResults) Report() string {
+	lines := []string{
+		fmt.Sprintf("Total nodes: %d", r.TotalNodes),
+		fmt.Sprintf("Nodes with fresh backups: %d", r.FreshNodes),
+		fmt.Sprintf("Maximum age: %s", r.MaxAge),
+		"",
+	}
+
+	if r.Error != nil {
+		lines = append(lines, "Error:", r.Error.Error(), "")
+	}
+
+	lines = append(lines, "Newest backups:")
+
+	for _, host := range r.hosts() {
+		freshness := r.ByHost[host]
+
+		switch {
+		case freshness.Error != nil:
+			lines = append(lines, fmt.Sprintf("%s: unknown", host))
+		case freshness.LastBackup.IsZero():
+			lines = append(lines, fmt.Sprintf("%s: none", host))
+		default:
+			lines = append(lines, fmt.Sprintf(
+				"%s: %s (%s ago)",
+				host,
+				freshness.LastBackup.Format(time.RFC3339),
+				freshness.Age.Round(time.Minute),
+			))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// returns the hosts in alphabetical order
+func (r BackupFreshnessResults) hosts() []string {
+	hosts := make([]string, 0, len(r.ByHost))
+	for host := range r.ByHost {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	return hosts
+}
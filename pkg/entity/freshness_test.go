@@ -0,0 +1,61 @@
+package entity
+
+import (
+	"errors"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestNewBackupFreshness(t *testing.T) {
+	now := time.Date(2022, 1, 2, 12, 0, 0, 0, time.UTC)
+	backups := []RemoteBackup{
+		{DateCreated: now.Add(-time.Hour * 30)},
+		{DateCreated: now.Add(-time.Hour * 6)},
+		{DateCreated: now.Add(-time.Hour * 18)},
+	}
+
+	freshness := NewBackupFreshness(backups, now, time.Hour*24)
+	require.Equal(t, now.Add(-time.Hour*6), freshness.LastBackup)
+	require.Equal(t, time.Hour*6, freshness.Age)
+	require.False(t, freshness.Stale)
+
+	freshness = NewBackupFreshness(backups, now, time.Hour*5)
+	require.True(t, freshness.Stale)
+
+	freshness = NewBackupFreshness(nil, now, time.Hour*24)
+	require.True(t, freshness.LastBackup.IsZero())
+	require.True(t, freshness.Stale)
+}
+
+func TestBackupFreshnessResults_Errors(t *testing.T) {
+	now := time.Date(2022, 1, 2, 12, 0, 0, 0, time.UTC)
+	results := BackupFreshnessResults{
+		MaxAge:     time.Hour * 24,
+		TotalNodes: 4,
+		FreshNodes: 1,
+		ByHost: map[string]BackupFreshness{
+			"host-1": {LastBackup: now.Add(-time.Hour), Age: time.Hour},
+			"host-2": {LastBackup: now.Add(-time.Hour * 48), Age: time.Hour * 48, Stale: true},
+			"host-3": {Stale: true},
+			"host-4": {Stale: true, Error: errors.New("access denied")},
+		},
+	}
+
+	err := results.Errors()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "the newest backup of host-2 is 48h0m0s old (more than 24h0m0s)")
+	require.Contains(t, err.Error(), "host-3 has no backups")
+	require.Contains(t, err.Error(), "could not list backups of host-4: access denied")
+	require.NotContains(t, err.Error(), "host-1")
+
+	report := results.Report()
+	require.Contains(t, report, `Newest backups:
+host-1: 2022-01-02T11:00:00Z (1h0m0s ago)
+host-2: 2021-12-31T12:00:00Z (48h0m0s ago)
+host-3: none
+host-4: unknown`)
+
+	results.ByHost = map[string]BackupFreshness{"host-1": results.ByHost["host-1"]}
+	require.NoError(t, results.Errors())
+}
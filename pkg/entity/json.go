@@ -0,0 +1,97 @@
+package entity
+
+import "encoding/json"
+
+// JSON encoding of operation results.
+// Errors are interfaces that usually encode as empty objects, so they are replaced with their messages.
+
+// returns an error message, or an empty string if there is no error
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	return err.Error()
+}
+
+func (r RemoteBackup) MarshalJSON() ([]byte, error) {
+	type alias RemoteBackup
+
+	return json.Marshal(struct {
+		alias
+		RemoveError string `json:",omitempty"`
+	}{alias(r), errorString(r.RemoveError)})
+}
+
+func (c CleanupResult) MarshalJSON() ([]byte, error) {
+	type alias CleanupResult
+
+	return json.Marshal(struct {
+		alias
+		LocalError  string `json:",omitempty"`
+		RemoteError string `json:",omitempty"`
+	}{alias(c), errorString(c.LocalError), errorString(c.RemoteError)})
+}
+
+func (b BackupResult) MarshalJSON() ([]byte, error) {
+	type alias BackupResult
+
+	return json.Marshal(struct {
+		alias
+		Error string `json:",omitempty"`
+	}{alias(b), errorString(b.Error)})
+}
+
+func (b BackupResults) MarshalJSON() ([]byte, error) {
+	type alias BackupResults
+
+	return json.Marshal(struct {
+		alias
+		Error string `json:",omitempty"`
+	}{alias(b), errorString(b.Error)})
+}
+
+func (r RepairResults) MarshalJSON() ([]byte, error) {
+	type alias RepairResults
+
+	return json.Marshal(struct {
+		alias
+		Error string `json:",omitempty"`
+	}{alias(r), errorString(r.Error)})
+}
+
+func (r MaintenanceResults) MarshalJSON() ([]byte, error) {
+	type alias MaintenanceResults
+
+	return json.Marshal(struct {
+		alias
+		Error string `json:",omitempty"`
+	}{alias(r), errorString(r.Error)})
+}
+
+func (r RollingRestartResults) MarshalJSON() ([]byte, error) {
+	type alias RollingRestartResults
+
+	return json.Marshal(struct {
+		alias
+		Error string `json:",omitempty"`
+	}{alias(r), errorString(r.Error)})
+}
+
+func (b BackupFreshness) MarshalJSON() ([]byte, error) {
+	type alias BackupFreshness
+
+	return json.Marshal(struct {
+		alias
+		Error string `json:",omitempty"`
+	}{alias(b), errorString(b.Error)})
+}
+
+func (r BackupFreshnessResults) MarshalJSON() ([]byte, error) {
+	type alias BackupFreshnessResults
+
+	return json.Marshal(struct {
+		alias
+		Error string `json:",omitempty"`
+	}{alias(r), errorString(r.Error)})
+}
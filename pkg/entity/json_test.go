@@ -0,0 +1,53 @@
+package entity
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestBackupResults_MarshalJSON(t *testing.T) {
+	results := BackupResults{
+		TotalNodes:    2,
+		BackedUpNodes: 1,
+		ByHost: map[string]BackupResult{
+			"127.0.0.1": {
+				SnapshotTag: "snapshot-tag-1",
+				Uploaded:    true,
+				CleanupResult: CleanupResult{
+					RemoteError: errors.New("could not remove expired backups"),
+					RemovedRemoteBackups: []RemoteBackup{
+						{Path: "s3://bucket/127.0.0.1/01-02-2022-03-04"},
+					},
+				},
+			},
+			"127.0.0.2": {
+				SnapshotTag: "snapshot-tag-2",
+				Error:       errors.New("could not backup a node"),
+			},
+		},
+		Error: errors.New("backup failed"),
+	}
+
+	data, err := json.Marshal(results)
+	require.NoError(t, err)
+
+	decoded := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+
+	require.Equal(t, "backup failed", decoded["Error"])
+	require.EqualValues(t, 2, decoded["TotalNodes"])
+
+	byHost := decoded["ByHost"].(map[string]interface{})
+	failed := byHost["127.0.0.2"].(map[string]interface{})
+	require.Equal(t, "could not backup a node", failed["Error"])
+	require.Equal(t, "snapshot-tag-2", failed["SnapshotTag"])
+
+	succeeded := byHost["127.0.0.1"].(map[string]interface{})
+	require.NotContains(t, succeeded, "Error")
+	cleanup := succeeded["CleanupResult"].(map[string]interface{})
+	require.Equal(t, "could not remove expired backups", cleanup["RemoteError"])
+	require.NotContains(t, cleanup, "LocalError")
+	require.Len(t, cleanup["RemovedRemoteBackups"], 1)
+}
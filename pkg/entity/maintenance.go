@@ -0,0 +1,102 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaintenanceOperation a `nodetool` maintenance command
+type MaintenanceOperation string
+
+const (
+	// MaintenanceCleanup removes the data that no longer belongs to a node
+	MaintenanceCleanup MaintenanceOperation = "cleanup"
+	// MaintenanceCompact forces a major compaction
+	MaintenanceCompact MaintenanceOperation = "compact"
+	// MaintenanceScrub rebuilds sstables, discarding the corrupted data
+	MaintenanceScrub MaintenanceOperation = "scrub"
+	// MaintenanceUpgradeSSTables rewrites sstables to the current format
+	MaintenanceUpgradeSSTables MaintenanceOperation = "upgradesstables"
+	// MaintenanceFlush flushes memtables to disk
+	MaintenanceFlush MaintenanceOperation = "flush"
+)
+
+// MaintenanceOperations all supported maintenance operations
+var MaintenanceOperations = []MaintenanceOperation{
+	MaintenanceCleanup,
+	MaintenanceCompact,
+	MaintenanceScrub,
+	MaintenanceUpgradeSSTables,
+	MaintenanceFlush,
+}
+
+// MaintenanceFilter limits a maintenance operation to given keyspaces and tables.
+// An empty filter means all keyspaces.
+type MaintenanceFilter struct {
+	Keyspaces []string
+	// tables can only be given along with a single keyspace
+	Tables []string
+}
+
+// Validate checks that a filter can be converted to `nodetool` arguments
+func (f MaintenanceFilter) Validate() error {
+	if len(f.Tables) > 0 && len(f.Keyspaces) != 1 {
+		return errors.New("tables can only be filtered within a single keyspace")
+	}
+
+	return nil
+}
+
+// MaintenanceResult a result of a maintenance operation on a single database node
+type MaintenanceResult struct {
+	Output   string
+	Duration time.Duration
+}
+
+// MaintenanceResults results of a maintenance operation on multiple database nodes
+type MaintenanceResults struct {
+	Operation      MaintenanceOperation
+	TotalNodes     int
+	CompletedNodes int
+	ByHost         map[string]MaintenanceResult
+	Error          error
+}
+
+// Report creates a human-readable report string about maintenance results to be used in a notification
+func (r MaintenanceResults) Report() string {
+	lines := []string{
+		fmt.Sprintf("Operation: %s", r.Operation),
+		fmt.Sprintf("Total nodes: %d", r.TotalNodes),
+		fmt.Sprintf("Completed nodes: %d", r.CompletedNodes),
+		"",
+	}
+
+	if r.Error != nil {
+		lines = append(
+			lines,
+			"Error:",
+			r.Error.Error(),
+		)
+	}
+
+	lines = append(lines, "Duration:")
+
+	hosts := make([]string, 0, len(r.ByHost))
+	for host := range r.ByHost {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	for _, host := range hosts {
+		lines = append(lines, fmt.Sprintf(
+			"%s: %s",
+			host,
+			r.ByHost[host].Duration.String(),
+		))
+	}
+
+	return strings.Join(lines, "\n")
+}
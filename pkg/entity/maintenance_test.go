@@ -0,0 +1,51 @@
+package entity
+
+import (
+	"errors"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestMaintenanceFilter_Validate(t *testing.T) {
+	require.NoError(t, MaintenanceFilter{}.Validate())
+	require.NoError(t, MaintenanceFilter{Keyspaces: []string{"a", "b"}}.Validate())
+	require.NoError(t, MaintenanceFilter{Keyspaces: []string{"a"}, Tables: []string{"t1", "t2"}}.Validate())
+	require.Error(
+		t,
+		MaintenanceFilter{Keyspaces: []string{"a", "b"}, Tables: []string{"t1"}}.Validate(),
+		"tables cannot be filtered in multiple keyspaces",
+	)
+	require.Error(
+		t,
+		MaintenanceFilter{Tables: []string{"t1"}}.Validate(),
+		"tables cannot be filtered without a keyspace",
+	)
+}
+
+func TestMaintenanceResults_Report(t *testing.T) {
+	results := MaintenanceResults{
+		Operation:      MaintenanceCleanup,
+		TotalNodes:     3,
+		CompletedNodes: 2,
+		ByHost: map[string]MaintenanceResult{
+			"host-2": {Duration: time.Second * 2},
+			"host-1": {Duration: time.Second},
+		},
+		Error: errors.New("test error"),
+	}
+
+	require.Equal(
+		t,
+		`Operation: cleanup
+Total nodes: 3
+Completed nodes: 2
+
+Error:
+test error
+Duration:
+host-1: 1s
+host-2: 2s`,
+		results.Report(),
+	)
+}
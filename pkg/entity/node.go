@@ -6,7 +6,9 @@ import (
 	"github.com/hashicorp/go-multierror"
 	"github.com/kolesa-team/scylla-octopus/pkg/cmd"
 	"net"
+	"sort"
 	"strings"
+	"time"
 )
 
 // NodeStatusOk a normal database node status (Up, Normal)
@@ -21,11 +23,27 @@ type NodeInfo struct {
 	DataPath    string
 	ClusterName string
 	Datacenter  string
+	Rack        string
+	HostId      string
 	// a status according to `nodetool status`
 	Status   string
 	Binaries NodeBinaries
 }
 
+// NodeStatus a status of a single node according to `nodetool status`
+type NodeStatus struct {
+	Address    string
+	Status     string
+	Datacenter string
+	Rack       string
+	HostId     string
+}
+
+// IsOk whether a node is up and normal
+func (s NodeStatus) IsOk() bool {
+	return s.Status == NodeStatusOk
+}
+
 type Node struct {
 	Info NodeInfo
 	// a shell command executor on this node
@@ -50,6 +68,9 @@ type NodeCallbackResult struct {
 	Host  string
 	Value interface{}
 	Err   error
+	// when the callback was started and finished on a node (empty if it never ran)
+	DateStarted  time.Time
+	DateFinished time.Time
 }
 
 // CallbackOk creates a callback result with a given value
@@ -70,23 +91,61 @@ func CallbackErrorWithValue(err error, value interface{}) NodeCallbackResult {
 // NodeCallbackResults callback results by hosts
 type NodeCallbackResults map[string]NodeCallbackResult
 
-// Returns errors from callback results (if any) as a single error
+// Returns errors from callback results (if any) as a single error, in alphabetical order of hosts
 func (results NodeCallbackResults) Error() error {
 	var err *multierror.Error
 
-	for _, result := range results {
-		if result.Err != nil {
-			err = multierror.Append(err, result.Err)
+	for _, host := range results.Hosts() {
+		if results[host].Err != nil {
+			err = multierror.Append(err, results[host].Err)
 		}
 	}
 
 	return err.ErrorOrNil()
 }
 
+// Hosts returns the hosts of callback results in alphabetical order
+func (results NodeCallbackResults) Hosts() []string {
+	hosts := make([]string, 0, len(results))
+	for host := range results {
+		hosts = append(hosts, host)
+	}
+
+	sort.Strings(hosts)
+
+	return hosts
+}
+
 func (n NodeInfo) IsStatusOk() bool {
 	return n.Status == NodeStatusOk
 }
 
+// HasAddress whether a given address (e.g. from `nodetool status`) belongs to this node
+func (n NodeInfo) HasAddress(address string) bool {
+	if len(address) == 0 {
+		return false
+	}
+
+	return address == n.Host || address == n.IpAddress || address == n.DomainName
+}
+
+// OwnsStatus whether a given status from `nodetool status` belongs to this node:
+// by one of its addresses, or by its host ID if the status lists another address (e.g. an IP of a node configured by name)
+func (n NodeInfo) OwnsStatus(status NodeStatus) bool {
+	return n.HasAddress(status.Address) || (len(n.HostId) > 0 && status.HostId == n.HostId)
+}
+
+// FindStatus returns the status of this node among the statuses of all cluster nodes
+func (n NodeInfo) FindStatus(statuses []NodeStatus) (status NodeStatus, found bool) {
+	for _, status := range statuses {
+		if n.OwnsStatus(status) {
+			return status, true
+		}
+	}
+
+	return NodeStatus{}, false
+}
+
 // RemoteStoragePath returns a path where the backup from a node should be stored in s3.
 // The format is "cluster-name/datacenter/domain-name"
 func (n NodeInfo) RemoteStoragePath() string {
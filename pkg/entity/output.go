@@ -0,0 +1,252 @@
+package entity
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// OutputTable is a tabular view of results, printed by CLI commands with `--output table`
+type OutputTable struct {
+	Header []string
+	Rows   [][]string
+}
+
+// RepairDueTables a list of tables that must be repaired soon
+type RepairDueTables []RepairDueTable
+
+// returns the string keys of a map in alphabetical order
+func sortedKeys(m interface{}) []string {
+	keys := []string{}
+	for _, key := range reflect.ValueOf(m).MapKeys() {
+		keys = append(keys, key.String())
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// formats a date for a table, or returns "-" for an empty date
+func formatDate(date time.Time) string {
+	if date.IsZero() {
+		return "-"
+	}
+
+	return date.Format(time.RFC3339)
+}
+
+// Table returns the backup result of every node
+func (b BackupResults) Table() OutputTable {
+	table := OutputTable{
+		Header: []string{"HOST", "STATUS", "SNAPSHOT TAG", "UPLOADED", "DURATION", "SIZE", "EXPIRED REMOVED", "ERROR"},
+	}
+
+	for _, host := range sortedKeys(b.ByHost) {
+		result := b.ByHost[host]
+		status := "ok"
+		errs := []string{}
+
+		if result.Error != nil {
+			status = "error"
+			errs = append(errs, result.Error.Error())
+		}
+
+		if result.CleanupResult.RemoteError != nil {
+			errs = append(errs, result.CleanupResult.RemoteError.Error())
+		}
+
+		if result.CleanupResult.LocalError != nil {
+			errs = append(errs, result.CleanupResult.LocalError.Error())
+		}
+
+		table.Rows = append(table.Rows, []string{
+			host,
+			status,
+			result.SnapshotTag,
+			fmt.Sprintf("%t", result.Uploaded),
+			result.Duration.String(),
+			FormatBytes(result.Size),
+			fmt.Sprintf("%d", len(result.CleanupResult.RemovedRemoteBackups)),
+			strings.Join(errs, "; "),
+		})
+	}
+
+	return table
+}
+
+// Table returns the repair result of every node
+func (r RepairResults) Table() OutputTable {
+	table := OutputTable{
+		Header: []string{"HOST", "DURATION", "TABLES", "FAILED TABLES"},
+	}
+
+	for _, host := range sortedKeys(r.ByHost) {
+		result := r.ByHost[host]
+		failed := 0
+		for _, tableRepair := range result.Tables {
+			if !tableRepair.IsOk() {
+				failed++
+			}
+		}
+
+		table.Rows = append(table.Rows, []string{
+			host,
+			result.Duration.String(),
+			fmt.Sprintf("%d", len(result.Tables)),
+			fmt.Sprintf("%d", failed),
+		})
+	}
+
+	return table
+}
+
+// Table returns the duration of a maintenance operation on every node
+func (r MaintenanceResults) Table() OutputTable {
+	table := OutputTable{
+		Header: []string{"HOST", "OPERATION", "DURATION"},
+	}
+
+	for _, host := range sortedKeys(r.ByHost) {
+		table.Rows = append(table.Rows, []string{host, string(r.Operation), r.ByHost[host].Duration.String()})
+	}
+
+	return table
+}
+
+// Table returns the restart duration of every node
+func (r RollingRestartResults) Table() OutputTable {
+	table := OutputTable{
+		Header: []string{"HOST", "DURATION"},
+	}
+
+	for _, host := range sortedKeys(r.ByHost) {
+		table.Rows = append(table.Rows, []string{host, r.ByHost[host].Duration.String()})
+	}
+
+	return table
+}
+
+// Table returns the newest backup of every node
+func (r BackupFreshnessResults) Table() OutputTable {
+	table := OutputTable{
+		Header: []string{"HOST", "LAST BACKUP", "AGE", "STALE", "ERROR"},
+	}
+
+	for _, host := range sortedKeys(r.ByHost) {
+		freshness := r.ByHost[host]
+		age := "-"
+		if !freshness.LastBackup.IsZero() {
+			age = freshness.Age.Round(time.Minute).String()
+		}
+
+		table.Rows = append(table.Rows, []string{
+			host,
+			formatDate(freshness.LastBackup),
+			age,
+			fmt.Sprintf("%t", freshness.Stale),
+			errorString(freshness.Error),
+		})
+	}
+
+	return table
+}
+
+// Table returns every backup of every node
+func (b RemoteBackupsByHost) Table() OutputTable {
+	table := OutputTable{
+		Header: []string{"HOST", "DATE CREATED", "PATH", "REMOVED", "ERROR"},
+	}
+
+	for _, host := range sortedKeys(b) {
+		for _, backup := range b[host] {
+			table.Rows = append(table.Rows, []string{
+				host,
+				formatDate(backup.DateCreated),
+				backup.Path,
+				fmt.Sprintf("%t", backup.Removed),
+				errorString(backup.RemoveError),
+			})
+		}
+	}
+
+	return table
+}
+
+// Table returns every snapshot of every node, with its keyspaces and a number of tables
+func (s SnapshotsByNode) Table() OutputTable {
+	table := OutputTable{
+		Header: []string{"HOST", "TAG", "KEYSPACES", "TABLES"},
+	}
+
+	for _, host := range sortedKeys(s) {
+		for _, tag := range sortedKeys(s[host]) {
+			snapshot := s[host][tag]
+			keyspaces := map[string]bool{}
+			for _, item := range snapshot.Items {
+				keyspaces[item.Keyspace] = true
+			}
+
+			keyspaceNames := make([]string, 0, len(keyspaces))
+			for keyspace := range keyspaces {
+				keyspaceNames = append(keyspaceNames, keyspace)
+			}
+			sort.Strings(keyspaceNames)
+
+			table.Rows = append(table.Rows, []string{
+				host,
+				tag,
+				strings.Join(keyspaceNames, ","),
+				fmt.Sprintf("%d", len(snapshot.Items)),
+			})
+		}
+	}
+
+	return table
+}
+
+// Table returns every repair run
+func (h RepairHistory) Table() OutputTable {
+	table := OutputTable{
+		Header: []string{"ID", "STARTED", "FINISHED", "NODES", "TABLES", "FAILED TABLES"},
+	}
+
+	for _, run := range h {
+		failed := 0
+		for _, tableRepair := range run.Tables {
+			if !tableRepair.IsOk() {
+				failed++
+			}
+		}
+
+		table.Rows = append(table.Rows, []string{
+			run.Id,
+			formatDate(run.DateStarted),
+			formatDate(run.DateFinished),
+			fmt.Sprintf("%d", run.TotalNodes),
+			fmt.Sprintf("%d", len(run.Tables)),
+			fmt.Sprintf("%d", failed),
+		})
+	}
+
+	return table
+}
+
+// Table returns the tables that must be repaired soon
+func (t RepairDueTables) Table() OutputTable {
+	table := OutputTable{
+		Header: []string{"KEYSPACE", "TABLE", "GC GRACE SECONDS", "LAST REPAIRED"},
+	}
+
+	for _, dueTable := range t {
+		table.Rows = append(table.Rows, []string{
+			dueTable.Keyspace,
+			dueTable.Table,
+			fmt.Sprintf("%d", dueTable.GcGraceSeconds),
+			formatDate(dueTable.LastRepaired),
+		})
+	}
+
+	return table
+}
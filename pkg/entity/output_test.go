@@ -0,0 +1,69 @@
+package entity
+
+import (
+	"errors"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestBackupResults_Table(t *testing.T) {
+	results := BackupResults{
+		ByHost: map[string]BackupResult{
+			"127.0.0.2": {
+				SnapshotTag: "snapshot-tag-2",
+				Error:       errors.New("could not backup a node"),
+			},
+			"127.0.0.1": {
+				SnapshotTag: "snapshot-tag-1",
+				Uploaded:    true,
+				Duration:    time.Second,
+				Size:        1536,
+				CleanupResult: CleanupResult{
+					RemoteError:          errors.New("access denied"),
+					RemovedRemoteBackups: []RemoteBackup{{}},
+				},
+			},
+		},
+	}
+
+	require.Equal(t, OutputTable{
+		Header: []string{"HOST", "STATUS", "SNAPSHOT TAG", "UPLOADED", "DURATION", "SIZE", "EXPIRED REMOVED", "ERROR"},
+		Rows: [][]string{
+			{"127.0.0.1", "ok", "snapshot-tag-1", "true", "1s", "1.5 KiB", "1", "access denied"},
+			{"127.0.0.2", "error", "snapshot-tag-2", "false", "0s", "0 B", "0", "could not backup a node"},
+		},
+	}, results.Table())
+}
+
+func TestRemoteBackupsByHost_Table(t *testing.T) {
+	date := time.Date(2022, 1, 2, 3, 4, 0, 0, time.UTC)
+	backups := RemoteBackupsByHost{
+		"host-1": {
+			{Path: "s3://bucket/host-1/01-02-2022-03-04", DateCreated: date},
+			{Path: "s3://bucket/host-1/01-01-2022-03-04", Removed: true},
+		},
+	}
+
+	require.Equal(t, [][]string{
+		{"host-1", "2022-01-02T03:04:00Z", "s3://bucket/host-1/01-02-2022-03-04", "false", ""},
+		{"host-1", "-", "s3://bucket/host-1/01-01-2022-03-04", "true", ""},
+	}, backups.Table().Rows)
+}
+
+func TestSnapshotsByNode_Table(t *testing.T) {
+	snapshots := SnapshotsByNode{
+		"host-1": {
+			"tag-1": {
+				Tag: "tag-1",
+				Items: []SnapshotItem{
+					{Keyspace: "ks2", ColumnFamily: "a"},
+					{Keyspace: "ks1", ColumnFamily: "b"},
+					{Keyspace: "ks2", ColumnFamily: "c"},
+				},
+			},
+		},
+	}
+
+	require.Equal(t, [][]string{{"host-1", "tag-1", "ks1,ks2", "3"}}, snapshots.Table().Rows)
+}
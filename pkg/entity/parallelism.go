@@ -0,0 +1,30 @@
+package entity
+
+// Parallelism limits how many nodes are processed at once.
+// Zero means no limit.
+type Parallelism struct {
+	// the maximum number of nodes processed at once in the whole cluster
+	Max int
+	// the maximum number of nodes processed at once in every datacenter
+	PerDatacenter int `yaml:"perDatacenter"`
+	// the maximum number of nodes processed at once in every rack
+	PerRack int `yaml:"perRack"`
+}
+
+// Allows whether one more node can be started, given the number of nodes running in the cluster,
+// in the node's datacenter and in the node's rack
+func (p Parallelism) Allows(running, runningInDatacenter, runningInRack int) bool {
+	if p.Max > 0 && running >= p.Max {
+		return false
+	}
+
+	if p.PerDatacenter > 0 && runningInDatacenter >= p.PerDatacenter {
+		return false
+	}
+
+	if p.PerRack > 0 && runningInRack >= p.PerRack {
+		return false
+	}
+
+	return true
+}
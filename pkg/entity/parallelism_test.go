@@ -0,0 +1,17 @@
+package entity
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParallelism_Allows(t *testing.T) {
+	require.True(t, Parallelism{}.Allows(100, 100, 100), "no limits by default")
+
+	parallelism := Parallelism{Max: 3, PerDatacenter: 2, PerRack: 1}
+	require.True(t, parallelism.Allows(0, 0, 0))
+	require.True(t, parallelism.Allows(2, 1, 0))
+	require.False(t, parallelism.Allows(3, 0, 0), "the cluster limit is reached")
+	require.False(t, parallelism.Allows(2, 2, 0), "the datacenter limit is reached")
+	require.False(t, parallelism.Allows(2, 1, 1), "the rack limit is reached")
+}
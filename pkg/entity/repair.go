@@ -2,15 +2,44 @@ package entity
 
 import (
 	"fmt"
-	"html"
 	"strings"
 	"time"
 )
 
+// RepairRangesPrimary means that only the primary token ranges of a node were repaired
+// (`nodetool repair --partitioner-range`)
+const RepairRangesPrimary = "primary"
+
+// Table repair statuses
+const (
+	RepairStatusOk     = "ok"
+	RepairStatusFailed = "failed"
+)
+
 // RepairResult a result of "nodetool repair" command
 type RepairResult struct {
 	Output   string
 	Duration time.Duration
+	// repair results of individual tables
+	Tables []TableRepair
+}
+
+// TableRepair a result of repairing a single table on a database node
+type TableRepair struct {
+	Host         string
+	Keyspace     string
+	Table        string
+	DateStarted  time.Time
+	DateFinished time.Time
+	Status       string
+	// which token ranges were repaired (see RepairRangesPrimary)
+	Ranges string
+	Error  string
+}
+
+// IsOk whether a table was repaired successfully
+func (t TableRepair) IsOk() bool {
+	return t.Status == RepairStatusOk
 }
 
 // RepairResults results of "nodetool repair" on multiple database nodes
@@ -33,7 +62,7 @@ func (r RepairResults) Report() string {
 		lines = append(
 			lines,
 			"Error:",
-			fmt.Sprintf("%s", html.EscapeString(r.Error.Error())),
+			fmt.Sprintf("%s", r.Error.Error()),
 		)
 	}
 
@@ -49,3 +78,105 @@ func (r RepairResults) Report() string {
 
 	return strings.Join(lines, "\n")
 }
+
+// RepairRun a single execution of repair on a database cluster, as stored in repair history
+type RepairRun struct {
+	Id           string
+	DateStarted  time.Time
+	DateFinished time.Time
+	// a number of nodes in the cluster at the time of repair
+	TotalNodes int
+	Tables     []TableRepair
+}
+
+// NewRepairRunId creates a repair run identifier based on its start date
+func NewRepairRunId(dateStarted time.Time) string {
+	return dateStarted.UTC().Format("20060102T150405Z")
+}
+
+// RepairHistory a list of repair runs, from the oldest to the newest
+type RepairHistory []RepairRun
+
+// LastRepaired returns the date of the last successful repair of every table, where the map key is "keyspace.table".
+// Since each node only repairs its primary token ranges, a table is considered repaired
+// only when it was repaired successfully on every cluster node within the same run.
+// The date of the earliest table repair start in such run is used,
+// because the data written after it might still be inconsistent.
+func (h RepairHistory) LastRepaired() map[string]time.Time {
+	result := map[string]time.Time{}
+
+	for _, run := range h {
+		repairedHosts := map[string]map[string]bool{}
+		dateStarted := map[string]time.Time{}
+		failed := map[string]bool{}
+
+		for _, table := range run.Tables {
+			name := Table{Keyspace: table.Keyspace, Name: table.Table}.FullName()
+
+			if !table.IsOk() {
+				failed[name] = true
+				continue
+			}
+
+			if repairedHosts[name] == nil {
+				repairedHosts[name] = map[string]bool{}
+			}
+			repairedHosts[name][table.Host] = true
+
+			if dateStarted[name].IsZero() || table.DateStarted.Before(dateStarted[name]) {
+				dateStarted[name] = table.DateStarted
+			}
+		}
+
+		for name, hosts := range repairedHosts {
+			if failed[name] || len(hosts) < run.TotalNodes {
+				continue
+			}
+
+			if dateStarted[name].After(result[name]) {
+				result[name] = dateStarted[name]
+			}
+		}
+	}
+
+	return result
+}
+
+// DueTables returns the tables that must be repaired soon:
+// those that were never repaired, or whose last repair is older than a given fraction of their gc_grace_seconds.
+// Local tables and tables with zero gc_grace_seconds are skipped.
+func (h RepairHistory) DueTables(tables []Table, now time.Time, dueFraction float64) []RepairDueTable {
+	lastRepaired := h.LastRepaired()
+	result := []RepairDueTable{}
+
+	for _, table := range tables {
+		if table.IsLocal() || table.GcGraceSeconds <= 0 {
+			continue
+		}
+
+		dueAfter := time.Duration(float64(table.GcGraceSeconds)*dueFraction) * time.Second
+		dateRepaired := lastRepaired[table.FullName()]
+
+		if !dateRepaired.IsZero() && now.Sub(dateRepaired) < dueAfter {
+			continue
+		}
+
+		result = append(result, RepairDueTable{
+			Keyspace:       table.Keyspace,
+			Table:          table.Name,
+			GcGraceSeconds: table.GcGraceSeconds,
+			LastRepaired:   dateRepaired,
+		})
+	}
+
+	return result
+}
+
+// RepairDueTable a table that must be repaired soon
+type RepairDueTable struct {
+	Keyspace       string
+	Table          string
+	GcGraceSeconds int
+	// a zero value means the table was never repaired
+	LastRepaired time.Time
+}
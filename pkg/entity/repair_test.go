@@ -31,9 +31,38 @@ func TestRepairResults_Report(t *testing.T) {
 Repaired nodes: 2
 
 Error:
-test error &lt;test-tag&gt;`,
+test error <test-tag>`,
 	)
 
 	require.Contains(t, report, `localhost: 1s`)
 	require.Contains(t, report, `google.com: 2s`)
 }
+
+func TestRepairHistory_LastRepaired(t *testing.T) {
+	dateStarted := time.Date(2021, 10, 22, 15, 0, 0, 0, time.UTC)
+	history := RepairHistory{
+		// a complete run on a cluster of 2 nodes
+		{
+			TotalNodes: 2,
+			Tables: []TableRepair{
+				{Host: "host-1", Keyspace: "test", Table: "users", Status: RepairStatusOk, DateStarted: dateStarted},
+				{Host: "host-2", Keyspace: "test", Table: "users", Status: RepairStatusOk, DateStarted: dateStarted.Add(time.Minute)},
+			},
+		},
+		// a newer run that has failed on a second node
+		{
+			TotalNodes: 2,
+			Tables: []TableRepair{
+				{Host: "host-1", Keyspace: "test", Table: "users", Status: RepairStatusOk, DateStarted: dateStarted.Add(time.Hour)},
+				{Host: "host-2", Keyspace: "test", Table: "users", Status: RepairStatusFailed, DateStarted: dateStarted.Add(time.Hour)},
+			},
+		},
+	}
+
+	require.Equal(
+		t,
+		map[string]time.Time{"test.users": dateStarted},
+		history.LastRepaired(),
+		"a table is repaired at the start of the last run that was successful on every node",
+	)
+}
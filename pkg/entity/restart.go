@@ -0,0 +1,57 @@
+package entity
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RollingRestartResult a result of restarting a single database node
+type RollingRestartResult struct {
+	// how long it took to drain, restart and wait for the node to come back
+	Duration time.Duration
+}
+
+// RollingRestartResults results of a rolling restart of a database cluster
+type RollingRestartResults struct {
+	TotalNodes     int
+	RestartedNodes int
+	ByHost         map[string]RollingRestartResult
+	Error          error
+}
+
+// Report creates a human-readable report string about rolling restart results to be used in a notification
+func (r RollingRestartResults) Report() string {
+	lines := []string{
+		fmt.Sprintf("Total nodes: %d", r.TotalNodes),
+		fmt.Sprintf("Restarted nodes: %d", r.RestartedNodes),
+		"",
+	}
+
+	if r.Error != nil {
+		lines = append(
+			lines,
+			"Error:",
+			r.Error.Error(),
+		)
+	}
+
+	lines = append(lines, "Duration:")
+
+	hosts := make([]string, 0, len(r.ByHost))
+	for host := range r.ByHost {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	for _, host := range hosts {
+		lines = append(lines, fmt.Sprintf(
+			"%s: %s",
+			host,
+			r.ByHost[host].Duration.String(),
+		))
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,290 @@
+package entity
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// OperationRun a recorded run of an operation (e.g. a backup) on the cluster
+type OperationRun struct {
+	Id string
+	// backup, cleanup, repair, maintenance or restart
+	Operation    string
+	DateStarted  time.Time
+	DateFinished time.Time
+	TotalNodes   int
+	// the outcome of every node, in alphabetical order of hosts
+	Hosts []HostRun
+	// the errors of all nodes (empty if the run succeeded)
+	Error string `json:",omitempty"`
+}
+
+// HostRun the outcome of an operation on a single node
+type HostRun struct {
+	Host     string
+	Duration time.Duration
+	// the size of the data processed on a node (e.g. a backup size), if known
+	Bytes int64  `json:",omitempty"`
+	Error string `json:",omitempty"`
+}
+
+// RunHistory a list of operation runs, from the oldest to the newest
+type RunHistory []OperationRun
+
+// RunStats the success rate of an operation
+type RunStats struct {
+	Operation string
+	Runs      int
+	Succeeded int
+	Failed    int
+	// a percentage of successful runs
+	SuccessRate float64
+	// the total size of the data processed by all runs
+	Bytes       int64
+	LastSuccess time.Time
+	LastFailure time.Time
+}
+
+// RunStatsList the stats of multiple operations
+type RunStatsList []RunStats
+
+// NewRunId creates a run identifier based on an operation and its start date.
+// The runs started in the same second get the same identifier, so the history adds a sequence suffix to it.
+func NewRunId(operation string, dateStarted time.Time) string {
+	return operation + "-" + dateStarted.UTC().Format("20060102T150405Z")
+}
+
+// NewOperationRun creates a run of an operation from the callback results of every node
+func NewOperationRun(operation string, dateStarted time.Time, totalNodes int, results NodeCallbackResults) OperationRun {
+	run := OperationRun{
+		Id:           NewRunId(operation, dateStarted),
+		Operation:    operation,
+		DateStarted:  dateStarted,
+		DateFinished: time.Now(),
+		TotalNodes:   totalNodes,
+		Hosts:        []HostRun{},
+		Error:        errorString(results.Error()),
+	}
+
+	for _, host := range results.Hosts() {
+		result := results[host]
+		hostRun := HostRun{
+			Host:  host,
+			Error: errorString(result.Err),
+		}
+
+		if !result.DateStarted.IsZero() && !result.DateFinished.IsZero() {
+			hostRun.Duration = result.DateFinished.Sub(result.DateStarted)
+		}
+
+		run.Hosts = append(run.Hosts, hostRun)
+	}
+
+	return run
+}
+
+// IsOk whether an operation succeeded on every node
+func (r OperationRun) IsOk() bool {
+	return r.Error == ""
+}
+
+// Bytes returns the total size of the data processed on all nodes
+func (r OperationRun) Bytes() int64 {
+	var bytes int64
+	for _, host := range r.Hosts {
+		bytes += host.Bytes
+	}
+
+	return bytes
+}
+
+// Report creates a human-readable report about a run
+func (r OperationRun) Report() string {
+	status := "ok"
+	if !r.IsOk() {
+		status = "failed"
+	}
+
+	lines := []string{
+		fmt.Sprintf("Id: %s", r.Id),
+		fmt.Sprintf("Operation: %s", r.Operation),
+		fmt.Sprintf("Status: %s", status),
+		fmt.Sprintf("Started: %s", formatDate(r.DateStarted)),
+		fmt.Sprintf("Finished: %s", formatDate(r.DateFinished)),
+		fmt.Sprintf("Total nodes: %d", r.TotalNodes),
+	}
+
+	if bytes := r.Bytes(); bytes > 0 {
+		lines = append(lines, fmt.Sprintf("Size: %s", FormatBytes(bytes)))
+	}
+
+	lines = append(lines, "", "Nodes:")
+
+	for _, host := range r.Hosts {
+		line := fmt.Sprintf("%s: ok (%s)", host.Host, host.Duration)
+		if host.Error != "" {
+			line = fmt.Sprintf("%s: %s", host.Host, host.Error)
+		}
+
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Table returns the outcome of every node
+func (r OperationRun) Table() OutputTable {
+	table := OutputTable{
+		Header: []string{"HOST", "STATUS", "DURATION", "SIZE", "ERROR"},
+	}
+
+	for _, host := range r.Hosts {
+		status := "ok"
+		if host.Error != "" {
+			status = "error"
+		}
+
+		table.Rows = append(table.Rows, []string{
+			host.Host,
+			status,
+			host.Duration.String(),
+			FormatBytes(host.Bytes),
+			host.Error,
+		})
+	}
+
+	return table
+}
+
+// Find returns a run with a given id
+func (h RunHistory) Find(id string) (OperationRun, bool) {
+	for _, run := range h {
+		if run.Id == id {
+			return run, true
+		}
+	}
+
+	return OperationRun{}, false
+}
+
+// Filter returns the runs of a given operation (any operation if empty) that started within [from, to).
+// A zero date is not a limit.
+func (h RunHistory) Filter(operation string, from, to time.Time) RunHistory {
+	runs := RunHistory{}
+
+	for _, run := range h {
+		if operation != "" && run.Operation != operation {
+			continue
+		}
+
+		if !from.IsZero() && run.DateStarted.Before(from) {
+			continue
+		}
+
+		if !to.IsZero() && !run.DateStarted.Before(to) {
+			continue
+		}
+
+		runs = append(runs, run)
+	}
+
+	return runs
+}
+
+// Stats returns the success rate of every operation, in alphabetical order of operations
+func (h RunHistory) Stats() RunStatsList {
+	byOperation := map[string]*RunStats{}
+
+	for _, run := range h {
+		stats, ok := byOperation[run.Operation]
+		if !ok {
+			stats = &RunStats{Operation: run.Operation}
+			byOperation[run.Operation] = stats
+		}
+
+		stats.Runs++
+		stats.Bytes += run.Bytes()
+
+		if run.IsOk() {
+			stats.Succeeded++
+			if run.DateStarted.After(stats.LastSuccess) {
+				stats.LastSuccess = run.DateStarted
+			}
+		} else {
+			stats.Failed++
+			if run.DateStarted.After(stats.LastFailure) {
+				stats.LastFailure = run.DateStarted
+			}
+		}
+	}
+
+	statsList := RunStatsList{}
+	for _, stats := range byOperation {
+		stats.SuccessRate = float64(stats.Succeeded) * 100 / float64(stats.Runs)
+		statsList = append(statsList, *stats)
+	}
+
+	sort.Slice(statsList, func(i, j int) bool {
+		return statsList[i].Operation < statsList[j].Operation
+	})
+
+	return statsList
+}
+
+// Table returns every run
+func (h RunHistory) Table() OutputTable {
+	table := OutputTable{
+		Header: []string{"ID", "OPERATION", "STARTED", "FINISHED", "NODES", "FAILED NODES", "SIZE", "STATUS"},
+	}
+
+	for _, run := range h {
+		failed := 0
+		for _, host := range run.Hosts {
+			if host.Error != "" {
+				failed++
+			}
+		}
+
+		status := "ok"
+		if !run.IsOk() {
+			status = "failed"
+		}
+
+		table.Rows = append(table.Rows, []string{
+			run.Id,
+			run.Operation,
+			formatDate(run.DateStarted),
+			formatDate(run.DateFinished),
+			fmt.Sprintf("%d", run.TotalNodes),
+			fmt.Sprintf("%d", failed),
+			FormatBytes(run.Bytes()),
+			status,
+		})
+	}
+
+	return table
+}
+
+// Table returns the success rate of every operation
+func (l RunStatsList) Table() OutputTable {
+	table := OutputTable{
+		Header: []string{"OPERATION", "RUNS", "SUCCEEDED", "FAILED", "SUCCESS RATE", "SIZE", "LAST SUCCESS", "LAST FAILURE"},
+	}
+
+	for _, stats := range l {
+		table.Rows = append(table.Rows, []string{
+			stats.Operation,
+			fmt.Sprintf("%d", stats.Runs),
+			fmt.Sprintf("%d", stats.Succeeded),
+			fmt.Sprintf("%d", stats.Failed),
+			fmt.Sprintf("%.2f%%", stats.SuccessRate),
+			FormatBytes(stats.Bytes),
+			formatDate(stats.LastSuccess),
+			formatDate(stats.LastFailure),
+		})
+	}
+
+	return table
+}
@@ -0,0 +1,73 @@
+package entity
+
+import (
+	"errors"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestNewOperationRun(t *testing.T) {
+	dateStarted := time.Date(2022, 1, 2, 3, 0, 0, 0, time.UTC)
+	run := NewOperationRun("backup", dateStarted, 3, NodeCallbackResults{
+		"host-2": {Host: "host-2", Err: errors.New("test error")},
+		"host-1": {
+			Host:         "host-1",
+			DateStarted:  dateStarted,
+			DateFinished: dateStarted.Add(time.Minute),
+		},
+	})
+
+	require.Equal(t, "backup-20220102T030000Z", run.Id)
+	require.Equal(t, 3, run.TotalNodes)
+	require.False(t, run.IsOk())
+	require.Contains(t, run.Error, "test error")
+	require.Equal(t, []HostRun{
+		{Host: "host-1", Duration: time.Minute},
+		{Host: "host-2", Error: "test error"},
+	}, run.Hosts)
+}
+
+func TestRunHistory_Stats(t *testing.T) {
+	day := func(n int) time.Time {
+		return time.Date(2022, 1, n, 3, 0, 0, 0, time.UTC)
+	}
+	history := RunHistory{
+		{Id: "1", Operation: "backup", DateStarted: day(1), Hosts: []HostRun{{Bytes: 100}, {Bytes: 200}}},
+		{Id: "2", Operation: "backup", DateStarted: day(2), Error: "test error"},
+		{Id: "3", Operation: "repair", DateStarted: day(2)},
+		{Id: "4", Operation: "backup", DateStarted: day(3), Hosts: []HostRun{{Bytes: 300}}},
+		{Id: "5", Operation: "backup", DateStarted: day(4), Hosts: []HostRun{{Bytes: 400}}},
+	}
+
+	run, ok := history.Find("2")
+	require.True(t, ok)
+	require.Equal(t, day(2), run.DateStarted)
+	_, ok = history.Find("6")
+	require.False(t, ok)
+
+	filtered := history.Filter("backup", day(2), day(4))
+	require.Len(t, filtered, 2)
+	require.Equal(t, "2", filtered[0].Id)
+	require.Equal(t, "4", filtered[1].Id)
+
+	require.Equal(t, RunStatsList{
+		{
+			Operation:   "backup",
+			Runs:        4,
+			Succeeded:   3,
+			Failed:      1,
+			SuccessRate: 75,
+			Bytes:       1000,
+			LastSuccess: day(4),
+			LastFailure: day(2),
+		},
+		{
+			Operation:   "repair",
+			Runs:        1,
+			Succeeded:   1,
+			SuccessRate: 100,
+			LastSuccess: day(2),
+		},
+	}, history.Stats())
+}
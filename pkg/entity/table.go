@@ -0,0 +1,69 @@
+package entity
+
+import (
+	"strconv"
+	"strings"
+)
+
+// keyspaces that are stored locally on every node and are never repaired
+var localKeyspaces = map[string]bool{
+	"system":        true,
+	"system_schema": true,
+}
+
+// Table a database table with the schema settings relevant for maintenance
+type Table struct {
+	Keyspace       string
+	Name           string
+	GcGraceSeconds int
+}
+
+// FullName returns a table name prefixed with a keyspace, e.g. "keyspace.table"
+func (t Table) FullName() string {
+	return t.Keyspace + "." + t.Name
+}
+
+// IsLocal whether a table belongs to a keyspace that is not replicated between nodes
+func (t Table) IsLocal() bool {
+	return localKeyspaces[t.Keyspace]
+}
+
+// ParseTables returns a list of tables from `cqlsh` output of the following query:
+// SELECT keyspace_name, table_name, gc_grace_seconds FROM system_schema.tables
+func ParseTables(output string) []Table {
+	tables := []Table{}
+	lines := strings.Split(output, "\n")
+	headerFound := false
+
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+
+		if !headerFound {
+			// the rows start after a "-----+-----" separator
+			if strings.HasPrefix(line, "---") && strings.Contains(line, "+") {
+				headerFound = true
+			}
+
+			continue
+		}
+
+		parts := strings.Split(line, "|")
+		if len(parts) < 3 {
+			// an empty line or a "(N rows)" summary
+			continue
+		}
+
+		gcGraceSeconds, err := strconv.Atoi(strings.TrimSpace(parts[2]))
+		if err != nil {
+			continue
+		}
+
+		tables = append(tables, Table{
+			Keyspace:       strings.TrimSpace(parts[0]),
+			Name:           strings.TrimSpace(parts[1]),
+			GcGraceSeconds: gcGraceSeconds,
+		})
+	}
+
+	return tables
+}
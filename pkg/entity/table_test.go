@@ -0,0 +1,27 @@
+package entity
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParseTables(t *testing.T) {
+	output := `
+ keyspace_name | table_name | gc_grace_seconds
+---------------+------------+------------------
+        system |      local |                0
+          test |      users |           864000
+          test |     orders |            3600
+
+(3 rows)
+`
+	require.Equal(
+		t,
+		[]Table{
+			{Keyspace: "system", Name: "local", GcGraceSeconds: 0},
+			{Keyspace: "test", Name: "users", GcGraceSeconds: 864000},
+			{Keyspace: "test", Name: "orders", GcGraceSeconds: 3600},
+		},
+		ParseTables(output),
+	)
+}
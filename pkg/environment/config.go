@@ -2,16 +2,21 @@ package environment
 
 import (
 	"github.com/go-yaml/yaml"
+	"github.com/kolesa-team/scylla-octopus/app"
 	"github.com/kolesa-team/scylla-octopus/app/backup"
 	"github.com/kolesa-team/scylla-octopus/pkg/awscli"
 	"github.com/kolesa-team/scylla-octopus/pkg/cluster"
 	"github.com/kolesa-team/scylla-octopus/pkg/cmd/factory"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd/ssh"
 	"github.com/kolesa-team/scylla-octopus/pkg/entity"
 	"github.com/kolesa-team/scylla-octopus/pkg/notifier"
 	"github.com/pkg/errors"
 	"go.uber.org/zap/zapcore"
 	"io/ioutil"
 	"net"
+	"os"
+	"path/filepath"
+	"time"
 )
 
 // Config holds project configuration
@@ -21,6 +26,10 @@ type Config struct {
 	Awscli      *awscli.Options
 	Log         LogOptions
 	Backup      backup.Options
+	Repair      app.RepairOptions
+	Restart     app.RollingRestartOptions
+	History     app.HistoryOptions
+	Parallelism app.ParallelismOptions
 	Notifier    notifier.Options
 	Commands    factory.Options
 }
@@ -38,6 +47,11 @@ func GetConfig(file string, forceVerboseMode bool) (Config, error) {
 		return cfg, errors.Wrap(err, "could not parse configuration file")
 	}
 
+	err = parseClusterHosts(cfgBytes, &cfg)
+	if err != nil {
+		return cfg, errors.Wrap(err, "could not parse cluster.hosts")
+	}
+
 	// sanity checks
 	if cfg.Awscli == nil {
 		if cfg.Backup.DisableUpload == false {
@@ -55,12 +69,58 @@ func GetConfig(file string, forceVerboseMode bool) (Config, error) {
 		}
 	}
 
+	if cfg.Repair.HistoryFile == "" {
+		cfg.Repair.HistoryFile = defaultDataPath("repair-history.json")
+	}
+
+	if cfg.Repair.HistoryMaxRuns <= 0 {
+		cfg.Repair.HistoryMaxRuns = 1000
+	}
+
+	if cfg.History.File == "" {
+		cfg.History.File = defaultDataPath("run-history.json")
+	}
+
+	if cfg.History.MaxRuns <= 0 {
+		cfg.History.MaxRuns = 1000
+	}
+
+	if cfg.Backup.MaxAge <= 0 {
+		// a daily backup with some slack
+		cfg.Backup.MaxAge = time.Hour * 25
+	}
+
+	if cfg.Notifier.StateFile == "" {
+		cfg.Notifier.StateFile = defaultDataPath("notifier-state.json")
+	}
+
+	cfg.Notifier.Cluster = cfg.Cluster.ClusterName
+	if cfg.Notifier.Cluster == "" {
+		cfg.Notifier.Cluster, _ = os.Hostname()
+	}
+
+	if cfg.Repair.DueFraction <= 0 {
+		cfg.Repair.DueFraction = 0.5
+	}
+
+	if cfg.Restart.Command == "" {
+		cfg.Restart.Command = "systemctl restart scylla-server"
+	}
+
+	if cfg.Restart.PollInterval <= 0 {
+		cfg.Restart.PollInterval = time.Second * 10
+	}
+
+	if cfg.Restart.Timeout <= 0 {
+		cfg.Restart.Timeout = time.Minute * 15
+	}
+
 	if forceVerboseMode {
 		cfg.Log.Level = zapcore.DebugLevel
 		cfg.Commands.Debug = true
 	}
 
-	if len(cfg.Cluster.Hosts) == 0 {
+	if len(cfg.Cluster.Hosts) == 0 && len(cfg.Cluster.Seeds) == 0 {
 		// if no remote hosts are present, assume we're running locally
 		cfg.Commands.UseSSH = false
 		cfg.Cluster.Hosts = []string{getLocalIP()}
@@ -71,6 +131,49 @@ func GetConfig(file string, forceVerboseMode bool) (Config, error) {
 	return cfg, nil
 }
 
+// reads cluster.hosts, where every entry is either an address,
+// or a mapping with an address and SSH connection overrides (user, port, keyFile)
+func parseClusterHosts(cfgBytes []byte, cfg *Config) error {
+	hostsConfig := struct {
+		Cluster struct {
+			Hosts []ssh.HostOptions
+		}
+	}{}
+
+	err := yaml.Unmarshal(cfgBytes, &hostsConfig)
+	if err != nil {
+		return err
+	}
+
+	for _, host := range hostsConfig.Cluster.Hosts {
+		if len(host.Host) == 0 {
+			return errors.New("every host must have an address")
+		}
+
+		cfg.Cluster.Hosts = append(cfg.Cluster.Hosts, host.Host)
+
+		if host != (ssh.HostOptions{Host: host.Host}) {
+			if cfg.Commands.SSH.Hosts == nil {
+				cfg.Commands.SSH.Hosts = map[string]ssh.HostOptions{}
+			}
+
+			cfg.Commands.SSH.Hosts[host.Host] = host
+		}
+	}
+
+	return nil
+}
+
+// returns a path to a file in a directory where the tool keeps its own data (~/.scylla-octopus)
+func defaultDataPath(filename string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = os.TempDir()
+	}
+
+	return filepath.Join(home, ".scylla-octopus", filename)
+}
+
 func getLocalIP() string {
 	addrs, err := net.InterfaceAddrs()
 
@@ -7,6 +7,7 @@ import (
 	"github.com/kolesa-team/scylla-octopus/pkg/cluster"
 	cmdFactory "github.com/kolesa-team/scylla-octopus/pkg/cmd/factory"
 	"github.com/kolesa-team/scylla-octopus/pkg/entity"
+	"github.com/kolesa-team/scylla-octopus/pkg/history"
 	"github.com/kolesa-team/scylla-octopus/pkg/notifier"
 	"github.com/kolesa-team/scylla-octopus/pkg/scylla"
 	"go.uber.org/zap"
@@ -23,6 +24,8 @@ type Environment struct {
 	Cluster       *cluster.Cluster
 	Notifier      notifier.Notifier
 	BackupService *backup.Service
+	RepairHistory *history.RepairStore
+	RunHistory    *history.RunStore
 	App           *app.Octopus
 }
 
@@ -35,7 +38,15 @@ func GetEnvironment(cfg Config, buildInfo entity.BuildInfo) (Environment, error)
 		Logger:    getLogger(cfg.Log),
 	}
 
-	env.Notifier = notifier.New(cfg.Notifier, env.Logger)
+	env.Notifier, err = notifier.New(cfg.Notifier, env.Logger)
+	if err != nil {
+		return env, err
+	}
+
+	if cfg.Commands.DryRun {
+		// nothing is actually done in dry-run mode, so there's nothing to notify about
+		env.Notifier = notifier.Disabled{}
+	}
 	env.Scylla = scylla.NewClient(cfg.Credentials, env.Logger)
 
 	if cfg.Awscli == nil {
@@ -45,6 +56,13 @@ func GetEnvironment(cfg Config, buildInfo entity.BuildInfo) (Environment, error)
 
 	env.AwsCli = awscli.NewClient(*cfg.Awscli, env.Logger)
 
+	// passwords are masked in the audit log
+	cfg.Commands.Audit.Secrets = append(
+		cfg.Commands.Audit.Secrets,
+		cfg.Credentials.Password,
+		cfg.Commands.Sudo.Password,
+	)
+
 	env.CmdFactory, err = cmdFactory.NewFactory(cfg.Commands, env.Logger)
 	if err != nil {
 		return env, err
@@ -53,6 +71,7 @@ func GetEnvironment(cfg Config, buildInfo entity.BuildInfo) (Environment, error)
 	env.Cluster = cluster.NewCluster(
 		cfg.Cluster,
 		env.CmdFactory,
+		env.Scylla,
 		env.Logger,
 	)
 
@@ -65,14 +84,30 @@ func GetEnvironment(cfg Config, buildInfo entity.BuildInfo) (Environment, error)
 		env.Logger,
 	)
 
+	if cfg.Commands.DryRun {
+		env.RepairHistory = history.NewReadOnlyRepairStore(cfg.Repair.HistoryFile)
+		env.RunHistory = history.NewReadOnlyRunStore(cfg.History.File)
+	} else {
+		env.RepairHistory = history.NewRepairStore(cfg.Repair.HistoryFile, cfg.Repair.HistoryMaxRuns, cfg.Repair.HistoryMaxAge)
+		env.RunHistory = history.NewRunStore(cfg.History.File, cfg.History.MaxRuns, cfg.History.MaxAge)
+	}
+
 	env.App = app.NewOctopus(
 		env.Cluster,
 		env.Scylla,
 		env.BackupService,
 		env.AwsCli,
+		env.RepairHistory,
+		env.RunHistory,
+		cfg.Parallelism,
 		env.Notifier,
 		env.Logger,
 	)
 
 	return env, nil
 }
+
+// Close releases the resources held by the environment (e.g. SSH connections)
+func (env Environment) Close() error {
+	return env.CmdFactory.Close()
+}
@@ -0,0 +1,48 @@
+package history
+
+// This package keeps the history of operations in local JSON files.
+
+import (
+	"encoding/json"
+	"github.com/kolesa-team/scylla-octopus/pkg/atomicfile"
+	"github.com/pkg/errors"
+	"os"
+)
+
+// reads a JSON file into a given value.
+// A missing file is not an error: the value is left untouched.
+func readJsonFile(path string, value interface{}) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "could not read history file %s", path)
+	}
+
+	err = json.Unmarshal(data, value)
+	if err != nil {
+		return errors.Wrapf(err, "could not parse history file %s", path)
+	}
+
+	return nil
+}
+
+// writes a given value into a JSON file.
+// The file is replaced atomically, so that the history is not corrupted if the program is interrupted.
+func writeJsonFile(path string, value interface{}) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return errors.Wrapf(atomicfile.Write(path, data), "could not write history file %s", path)
+}
+
+// takes a lock of a history file, so that concurrent runs of the program don't lose each other's changes
+func lockFile(path string) (func(), error) {
+	unlock, err := atomicfile.Lock(path)
+
+	return unlock, errors.Wrapf(err, "could not lock history file %s", path)
+}
@@ -0,0 +1,86 @@
+package history
+
+import (
+	"github.com/kolesa-team/scylla-octopus/pkg/entity"
+	"sync"
+	"time"
+)
+
+// RepairStore keeps the history of repair runs in a local JSON file
+type RepairStore struct {
+	path string
+	mu   sync.Mutex
+	// the history is not changed (e.g. in dry-run mode)
+	readOnly bool
+	// the number of the newest runs to keep (0 means no limit)
+	maxRuns int
+	// the runs started earlier than this are removed (0 means no limit)
+	maxAge time.Duration
+}
+
+// NewRepairStore creates a store that keeps at most maxRuns runs not older than maxAge (0 means no limit)
+func NewRepairStore(path string, maxRuns int, maxAge time.Duration) *RepairStore {
+	return &RepairStore{path: path, maxRuns: maxRuns, maxAge: maxAge}
+}
+
+// NewReadOnlyRepairStore creates a store that reads the history, but ignores new runs (for dry-run mode)
+func NewReadOnlyRepairStore(path string) *RepairStore {
+	return &RepairStore{path: path, readOnly: true}
+}
+
+// Add appends a repair run to the history and removes the runs beyond the limits
+func (s *RepairStore) Add(run entity.RepairRun) error {
+	if s.readOnly {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := lockFile(s.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	runs := entity.RepairHistory{}
+	err = readJsonFile(s.path, &runs)
+	if err != nil {
+		return err
+	}
+
+	runs = append(runs, run)
+
+	return writeJsonFile(s.path, s.prune(runs, time.Now()))
+}
+
+// List returns all repair runs, from the oldest to the newest
+func (s *RepairStore) List() (entity.RepairHistory, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	runs := entity.RepairHistory{}
+	err := readJsonFile(s.path, &runs)
+
+	return runs, err
+}
+
+// returns the runs within the limits of the store: the newest ones that are not too old
+func (s *RepairStore) prune(runs entity.RepairHistory, now time.Time) entity.RepairHistory {
+	if s.maxAge > 0 {
+		recentRuns := entity.RepairHistory{}
+		for _, run := range runs {
+			if now.Sub(run.DateStarted) <= s.maxAge {
+				recentRuns = append(recentRuns, run)
+			}
+		}
+
+		runs = recentRuns
+	}
+
+	if s.maxRuns > 0 && len(runs) > s.maxRuns {
+		runs = runs[len(runs)-s.maxRuns:]
+	}
+
+	return runs
+}
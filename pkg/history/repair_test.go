@@ -0,0 +1,74 @@
+package history
+
+import (
+	"github.com/kolesa-team/scylla-octopus/pkg/entity"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRepairStore(t *testing.T) {
+	store := NewRepairStore(filepath.Join(t.TempDir(), "history", "repair.json"), 0, 0)
+
+	runs, err := store.List()
+	require.NoError(t, err, "a missing history file is not an error")
+	require.Empty(t, runs)
+
+	dateStarted := time.Date(2021, 10, 22, 15, 1, 0, 0, time.UTC)
+	for i := 0; i < 2; i++ {
+		require.NoError(t, store.Add(entity.RepairRun{
+			Id:          entity.NewRepairRunId(dateStarted.Add(time.Hour * time.Duration(i))),
+			DateStarted: dateStarted.Add(time.Hour * time.Duration(i)),
+			TotalNodes:  1,
+			Tables: []entity.TableRepair{
+				{
+					Host:     "host-1",
+					Keyspace: "test",
+					Table:    "users",
+					Status:   entity.RepairStatusOk,
+					Ranges:   entity.RepairRangesPrimary,
+				},
+			},
+		}))
+	}
+
+	runs, err = store.List()
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	require.Equal(t, "20211022T150100Z", runs[0].Id)
+	require.Equal(t, "20211022T160100Z", runs[1].Id)
+	require.Equal(t, "users", runs[1].Tables[0].Table)
+}
+
+// Only the newest repair runs within the limits are kept
+func TestRepairStore_Retention(t *testing.T) {
+	store := NewRepairStore(filepath.Join(t.TempDir(), "repair.json"), 2, time.Hour*24)
+	now := time.Now()
+
+	for _, age := range []time.Duration{time.Hour * 48, time.Hour * 3, time.Hour * 2, time.Hour} {
+		require.NoError(t, store.Add(entity.RepairRun{
+			Id:          entity.NewRepairRunId(now.Add(-age)),
+			DateStarted: now.Add(-age),
+		}))
+	}
+
+	runs, err := store.List()
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	require.Equal(t, entity.NewRepairRunId(now.Add(-time.Hour*2)), runs[0].Id)
+	require.Equal(t, entity.NewRepairRunId(now.Add(-time.Hour)), runs[1].Id)
+}
+
+// A read-only store doesn't record new runs
+func TestRepairStore_ReadOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "repair.json")
+	store := NewReadOnlyRepairStore(path)
+
+	require.NoError(t, store.Add(entity.RepairRun{Id: "20211022T150100Z"}))
+
+	runs, err := store.List()
+	require.NoError(t, err)
+	require.Empty(t, runs)
+	require.NoFileExists(t, path)
+}
@@ -0,0 +1,104 @@
+package history
+
+import (
+	"fmt"
+	"github.com/kolesa-team/scylla-octopus/pkg/entity"
+	"sync"
+	"time"
+)
+
+// RunStore keeps the history of operation runs (backups, repairs, etc.) in a local JSON file
+type RunStore struct {
+	path string
+	mu   sync.Mutex
+	// the history is not changed (e.g. in dry-run mode)
+	readOnly bool
+	// the number of the newest runs to keep (0 means no limit)
+	maxRuns int
+	// the runs started earlier than this are removed (0 means no limit)
+	maxAge time.Duration
+}
+
+// NewRunStore creates a store that keeps at most maxRuns runs not older than maxAge (0 means no limit)
+func NewRunStore(path string, maxRuns int, maxAge time.Duration) *RunStore {
+	return &RunStore{path: path, maxRuns: maxRuns, maxAge: maxAge}
+}
+
+// NewReadOnlyRunStore creates a store that reads the history, but ignores new runs (for dry-run mode)
+func NewReadOnlyRunStore(path string) *RunStore {
+	return &RunStore{path: path, readOnly: true}
+}
+
+// Add appends a run to the history and removes the runs beyond the limits.
+// A run gets a sequence suffix if its id is already taken (e.g. by another run started in the same second).
+func (s *RunStore) Add(run entity.OperationRun) error {
+	if s.readOnly {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := lockFile(s.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	runs := entity.RunHistory{}
+	err = readJsonFile(s.path, &runs)
+	if err != nil {
+		return err
+	}
+
+	run.Id = uniqueRunId(runs, run.Id)
+	runs = append(runs, run)
+
+	return writeJsonFile(s.path, s.prune(runs, time.Now()))
+}
+
+// List returns all runs, from the oldest to the newest
+func (s *RunStore) List() (entity.RunHistory, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	runs := entity.RunHistory{}
+	err := readJsonFile(s.path, &runs)
+
+	return runs, err
+}
+
+// returns the runs within the limits of the store: the newest ones that are not too old
+func (s *RunStore) prune(runs entity.RunHistory, now time.Time) entity.RunHistory {
+	if s.maxAge > 0 {
+		recentRuns := entity.RunHistory{}
+		for _, run := range runs {
+			if now.Sub(run.DateStarted) <= s.maxAge {
+				recentRuns = append(recentRuns, run)
+			}
+		}
+
+		runs = recentRuns
+	}
+
+	if s.maxRuns > 0 && len(runs) > s.maxRuns {
+		runs = runs[len(runs)-s.maxRuns:]
+	}
+
+	return runs
+}
+
+// returns an id that is not taken by any of the runs: a given one, or the one with the lowest sequence suffix
+func uniqueRunId(runs entity.RunHistory, id string) string {
+	taken := map[string]bool{}
+	for _, run := range runs {
+		taken[run.Id] = true
+	}
+
+	uniqueId := id
+	for sequence := 2; taken[uniqueId]; sequence++ {
+		uniqueId = fmt.Sprintf("%s-%d", id, sequence)
+	}
+
+	return uniqueId
+}
@@ -0,0 +1,124 @@
+package history
+
+import (
+	"fmt"
+	"github.com/kolesa-team/scylla-octopus/pkg/entity"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRunStore(t *testing.T) {
+	store := NewRunStore(filepath.Join(t.TempDir(), "history", "runs.json"), 0, 0)
+
+	runs, err := store.List()
+	require.NoError(t, err, "a missing history file is not an error")
+	require.Empty(t, runs)
+
+	dateStarted := time.Date(2021, 10, 22, 15, 1, 0, 0, time.UTC)
+	require.NoError(t, store.Add(entity.OperationRun{
+		Id:          entity.NewRunId("backup", dateStarted),
+		Operation:   "backup",
+		DateStarted: dateStarted,
+		TotalNodes:  2,
+		Hosts: []entity.HostRun{
+			{Host: "host-1", Duration: time.Minute, Bytes: 1024},
+			{Host: "host-2", Error: "could not create a snapshot"},
+		},
+		Error: "could not create a snapshot",
+	}))
+	require.NoError(t, store.Add(entity.OperationRun{
+		Id:          entity.NewRunId("repair", dateStarted),
+		Operation:   "repair",
+		DateStarted: dateStarted,
+	}))
+
+	runs, err = store.List()
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	require.Equal(t, "backup-20211022T150100Z", runs[0].Id)
+	require.Equal(t, int64(1024), runs[0].Hosts[0].Bytes)
+	require.Equal(t, "could not create a snapshot", runs[0].Hosts[1].Error)
+	require.Equal(t, "repair-20211022T150100Z", runs[1].Id)
+}
+
+// Runs started in the same second get unique ids
+func TestRunStore_SameId(t *testing.T) {
+	store := NewRunStore(filepath.Join(t.TempDir(), "runs.json"), 0, 0)
+	dateStarted := time.Date(2021, 10, 22, 15, 1, 0, 0, time.UTC)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, store.Add(entity.OperationRun{Id: entity.NewRunId("backup", dateStarted), Operation: "backup"}))
+	}
+
+	runs, err := store.List()
+	require.NoError(t, err)
+	require.Equal(t, "backup-20211022T150100Z", runs[0].Id)
+	require.Equal(t, "backup-20211022T150100Z-2", runs[1].Id)
+	require.Equal(t, "backup-20211022T150100Z-3", runs[2].Id)
+}
+
+// Only the newest runs within the limits are kept
+func TestRunStore_Retention(t *testing.T) {
+	store := NewRunStore(filepath.Join(t.TempDir(), "runs.json"), 3, time.Hour*24)
+	now := time.Now()
+
+	for i, age := range []time.Duration{time.Hour * 48, time.Hour * 4, time.Hour * 3, time.Hour * 2, time.Hour} {
+		require.NoError(t, store.Add(entity.OperationRun{
+			Id:          fmt.Sprintf("backup-%d", i),
+			DateStarted: now.Add(-age),
+		}))
+	}
+
+	runs, err := store.List()
+	require.NoError(t, err)
+	require.Len(t, runs, 3)
+	require.Equal(t, "backup-2", runs[0].Id)
+	require.Equal(t, "backup-4", runs[2].Id)
+
+	store = NewRunStore(store.path, 0, time.Hour*24)
+	require.NoError(t, store.Add(entity.OperationRun{Id: "backup-old", DateStarted: now.Add(-time.Hour * 25)}))
+
+	runs, err = store.List()
+	require.NoError(t, err)
+	require.Len(t, runs, 3, "a run older than the limit is not kept")
+}
+
+// Separate stores of the same file (like concurrent runs of the program) don't lose each other's runs
+func TestRunStore_ConcurrentStores(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "runs.json")
+	dateStarted := time.Date(2021, 10, 22, 15, 1, 0, 0, time.UTC)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			require.NoError(t, NewRunStore(path, 0, 0).Add(entity.OperationRun{
+				Id:          "backup-20211022T150100Z",
+				DateStarted: dateStarted,
+			}))
+		}()
+	}
+	wg.Wait()
+
+	runs, err := NewRunStore(path, 0, 0).List()
+	require.NoError(t, err)
+	require.Len(t, runs, 20)
+	require.Equal(t, "backup-20211022T150100Z-20", runs[19].Id)
+}
+
+// A read-only store doesn't record new runs
+func TestRunStore_ReadOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "runs.json")
+	store := NewReadOnlyRunStore(path)
+
+	require.NoError(t, store.Add(entity.OperationRun{Id: "backup-20211022T150100Z"}))
+
+	runs, err := store.List()
+	require.NoError(t, err)
+	require.Empty(t, runs)
+	require.NoFileExists(t, path)
+}
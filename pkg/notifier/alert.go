@@ -0,0 +1,57 @@
+package notifier
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Alerting channels (PagerDuty, Opsgenie) open an alert when an operation fails,
+// and close it when the same operation succeeds again.
+// An alert is identified by a cluster and an operation, so repeated failures don't open new alerts.
+
+// the operations that alert on-call by default
+var alertOperations = []Operation{OperationBackup, OperationRepair, OperationHealthcheck, OperationFreshness}
+
+// returns the routing options of an alerting channel: the default operations, unless configured otherwise.
+// A success is only sent to resolve an alert after an error, so the minimum severity does not apply to it.
+func alertRouteOptions(options RouteOptions) RouteOptions {
+	if len(options.Operations) == 0 {
+		options.Operations = alertOperations
+	}
+
+	options.resolvesAlerts = true
+
+	return options
+}
+
+// returns a stable key of an alert about an operation in a cluster
+func alertKey(cluster string, operation Operation) string {
+	return fmt.Sprintf("scylla-octopus:%s:%s", cluster, operation)
+}
+
+// whether a message opens an alert; any other message (info or warning) means the operation succeeded and closes it
+func isAlert(msg Message) bool {
+	return msg.Severity >= SeverityError
+}
+
+// returns a one-line alert summary
+func alertSummary(cluster string, msg Message) string {
+	return fmt.Sprintf("scylla-octopus [%s] %s: %s", cluster, msg.Operation, msg.Header)
+}
+
+// returns the details of an alert: a body or an error, and extra data
+func alertDetails(msg Message) string {
+	lines := []string{}
+
+	if msg.showError() {
+		lines = append(lines, "Error:", msg.Err.Error())
+	} else if len(msg.Body) > 0 {
+		lines = append(lines, msg.Body)
+	}
+
+	if len(msg.Data) > 0 {
+		lines = append(lines, "", dataJson(msg.Data))
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -4,8 +4,5 @@ package notifier
 type Disabled struct {
 }
 
-func (d Disabled) Info(header, body string, data map[string]interface{}) {
-}
-
-func (d Disabled) Error(header, body string, err error, data map[string]interface{}) {
+func (d Disabled) Notify(msg Message) {
 }
@@ -0,0 +1,297 @@
+package notifier
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Email is an implementation of Notifier that sends multipart (text and HTML) emails over SMTP
+type Email struct {
+	options   EmailOptions
+	tlsConfig *tls.Config
+	logger    *zap.SugaredLogger
+}
+
+// the ways to secure an SMTP connection
+const (
+	// upgrade a plain connection with STARTTLS (usually port 587)
+	EmailSecurityStartTLS = "starttls"
+	// connect over TLS (usually port 465)
+	EmailSecurityTLS = "tls"
+	// don't encrypt the connection (e.g. a local relay)
+	EmailSecurityNone = "none"
+)
+
+type EmailOptions struct {
+	Host string
+	// defaults to 587 for starttls, 465 for tls, and 25 otherwise
+	Port int
+	// SMTP credentials; no authentication if empty
+	Username string
+	Password string
+	// the addresses may have display names, e.g. "Scylla Octopus <octopus@example.com>"
+	From string
+	To   []string
+	// starttls (default), tls or none
+	Security string
+	// do not verify the certificate of SMTP server (for test environments only)
+	InsecureSkipVerify bool `yaml:"insecureSkipVerify"`
+	// a timeout to send a message. defaults to 30s
+	Timeout      time.Duration
+	RouteOptions `yaml:",inline"`
+}
+
+func NewEmail(options EmailOptions, logger *zap.SugaredLogger) *Email {
+	if len(options.Security) == 0 {
+		options.Security = EmailSecurityStartTLS
+	}
+
+	if options.Port == 0 {
+		switch options.Security {
+		case EmailSecurityStartTLS:
+			options.Port = 587
+		case EmailSecurityTLS:
+			options.Port = 465
+		default:
+			options.Port = 25
+		}
+	}
+
+	if options.Timeout <= 0 {
+		options.Timeout = time.Second * 30
+	}
+
+	return &Email{
+		options: options,
+		tlsConfig: &tls.Config{
+			ServerName:         options.Host,
+			InsecureSkipVerify: options.InsecureSkipVerify,
+		},
+		logger: logger.Named("email-notifier"),
+	}
+}
+
+// Notify sends a message; the subject contains its severity and header
+func (e *Email) Notify(msg Message) {
+	subject := fmt.Sprintf("[scylla-octopus] %s: %s", strings.ToUpper(msg.Severity.String()), msg.Header)
+
+	addresses, err := parseEmailAddresses(e.options.From, e.options.To)
+	if err == nil {
+		var email []byte
+		email, err = e.getMessage(subject, msg, addresses)
+		if err == nil {
+			err = e.sendMail(email, addresses)
+		}
+	}
+
+	if err != nil {
+		e.logger.Warnw("could not send email", "subject", subject, "error", err)
+	}
+}
+
+// the sender and recipients of an email
+type emailAddresses struct {
+	from *mail.Address
+	to   []*mail.Address
+}
+
+// parses the sender and recipients: the bare addresses are used in SMTP commands, and the formatted ones in headers
+func parseEmailAddresses(from string, to []string) (emailAddresses, error) {
+	addresses := emailAddresses{}
+
+	var err error
+	addresses.from, err = mail.ParseAddress(from)
+	if err != nil {
+		return addresses, errors.Wrapf(err, "invalid sender address %q", from)
+	}
+
+	for _, recipient := range to {
+		address, err := mail.ParseAddress(recipient)
+		if err != nil {
+			return addresses, errors.Wrapf(err, "invalid recipient address %q", recipient)
+		}
+
+		addresses.to = append(addresses.to, address)
+	}
+
+	return addresses, nil
+}
+
+// Creates an email with text and HTML alternatives
+func (e *Email) getMessage(subject string, msg Message, addresses emailAddresses) ([]byte, error) {
+	var parts bytes.Buffer
+	writer := multipart.NewWriter(&parts)
+
+	alternatives := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain", textMessage(msg)},
+		{
+			"text/html",
+			fmt.Sprintf(
+				"<html><body><div style=\"white-space: pre-wrap\">%s</div></body></html>",
+				htmlMessage(msg),
+			),
+		},
+	}
+
+	for _, alternative := range alternatives {
+		part, writeErr := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {alternative.contentType + "; charset=utf-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if writeErr != nil {
+			return nil, writeErr
+		}
+
+		encoder := quotedprintable.NewWriter(part)
+		_, writeErr = encoder.Write([]byte(alternative.content))
+		if writeErr != nil {
+			return nil, writeErr
+		}
+
+		writeErr = encoder.Close()
+		if writeErr != nil {
+			return nil, writeErr
+		}
+	}
+
+	writeErr := writer.Close()
+	if writeErr != nil {
+		return nil, writeErr
+	}
+
+	recipients := make([]string, len(addresses.to))
+	for i, address := range addresses.to {
+		recipients[i] = address.String()
+	}
+
+	var email bytes.Buffer
+	headers := [][2]string{
+		{"From", addresses.from.String()},
+		{"To", strings.Join(recipients, ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "multipart/alternative; boundary=" + writer.Boundary()},
+	}
+
+	for _, header := range headers {
+		email.WriteString(header[0] + ": " + header[1] + "\r\n")
+	}
+
+	email.WriteString("\r\n")
+	email.Write(parts.Bytes())
+
+	return email.Bytes(), nil
+}
+
+// sends a message to all recipients
+func (e *Email) sendMail(msg []byte, addresses emailAddresses) error {
+	addr := net.JoinHostPort(e.options.Host, strconv.Itoa(e.options.Port))
+	dialer := &net.Dialer{Timeout: e.options.Timeout}
+
+	var conn net.Conn
+	var err error
+
+	if e.options.Security == EmailSecurityTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, e.tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "could not connect to SMTP server %s", addr)
+	}
+
+	_ = conn.SetDeadline(time.Now().Add(e.options.Timeout))
+
+	client, err := smtp.NewClient(conn, e.options.Host)
+	if err != nil {
+		_ = conn.Close()
+		return errors.Wrapf(err, "could not connect to SMTP server %s", addr)
+	}
+	defer client.Close()
+
+	if e.options.Security == EmailSecurityStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("SMTP server %s does not support STARTTLS", addr)
+		}
+
+		err = client.StartTLS(e.tlsConfig)
+		if err != nil {
+			return errors.Wrap(err, "could not start TLS")
+		}
+	}
+
+	if len(e.options.Username) > 0 {
+		err = client.Auth(smtp.PlainAuth("", e.options.Username, e.options.Password, e.options.Host))
+		if err != nil {
+			return errors.Wrap(err, "SMTP authentication failed")
+		}
+	}
+
+	err = client.Mail(addresses.from.Address)
+	if err != nil {
+		return errors.Wrapf(err, "SMTP server rejected sender %s", addresses.from.Address)
+	}
+
+	for _, recipient := range addresses.to {
+		err = client.Rcpt(recipient.Address)
+		if err != nil {
+			return errors.Wrapf(err, "SMTP server rejected recipient %s", recipient.Address)
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+
+	_, err = writer.Write(msg)
+	if err != nil {
+		return err
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return errors.Wrap(err, "SMTP server did not accept the message")
+	}
+
+	return client.Quit()
+}
+
+// Creates a plain text notification message
+func textMessage(msg Message) string {
+	lines := []string{title + msg.mark(), ""}
+
+	if len(msg.Header) > 0 {
+		lines = append(lines, msg.Header, "")
+	}
+
+	if msg.showError() {
+		lines = append(lines, "Error:", msg.Err.Error())
+	} else {
+		lines = append(lines, msg.Body)
+	}
+
+	if len(msg.Data) > 0 {
+		lines = append(lines, "", dataJson(msg.Data))
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,327 @@
+package notifier
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// an email received by a test SMTP server
+type testMail struct {
+	From string
+	To   []string
+	// decoded AUTH PLAIN credentials
+	Auth string
+	// whether the message was received over TLS
+	TLS  bool
+	Data string
+}
+
+// a minimal in-process SMTP server
+type testSmtpServer struct {
+	listener    net.Listener
+	tlsConfig   *tls.Config
+	implicitTLS bool
+	mu          sync.Mutex
+	mails       []testMail
+}
+
+// starts an SMTP server; returns the server and a client TLS config that trusts its certificate
+func newTestSmtpServer(t *testing.T, implicitTLS bool) (*testSmtpServer, *tls.Config) {
+	// httptest has a certificate for 127.0.0.1, and a client trusting it
+	httpServer := httptest.NewTLSServer(http.NotFoundHandler())
+	serverTLS := &tls.Config{Certificates: httpServer.TLS.Certificates}
+	clientTLS := &tls.Config{
+		ServerName: "127.0.0.1",
+		RootCAs:    httpServer.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs,
+	}
+	httpServer.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	server := &testSmtpServer{listener: listener, tlsConfig: serverTLS, implicitTLS: implicitTLS}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go server.serve(conn)
+		}
+	}()
+
+	return server, clientTLS
+}
+
+func (s *testSmtpServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *testSmtpServer) received() []testMail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]testMail{}, s.mails...)
+}
+
+func (s *testSmtpServer) serve(conn net.Conn) {
+	mail := testMail{}
+	if s.implicitTLS {
+		conn = tls.Server(conn, s.tlsConfig)
+		mail.TLS = true
+	}
+	defer conn.Close()
+
+	reader := textproto.NewReader(bufio.NewReader(conn))
+	reply := func(line string) {
+		_, _ = io.WriteString(conn, line+"\r\n")
+	}
+
+	reply("220 test ESMTP")
+
+	for {
+		line, err := reader.ReadLine()
+		if err != nil {
+			return
+		}
+
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch command {
+		case "EHLO", "HELO":
+			reply("250-test")
+			if !mail.TLS {
+				reply("250-STARTTLS")
+			}
+			reply("250 AUTH PLAIN")
+		case "STARTTLS":
+			reply("220 ready")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if tlsConn.Handshake() != nil {
+				return
+			}
+
+			conn = tlsConn
+			reader = textproto.NewReader(bufio.NewReader(conn))
+			mail.TLS = true
+		case "AUTH":
+			credentials, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(line, "AUTH PLAIN "))
+			mail.Auth = string(credentials)
+			reply("235 authenticated")
+		case "MAIL":
+			mail.From = strings.Trim(strings.TrimPrefix(line, "MAIL FROM:"), "<>")
+			reply("250 ok")
+		case "RCPT":
+			mail.To = append(mail.To, strings.Trim(strings.TrimPrefix(line, "RCPT TO:"), "<>"))
+			reply("250 ok")
+		case "DATA":
+			reply("354 go ahead")
+			data, err := reader.ReadDotBytes()
+			if err != nil {
+				return
+			}
+
+			mail.Data = string(data)
+			s.mu.Lock()
+			s.mails = append(s.mails, mail)
+			s.mu.Unlock()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+// returns a decoded subject and the text and HTML parts of a message
+func parseTestMail(t *testing.T, data string) (string, map[string]string) {
+	msg, err := mail.ReadMessage(strings.NewReader(data))
+	require.NoError(t, err)
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	require.Equal(t, "multipart/alternative", mediaType)
+
+	parts := map[string]string{}
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+
+		content, err := io.ReadAll(part)
+		require.NoError(t, err)
+
+		partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		parts[partType] = string(content)
+	}
+
+	return subject, parts
+}
+
+func TestEmail_StartTLS(t *testing.T) {
+	server, clientTLS := newTestSmtpServer(t, false)
+	email := NewEmail(EmailOptions{
+		Host:     "127.0.0.1",
+		Port:     server.port(),
+		Username: "octopus",
+		Password: "secret",
+		From:     "octopus@example.com",
+		To:       []string{"dba@example.com", "ops@example.com"},
+	}, zap.S())
+	email.tlsConfig = clientTLS
+
+	email.Notify(Message{
+		Operation: OperationBackup,
+		Severity:  SeverityError,
+		Header:    "Could not back up cluster nodes",
+		Body:      "Total nodes: 3\nnode <1>: failed",
+		Err:       errors.New("node is down"),
+	})
+
+	mails := server.received()
+	require.Len(t, mails, 1)
+	require.True(t, mails[0].TLS, "the connection must be upgraded with STARTTLS")
+	require.Equal(t, "\x00octopus\x00secret", mails[0].Auth)
+	require.Equal(t, "octopus@example.com", mails[0].From)
+	require.Equal(t, []string{"dba@example.com", "ops@example.com"}, mails[0].To)
+
+	subject, parts := parseTestMail(t, mails[0].Data)
+	require.Equal(t, "[scylla-octopus] ERROR: Could not back up cluster nodes", subject)
+	require.Equal(
+		t,
+		"🐙 scylla-octopus 🔥\n\nCould not back up cluster nodes\n\nTotal nodes: 3\nnode <1>: failed",
+		parts["text/plain"],
+	)
+	require.Contains(t, parts["text/html"], "<b>Could not back up cluster nodes</b>")
+	require.Contains(t, parts["text/html"], "node &lt;1&gt;: failed")
+}
+
+func TestEmail_TLS(t *testing.T) {
+	server, clientTLS := newTestSmtpServer(t, true)
+	email := NewEmail(EmailOptions{
+		Host:     "127.0.0.1",
+		Port:     server.port(),
+		Security: EmailSecurityTLS,
+		From:     "octopus@example.com",
+		To:       []string{"dba@example.com"},
+	}, zap.S())
+	email.tlsConfig = clientTLS
+
+	email.Notify(Message{
+		Operation: OperationBackup,
+		Header:    "Backup completed successfully",
+		Body:      "Total nodes: 3",
+		Data:      map[string]interface{}{"cluster": "test"},
+	})
+
+	mails := server.received()
+	require.Len(t, mails, 1)
+	require.True(t, mails[0].TLS)
+	require.Empty(t, mails[0].Auth, "there are no credentials")
+
+	subject, parts := parseTestMail(t, mails[0].Data)
+	require.Equal(t, "[scylla-octopus] INFO: Backup completed successfully", subject)
+	require.Contains(t, parts["text/plain"], "\"cluster\": \"test\"")
+}
+
+// An unencrypted connection is not upgraded
+func TestEmail_NoTLS(t *testing.T) {
+	server, _ := newTestSmtpServer(t, false)
+	email := NewEmail(EmailOptions{
+		Host:     "127.0.0.1",
+		Port:     server.port(),
+		Security: EmailSecurityNone,
+		From:     "octopus@example.com",
+		To:       []string{"dba@example.com"},
+	}, zap.S())
+
+	email.Notify(Message{
+		Operation: OperationBackup,
+		Header:    "Backup completed successfully",
+		Body:      "Total nodes: 3",
+	})
+
+	mails := server.received()
+	require.Len(t, mails, 1)
+	require.False(t, mails[0].TLS)
+}
+
+// The addresses with display names are sent as bare addresses in SMTP commands, and formatted in headers
+func TestEmail_DisplayNames(t *testing.T) {
+	server, _ := newTestSmtpServer(t, false)
+	email := NewEmail(EmailOptions{
+		Host:     "127.0.0.1",
+		Port:     server.port(),
+		Security: EmailSecurityNone,
+		From:     "Scylla Octopus <octopus@example.com>",
+		To:       []string{"DBA <dba@example.com>", "ops@example.com"},
+	}, zap.S())
+
+	email.Notify(Message{
+		Operation: OperationBackup,
+		Header:    "Backup completed successfully",
+	})
+
+	mails := server.received()
+	require.Len(t, mails, 1)
+	require.Equal(t, "octopus@example.com", mails[0].From)
+	require.Equal(t, []string{"dba@example.com", "ops@example.com"}, mails[0].To)
+
+	msg, err := mail.ReadMessage(strings.NewReader(mails[0].Data))
+	require.NoError(t, err)
+	require.Equal(t, `"Scylla Octopus" <octopus@example.com>`, msg.Header.Get("From"))
+	require.Equal(t, `"DBA" <dba@example.com>, <ops@example.com>`, msg.Header.Get("To"))
+}
+
+// A message is not sent to an invalid address
+func TestEmail_InvalidAddress(t *testing.T) {
+	server, _ := newTestSmtpServer(t, false)
+	email := NewEmail(EmailOptions{
+		Host:     "127.0.0.1",
+		Port:     server.port(),
+		Security: EmailSecurityNone,
+		From:     "octopus@example.com",
+		To:       []string{"dba@example.com, ops@example.com"},
+	}, zap.S())
+
+	email.Notify(Message{Header: "Backup completed successfully"})
+
+	require.Empty(t, server.received())
+}
+
+func TestNewEmail_DefaultPort(t *testing.T) {
+	for security, port := range map[string]int{
+		"":                    587,
+		EmailSecurityStartTLS: 587,
+		EmailSecurityTLS:      465,
+		EmailSecurityNone:     25,
+	} {
+		email := NewEmail(EmailOptions{Security: security}, zap.S())
+		require.Equal(t, port, email.options.Port, "security: "+strconv.Quote(security))
+	}
+}
@@ -0,0 +1,93 @@
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// the prefix of all notifications
+const title = "🐙 scylla-octopus"
+
+func newHttpClient() *http.Client {
+	return &http.Client{
+		Timeout: time.Second * 10,
+	}
+}
+
+// sends a JSON payload to a given URL
+func postJson(httpClient *http.Client, url string, payload interface{}) error {
+	return postJsonWithHeaders(httpClient, url, payload, nil)
+}
+
+// sends a JSON payload with extra headers (e.g. authorization) to a given URL
+func postJsonWithHeaders(httpClient *http.Client, url string, payload interface{}, headers map[string]string) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return errors.Wrap(err, "could not encode a message")
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return errors.Wrap(err, "could not create a request")
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "could not send a message")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// returns extra notification data as indented JSON
+func dataJson(data map[string]interface{}) string {
+	dataJsonBytes, _ := json.MarshalIndent(data, "", "  ")
+
+	return string(dataJsonBytes)
+}
+
+// cuts a string to a given number of characters, so that it fits into a message size limit
+func truncate(value string, maxLength int) string {
+	runes := []rune(value)
+	if len(runes) <= maxLength {
+		return value
+	}
+
+	return string(runes[:maxLength-1]) + "…"
+}
+
+// escapes a text and cuts the result to a given number of characters,
+// so that an escaped character is not cut in the middle
+func truncateEscaped(text string, maxLength int, escape func(string) string) string {
+	escaped := escape(text)
+	if len([]rune(escaped)) <= maxLength {
+		return escaped
+	}
+
+	return trimIncompleteMarkup(string([]rune(escaped)[:maxLength-1])) + "…"
+}
+
+// removes an HTML tag or an entity that is cut at the end of a text
+func trimIncompleteMarkup(text string) string {
+	i := strings.LastIndexAny(text, "<&")
+	if i >= 0 && !strings.ContainsAny(text[i:], ">;") {
+		return text[:i]
+	}
+
+	return text
+}
@@ -0,0 +1,81 @@
+package notifier
+
+import (
+	"fmt"
+	"go.uber.org/zap"
+	"net/http"
+	"strings"
+)
+
+// Mattermost is an implementation of Notifier that sends Markdown messages to a Mattermost incoming webhook
+type Mattermost struct {
+	options    MattermostOptions
+	httpClient *http.Client
+	logger     *zap.SugaredLogger
+}
+
+type MattermostOptions struct {
+	// an incoming webhook URL, e.g. https://mattermost.example.com/hooks/...
+	Url string
+	// overrides the default channel of a webhook
+	Channel string
+	// overrides the default username of a webhook
+	Username     string
+	RouteOptions `yaml:",inline"`
+}
+
+// mattermost limits a post to 16383 characters
+const mattermostMaxBodyLength = 15000
+
+type mattermostMessage struct {
+	Text     string `json:"text"`
+	Channel  string `json:"channel,omitempty"`
+	Username string `json:"username,omitempty"`
+}
+
+func NewMattermost(options MattermostOptions, logger *zap.SugaredLogger) *Mattermost {
+	return &Mattermost{
+		options:    options,
+		httpClient: newHttpClient(),
+		logger:     logger.Named("mattermost-notifier"),
+	}
+}
+
+// Notify sends a message
+func (m *Mattermost) Notify(msg Message) {
+	mattermostMsg := m.getMessage(msg)
+	err := postJson(m.httpClient, m.options.Url, mattermostMsg)
+	if err != nil {
+		m.logger.Warnw("could not send message to mattermost", "message", mattermostMsg.Text, "error", err)
+	}
+}
+
+// Creates a notification message in Markdown
+func (m *Mattermost) getMessage(msg Message) mattermostMessage {
+	lines := []string{"🐙 **scylla-octopus**" + msg.mark(), ""}
+
+	if len(msg.Header) > 0 {
+		lines = append(lines, "#### "+msg.Header, "")
+	}
+
+	if msg.showError() {
+		lines = append(lines, "Error:", markdownCode(msg.Err.Error()))
+	} else if len(msg.Body) > 0 {
+		lines = append(lines, markdownCode(truncate(msg.Body, mattermostMaxBodyLength)))
+	}
+
+	if len(msg.Data) > 0 {
+		lines = append(lines, "", markdownCode(dataJson(msg.Data)))
+	}
+
+	return mattermostMessage{
+		Text:     strings.Join(lines, "\n"),
+		Channel:  m.options.Channel,
+		Username: m.options.Username,
+	}
+}
+
+// returns a Markdown code block; a code fence inside the text would end the block, so it's replaced
+func markdownCode(text string) string {
+	return fmt.Sprintf("```\n%s\n```", strings.ReplaceAll(text, "```", "'''"))
+}
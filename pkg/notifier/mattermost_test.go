@@ -0,0 +1,40 @@
+package notifier
+
+import (
+	"errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"net/http"
+	"testing"
+)
+
+func TestMattermost(t *testing.T) {
+	server, requests := newTestServer(t, http.StatusOK)
+	mattermost := NewMattermost(MattermostOptions{Url: server.URL, Channel: "dba"}, zap.S())
+
+	mattermost.Notify(Message{
+		Operation: OperationBackup,
+		Header:    "Backup completed successfully",
+		Body:      "Total nodes: 3",
+		Data:      map[string]interface{}{"cluster": "test"},
+	})
+	mattermost.Notify(Message{
+		Operation: OperationBackup,
+		Severity:  SeverityError,
+		Header:    "Could not back up cluster nodes",
+		Err:       errors.New("node is down"),
+	})
+
+	received := requests()
+	require.Len(t, received, 2)
+	require.Equal(t, map[string]interface{}{
+		"channel": "dba",
+		"text": "🐙 **scylla-octopus**\n\n#### Backup completed successfully\n\n```\nTotal nodes: 3\n```\n\n" +
+			"```\n{\n  \"cluster\": \"test\"\n}\n```",
+	}, received[0].Body)
+	require.Equal(
+		t,
+		"🐙 **scylla-octopus** 🔥\n\n#### Could not back up cluster nodes\n\nError:\n```\nnode is down\n```",
+		received[1].Body["text"],
+	)
+}
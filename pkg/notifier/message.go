@@ -0,0 +1,88 @@
+package notifier
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity of a notification
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	// a partial failure, e.g. a backup that succeeded, but the expired backups were not removed
+	SeverityWarning
+	SeverityError
+)
+
+var severityNames = map[Severity]string{
+	SeverityInfo:    "info",
+	SeverityWarning: "warning",
+	SeverityError:   "error",
+}
+
+func (s Severity) String() string {
+	return severityNames[s]
+}
+
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText parses a severity name (info, warning or error); used in configuration and state files
+func (s *Severity) UnmarshalText(text []byte) error {
+	for severity, name := range severityNames {
+		if strings.EqualFold(name, string(text)) {
+			*s = severity
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown notification severity: %s (expected info, warning or error)", string(text))
+}
+
+// Operation that a notification is about
+type Operation string
+
+const (
+	OperationBackup      Operation = "backup"
+	OperationCleanup     Operation = "cleanup"
+	OperationRepair      Operation = "repair"
+	OperationHealthcheck Operation = "healthcheck"
+	OperationMaintenance Operation = "maintenance"
+	OperationRestart     Operation = "restart"
+	// a check that every node has a recent backup
+	OperationFreshness Operation = "freshness"
+	// a failure to start the program (e.g. a configuration error)
+	OperationStartup Operation = "startup"
+)
+
+// Message is a notification
+type Message struct {
+	Operation Operation
+	Severity  Severity
+	Header    string
+	Body      string
+	// an error to show if there is no body
+	Err  error
+	Data map[string]interface{}
+	// the results of an operation (e.g. entity.BackupResults), sent in structured webhook payloads
+	Result interface{}
+}
+
+// returns an emoji that marks the severity of a message
+func (m Message) mark() string {
+	switch m.Severity {
+	case SeverityError:
+		return " 🔥" // an error needs some fire
+	case SeverityWarning:
+		return " ⚠️"
+	default:
+		return ""
+	}
+}
+
+// whether an error must be shown instead of the body
+func (m Message) showError() bool {
+	return m.Err != nil && len(m.Body) == 0
+}
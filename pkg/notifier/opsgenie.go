@@ -0,0 +1,103 @@
+package notifier
+
+import (
+	"go.uber.org/zap"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Opsgenie is an implementation of Notifier that creates and closes Opsgenie alerts
+type Opsgenie struct {
+	options    OpsgenieOptions
+	cluster    string
+	httpClient *http.Client
+	logger     *zap.SugaredLogger
+}
+
+type OpsgenieOptions struct {
+	// an API key of an Opsgenie integration
+	ApiKey string `yaml:"apiKey"`
+	// defaults to https://api.opsgenie.com (https://api.eu.opsgenie.com in the EU)
+	ApiUrl string `yaml:"apiUrl"`
+	// the priority of alerts: P1..P5. defaults to P2
+	Priority string
+	Tags     []string
+	// the operations default to backup, repair, healthcheck and freshness
+	RouteOptions `yaml:",inline"`
+}
+
+const opsgenieDefaultApiUrl = "https://api.opsgenie.com"
+
+// opsgenie limits a message to 130 characters, and a description to 15000
+const (
+	opsgenieMaxMessageLength     = 130
+	opsgenieMaxDescriptionLength = 15000
+)
+
+type opsgenieAlert struct {
+	Message     string   `json:"message"`
+	Alias       string   `json:"alias"`
+	Description string   `json:"description,omitempty"`
+	Priority    string   `json:"priority"`
+	Source      string   `json:"source"`
+	Entity      string   `json:"entity"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+type opsgenieClose struct {
+	Source string `json:"source"`
+	Note   string `json:"note"`
+}
+
+func NewOpsgenie(options OpsgenieOptions, cluster string, logger *zap.SugaredLogger) *Opsgenie {
+	if len(options.ApiUrl) == 0 {
+		options.ApiUrl = opsgenieDefaultApiUrl
+	}
+
+	if len(options.Priority) == 0 {
+		options.Priority = "P2"
+	}
+
+	return &Opsgenie{
+		options:    options,
+		cluster:    cluster,
+		httpClient: newHttpClient(),
+		logger:     logger.Named("opsgenie-notifier"),
+	}
+}
+
+// Notify creates an alert on error, and closes it otherwise
+func (o *Opsgenie) Notify(msg Message) {
+	var err error
+	alias := alertKey(o.cluster, msg.Operation)
+	apiUrl := strings.TrimRight(o.options.ApiUrl, "/") + "/v2/alerts"
+	headers := map[string]string{"Authorization": "GenieKey " + o.options.ApiKey}
+
+	if isAlert(msg) {
+		err = postJsonWithHeaders(o.httpClient, apiUrl, o.getAlert(msg), headers)
+	} else {
+		err = postJsonWithHeaders(
+			o.httpClient,
+			apiUrl+"/"+url.PathEscape(alias)+"/close?identifierType=alias",
+			opsgenieClose{Source: "scylla-octopus", Note: msg.Header},
+			headers,
+		)
+	}
+
+	if err != nil {
+		o.logger.Warnw("could not send alert to opsgenie", "alias", alias, "error", err)
+	}
+}
+
+func (o *Opsgenie) getAlert(msg Message) opsgenieAlert {
+	return opsgenieAlert{
+		Message:     truncate(alertSummary(o.cluster, msg), opsgenieMaxMessageLength),
+		Alias:       alertKey(o.cluster, msg.Operation),
+		Description: truncate(alertDetails(msg), opsgenieMaxDescriptionLength),
+		Priority:    o.options.Priority,
+		Source:      "scylla-octopus",
+		Entity:      o.cluster,
+		Tags:        append([]string{"scylla-octopus", string(msg.Operation)}, o.options.Tags...),
+	}
+}
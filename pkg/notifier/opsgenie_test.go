@@ -0,0 +1,44 @@
+package notifier
+
+import (
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"net/http"
+	"testing"
+)
+
+// A failure creates an alert, and the next success closes it by alias
+func TestOpsgenie_Notify(t *testing.T) {
+	server, requests := newTestServer(t, http.StatusAccepted)
+	opsgenie := NewOpsgenie(OpsgenieOptions{ApiKey: "api-key", ApiUrl: server.URL + "/", Tags: []string{"dba"}}, "main", zap.S())
+
+	opsgenie.Notify(Message{
+		Operation: OperationHealthcheck,
+		Severity:  SeverityError,
+		Header:    "Could not perform a healthcheck before creating backups.",
+		Body:      "node 127.0.0.1 is down",
+	})
+	opsgenie.Notify(Message{
+		Operation: OperationHealthcheck,
+		Severity:  SeverityInfo,
+		Header:    "Healthcheck passed before creating backups.",
+	})
+
+	require.Len(t, requests(), 2)
+
+	alert := requests()[0]
+	require.Equal(t, "/v2/alerts", alert.Path)
+	require.Equal(t, "GenieKey api-key", alert.Header.Get("Authorization"))
+	require.Equal(t, "scylla-octopus:main:healthcheck", alert.Body["alias"])
+	require.Equal(t, "P2", alert.Body["priority"])
+	require.Equal(t, "main", alert.Body["entity"])
+	require.Equal(t, "node 127.0.0.1 is down", alert.Body["description"])
+	require.Equal(t, []interface{}{"scylla-octopus", "healthcheck", "dba"}, alert.Body["tags"])
+	require.LessOrEqual(t, len([]rune(alert.Body["message"].(string))), opsgenieMaxMessageLength)
+
+	closeRequest := requests()[1]
+	require.Equal(t, "/v2/alerts/scylla-octopus:main:healthcheck/close", closeRequest.Path)
+	require.Equal(t, "identifierType=alias", closeRequest.Query)
+	require.Equal(t, "GenieKey api-key", closeRequest.Header.Get("Authorization"))
+	require.Equal(t, "Healthcheck passed before creating backups.", closeRequest.Body["note"])
+}
@@ -0,0 +1,103 @@
+package notifier
+
+import (
+	"go.uber.org/zap"
+	"net/http"
+)
+
+// PagerDuty is an implementation of Notifier that triggers and resolves incidents with PagerDuty Events API v2
+type PagerDuty struct {
+	options    PagerDutyOptions
+	cluster    string
+	httpClient *http.Client
+	logger     *zap.SugaredLogger
+}
+
+type PagerDutyOptions struct {
+	// an integration key of a PagerDuty service
+	RoutingKey string `yaml:"routingKey"`
+	// defaults to https://events.pagerduty.com/v2/enqueue
+	ApiUrl string `yaml:"apiUrl"`
+	// the severity of incidents: critical, error (default), warning or info
+	Severity string
+	// the operations default to backup, repair, healthcheck and freshness
+	RouteOptions `yaml:",inline"`
+}
+
+const pagerDutyDefaultApiUrl = "https://events.pagerduty.com/v2/enqueue"
+
+// pagerduty limits a summary to 1024 characters
+const pagerDutyMaxSummaryLength = 1024
+
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string                 `json:"summary"`
+	Source        string                 `json:"source"`
+	Severity      string                 `json:"severity"`
+	Component     string                 `json:"component"`
+	Group         string                 `json:"group"`
+	CustomDetails map[string]interface{} `json:"custom_details,omitempty"`
+}
+
+func NewPagerDuty(options PagerDutyOptions, cluster string, logger *zap.SugaredLogger) *PagerDuty {
+	if len(options.ApiUrl) == 0 {
+		options.ApiUrl = pagerDutyDefaultApiUrl
+	}
+
+	if len(options.Severity) == 0 {
+		options.Severity = "error"
+	}
+
+	return &PagerDuty{
+		options:    options,
+		cluster:    cluster,
+		httpClient: newHttpClient(),
+		logger:     logger.Named("pagerduty-notifier"),
+	}
+}
+
+// Notify triggers an incident on error, and resolves it otherwise
+func (p *PagerDuty) Notify(msg Message) {
+	event := p.getEvent(msg)
+	err := postJson(p.httpClient, p.options.ApiUrl, event)
+	if err != nil {
+		p.logger.Warnw(
+			"could not send event to pagerduty",
+			"action", event.EventAction,
+			"dedupKey", event.DedupKey,
+			"error", err,
+		)
+	}
+}
+
+func (p *PagerDuty) getEvent(msg Message) pagerDutyEvent {
+	event := pagerDutyEvent{
+		RoutingKey:  p.options.RoutingKey,
+		EventAction: "resolve",
+		DedupKey:    alertKey(p.cluster, msg.Operation),
+	}
+
+	if !isAlert(msg) {
+		return event
+	}
+
+	event.EventAction = "trigger"
+	event.Payload = &pagerDutyPayload{
+		Summary:   truncate(alertSummary(p.cluster, msg), pagerDutyMaxSummaryLength),
+		Source:    p.cluster,
+		Severity:  p.options.Severity,
+		Component: "scylla-octopus",
+		Group:     string(msg.Operation),
+		CustomDetails: map[string]interface{}{
+			"details": alertDetails(msg),
+		},
+	}
+
+	return event
+}
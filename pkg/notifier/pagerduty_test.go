@@ -0,0 +1,56 @@
+package notifier
+
+import (
+	"errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"net/http"
+	"testing"
+)
+
+// A failure triggers an incident, and the next success resolves it with the same dedup key
+func TestPagerDuty_Notify(t *testing.T) {
+	server, requests := newTestServer(t, http.StatusAccepted)
+	pagerDuty := NewPagerDuty(PagerDutyOptions{RoutingKey: "routing-key", ApiUrl: server.URL + "/v2/enqueue"}, "main", zap.S())
+
+	pagerDuty.Notify(Message{
+		Operation: OperationBackup,
+		Severity:  SeverityError,
+		Header:    "Could not back up cluster nodes",
+		Err:       errors.New("test error"),
+	})
+	pagerDuty.Notify(Message{
+		Operation: OperationBackup,
+		Severity:  SeverityInfo,
+		Header:    "Backup completed successfully",
+	})
+
+	require.Len(t, requests(), 2)
+
+	trigger := requests()[0]
+	require.Equal(t, "/v2/enqueue", trigger.Path)
+	require.Equal(t, "routing-key", trigger.Body["routing_key"])
+	require.Equal(t, "trigger", trigger.Body["event_action"])
+	require.Equal(t, "scylla-octopus:main:backup", trigger.Body["dedup_key"])
+
+	payload := trigger.Body["payload"].(map[string]interface{})
+	require.Equal(t, "scylla-octopus [main] backup: Could not back up cluster nodes", payload["summary"])
+	require.Equal(t, "main", payload["source"])
+	require.Equal(t, "error", payload["severity"])
+	require.Equal(t, "backup", payload["group"])
+	require.Equal(t, "Error:\ntest error", payload["custom_details"].(map[string]interface{})["details"])
+
+	resolve := requests()[1]
+	require.Equal(t, "resolve", resolve.Body["event_action"])
+	require.Equal(t, "scylla-octopus:main:backup", resolve.Body["dedup_key"])
+	require.NotContains(t, resolve.Body, "payload")
+}
+
+func TestPagerDuty_Notify_Error(t *testing.T) {
+	server, requests := newTestServer(t, http.StatusBadRequest)
+	pagerDuty := NewPagerDuty(PagerDutyOptions{ApiUrl: server.URL}, "main", zap.S())
+
+	// an error is only logged
+	pagerDuty.Notify(Message{Operation: OperationRepair, Severity: SeverityError, Header: "Could not execute nodetool repair"})
+	require.Len(t, requests(), 1)
+}
@@ -0,0 +1,90 @@
+package notifier
+
+import (
+	"go.uber.org/zap"
+)
+
+// RouteOptions filter the notifications sent to a channel
+type RouteOptions struct {
+	// the minimum severity of notifications: info (default), warning or error
+	MinSeverity Severity `yaml:"minSeverity"`
+	// the operations to notify about (backup, cleanup, repair, healthcheck, maintenance, restart, freshness, startup).
+	// all operations if empty.
+	Operations []Operation
+	// only notify about a success if it ends a streak of warnings or errors
+	RecoveryOnly bool `yaml:"recoveryOnly"`
+	// a channel opens alerts on errors and resolves them (see alertRouteOptions)
+	resolvesAlerts bool
+}
+
+// whether a message must be sent to a channel, given the severity of the previous message of the same operation
+func (o RouteOptions) allows(msg Message, previous Severity) bool {
+	if o.resolvesAlerts && !isAlert(msg) {
+		// a success only resolves an alert opened by the previous error, regardless of the minimum severity
+		if previous < SeverityError {
+			return false
+		}
+	} else if msg.Severity < o.MinSeverity {
+		return false
+	}
+
+	if o.RecoveryOnly && msg.Severity == SeverityInfo && previous == SeverityInfo {
+		return false
+	}
+
+	if len(o.Operations) == 0 {
+		return true
+	}
+
+	for _, operation := range o.Operations {
+		if operation == msg.Operation {
+			return true
+		}
+	}
+
+	return false
+}
+
+type route struct {
+	notifier Notifier
+	options  RouteOptions
+}
+
+// Router is a notifier that sends every notification to all channels that accept it
+type Router struct {
+	routes []route
+	state  *stateStore
+	logger *zap.SugaredLogger
+}
+
+// NewRouter creates a router that keeps the state of notifications about a given cluster in a file
+func NewRouter(stateFile, cluster string, logger *zap.SugaredLogger) *Router {
+	return &Router{
+		state:  newStateStore(stateFile, cluster),
+		logger: logger,
+	}
+}
+
+// Add adds a channel with its routing options
+func (r *Router) Add(notifier Notifier, options RouteOptions) {
+	r.routes = append(r.routes, route{notifier: notifier, options: options})
+}
+
+// Notify sends a message to all channels that accept it
+func (r *Router) Notify(msg Message) {
+	previous, err := r.state.swap(msg.Operation, msg.Severity)
+	if err != nil {
+		r.logger.Warnw("could not update notification state", "error", err)
+	}
+
+	if msg.Operation == OperationHealthcheck && msg.Severity == SeverityInfo && previous == SeverityInfo {
+		// a passed healthcheck precedes every command, so it's only worth a notification after a failure
+		return
+	}
+
+	for _, route := range r.routes {
+		if route.options.allows(msg, previous) {
+			route.notifier.Notify(msg)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package notifier
+
+import (
+	"github.com/go-yaml/yaml"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// a notifier that remembers the received messages
+type testNotifier struct {
+	messages []Message
+}
+
+func (n *testNotifier) Notify(msg Message) {
+	n.messages = append(n.messages, msg)
+}
+
+// returns the headers of received messages
+func (n *testNotifier) headers() []string {
+	headers := []string{}
+	for _, msg := range n.messages {
+		headers = append(headers, msg.Header)
+	}
+
+	return headers
+}
+
+func TestRouter(t *testing.T) {
+	all := &testNotifier{}
+	errorsOnly := &testNotifier{}
+	repairWarnings := &testNotifier{}
+
+	router := NewRouter("", "cluster", zap.S())
+	router.Add(all, RouteOptions{})
+	router.Add(errorsOnly, RouteOptions{MinSeverity: SeverityError})
+	router.Add(repairWarnings, RouteOptions{
+		MinSeverity: SeverityWarning,
+		Operations:  []Operation{OperationRepair, OperationHealthcheck},
+	})
+
+	router.Notify(Message{Operation: OperationBackup, Severity: SeverityInfo, Header: "backup ok"})
+	router.Notify(Message{Operation: OperationBackup, Severity: SeverityWarning, Header: "backup warning"})
+	router.Notify(Message{Operation: OperationRepair, Severity: SeverityWarning, Header: "repair warning"})
+	router.Notify(Message{Operation: OperationHealthcheck, Severity: SeverityError, Header: "healthcheck error"})
+
+	require.Equal(t, []string{"backup ok", "backup warning", "repair warning", "healthcheck error"}, all.headers())
+	require.Equal(t, []string{"healthcheck error"}, errorsOnly.headers())
+	require.Equal(t, []string{"repair warning", "healthcheck error"}, repairWarnings.headers())
+}
+
+// Successes are only sent after a failure streak; the state is kept between runs in a file
+func TestRouter_RecoveryOnly(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "state", "notifier.json")
+	channel := &testNotifier{}
+
+	notify := func(operation Operation, severity Severity, header string) {
+		// a new router for every message, as if the program was run several times
+		router := NewRouter(stateFile, "cluster", zap.S())
+		router.Add(channel, RouteOptions{RecoveryOnly: true})
+		router.Notify(Message{Operation: operation, Severity: severity, Header: header})
+	}
+
+	notify(OperationBackup, SeverityInfo, "backup ok 1")
+	notify(OperationBackup, SeverityError, "backup failed 1")
+	notify(OperationBackup, SeverityError, "backup failed 2")
+	notify(OperationRepair, SeverityInfo, "repair ok")
+	notify(OperationBackup, SeverityInfo, "backup recovered")
+	notify(OperationBackup, SeverityInfo, "backup ok 2")
+	notify(OperationBackup, SeverityWarning, "backup warning")
+	notify(OperationBackup, SeverityInfo, "backup recovered from warning")
+
+	require.Equal(t, []string{
+		"backup failed 1",
+		"backup failed 2",
+		"backup recovered",
+		"backup warning",
+		"backup recovered from warning",
+	}, channel.headers())
+}
+
+// An alerting channel only receives a success after an error, to resolve an alert, even with minSeverity: error
+func TestRouter_Alerts(t *testing.T) {
+	channel := &testNotifier{}
+	router := NewRouter("", "cluster", zap.S())
+	router.Add(channel, alertRouteOptions(RouteOptions{MinSeverity: SeverityError}))
+
+	router.Notify(Message{Operation: OperationBackup, Severity: SeverityInfo, Header: "backup ok 1"})
+	router.Notify(Message{Operation: OperationBackup, Severity: SeverityError, Header: "backup failed"})
+	router.Notify(Message{Operation: OperationBackup, Severity: SeverityInfo, Header: "backup recovered"})
+	router.Notify(Message{Operation: OperationBackup, Severity: SeverityInfo, Header: "backup ok 2"})
+	router.Notify(Message{Operation: OperationBackup, Severity: SeverityWarning, Header: "backup warning"})
+	router.Notify(Message{Operation: OperationCleanup, Severity: SeverityError, Header: "cleanup failed"})
+
+	require.Equal(t, []string{"backup failed", "backup recovered"}, channel.headers())
+}
+
+// Clusters sharing a state file don't recover each other's failures
+func TestRouter_StatePerCluster(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "notifier.json")
+	first := &testNotifier{}
+	second := &testNotifier{}
+
+	firstRouter := NewRouter(stateFile, "first", zap.S())
+	firstRouter.Add(first, RouteOptions{RecoveryOnly: true})
+	secondRouter := NewRouter(stateFile, "second", zap.S())
+	secondRouter.Add(second, RouteOptions{RecoveryOnly: true})
+
+	firstRouter.Notify(Message{Operation: OperationBackup, Severity: SeverityError, Header: "first failed"})
+	secondRouter.Notify(Message{Operation: OperationBackup, Severity: SeverityInfo, Header: "second ok"})
+	firstRouter.Notify(Message{Operation: OperationBackup, Severity: SeverityInfo, Header: "first recovered"})
+
+	require.Equal(t, []string{"first failed", "first recovered"}, first.headers())
+	require.Empty(t, second.headers(), "a success of another cluster is not a recovery")
+}
+
+// Concurrent runs sharing a state file don't lose each other's state
+func TestRouter_ConcurrentState(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "notifier.json")
+	operations := []Operation{OperationBackup, OperationRepair, OperationCleanup, OperationMaintenance}
+	wg := sync.WaitGroup{}
+
+	for _, operation := range operations {
+		wg.Add(1)
+		go func(operation Operation) {
+			defer wg.Done()
+			NewRouter(stateFile, "cluster", zap.S()).Notify(Message{Operation: operation, Severity: SeverityError})
+		}(operation)
+	}
+
+	wg.Wait()
+
+	for _, operation := range operations {
+		previous, err := newStateStore(stateFile, "cluster").swap(operation, SeverityInfo)
+		require.NoError(t, err)
+		require.Equal(t, SeverityError, previous, string(operation))
+	}
+}
+
+// Routing options are configured inline with channel options
+// A passed healthcheck is only sent after a failed one
+func TestRouter_Healthcheck(t *testing.T) {
+	channel := &testNotifier{}
+	router := NewRouter("", "cluster", zap.S())
+	router.Add(channel, RouteOptions{})
+
+	router.Notify(Message{Operation: OperationHealthcheck, Severity: SeverityInfo, Header: "passed 1"})
+	router.Notify(Message{Operation: OperationHealthcheck, Severity: SeverityError, Header: "failed"})
+	router.Notify(Message{Operation: OperationHealthcheck, Severity: SeverityInfo, Header: "passed 2"})
+	router.Notify(Message{Operation: OperationHealthcheck, Severity: SeverityInfo, Header: "passed 3"})
+
+	require.Equal(t, []string{"failed", "passed 2"}, channel.headers())
+}
+
+func TestRouteOptions_Yaml(t *testing.T) {
+	options := Options{}
+	err := yaml.Unmarshal([]byte(`
+slack:
+  - url: https://hooks.slack.com/test
+    minSeverity: warning
+    operations: [ backup, cleanup ]
+    recoveryOnly: true
+`), &options)
+	require.NoError(t, err)
+	require.Equal(t, SlackOptions{
+		Url: "https://hooks.slack.com/test",
+		RouteOptions: RouteOptions{
+			MinSeverity:  SeverityWarning,
+			Operations:   []Operation{OperationBackup, OperationCleanup},
+			RecoveryOnly: true,
+		},
+	}, options.Slack[0])
+
+	err = yaml.Unmarshal([]byte("slack: [ { minSeverity: fatal } ]"), &options)
+	require.Error(t, err)
+}
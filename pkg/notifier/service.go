@@ -4,18 +4,71 @@ import "go.uber.org/zap"
 
 // Notifier an interface for sending notifications
 type Notifier interface {
-	Info(header, body string, data map[string]interface{})
-	Error(header, body string, err error, data map[string]interface{})
+	Notify(msg Message)
 }
 
+// Options configure the notification channels.
+// Every notification is sent to all channels whose routing options accept it (see RouteOptions).
 type Options struct {
-	Webhook *WebhookOptions
+	// keeps the severity of the last notification of every operation to detect recoveries
+	StateFile string `yaml:"stateFile"`
+	// identifies the cluster in alerts (cluster.clusterName, or the hostname of the machine by default)
+	Cluster    string `yaml:"-"`
+	Webhook    *WebhookOptions
+	Slack      []SlackOptions
+	Telegram   []TelegramOptions
+	Mattermost []MattermostOptions
+	Email      []EmailOptions
+	PagerDuty  []PagerDutyOptions `yaml:"pagerDuty"`
+	Opsgenie   []OpsgenieOptions
 }
 
-func New(options Options, logger *zap.SugaredLogger) Notifier {
-	if options.Webhook == nil || len(options.Webhook.Url) == 0 {
-		return Disabled{}
-	} else {
-		return NewWebhook(*options.Webhook, logger)
+// New creates a notifier for all configured channels
+func New(options Options, logger *zap.SugaredLogger) (Notifier, error) {
+	router := NewRouter(options.StateFile, options.Cluster, logger)
+
+	if options.Webhook != nil && len(options.Webhook.Url) > 0 {
+		webhook, err := NewWebhook(*options.Webhook, logger)
+		if err != nil {
+			return nil, err
+		}
+
+		router.Add(webhook, options.Webhook.RouteOptions)
 	}
+
+	for _, slackOptions := range options.Slack {
+		router.Add(NewSlack(slackOptions, logger), slackOptions.RouteOptions)
+	}
+
+	for _, telegramOptions := range options.Telegram {
+		router.Add(NewTelegram(telegramOptions, logger), telegramOptions.RouteOptions)
+	}
+
+	for _, mattermostOptions := range options.Mattermost {
+		router.Add(NewMattermost(mattermostOptions, logger), mattermostOptions.RouteOptions)
+	}
+
+	for _, emailOptions := range options.Email {
+		router.Add(NewEmail(emailOptions, logger), emailOptions.RouteOptions)
+	}
+
+	for _, pagerDutyOptions := range options.PagerDuty {
+		router.Add(
+			NewPagerDuty(pagerDutyOptions, options.Cluster, logger),
+			alertRouteOptions(pagerDutyOptions.RouteOptions),
+		)
+	}
+
+	for _, opsgenieOptions := range options.Opsgenie {
+		router.Add(
+			NewOpsgenie(opsgenieOptions, options.Cluster, logger),
+			alertRouteOptions(opsgenieOptions.RouteOptions),
+		)
+	}
+
+	if len(router.routes) == 0 {
+		return Disabled{}, nil
+	}
+
+	return router, nil
 }
@@ -0,0 +1,108 @@
+package notifier
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// a request received by a test server
+type testRequest struct {
+	Path   string
+	Query  string
+	Header http.Header
+	Body   map[string]interface{}
+}
+
+// starts an HTTP server that records JSON requests and replies with a given status code
+func newTestServer(t *testing.T, statusCode int) (*httptest.Server, func() []testRequest) {
+	var mu sync.Mutex
+	requests := []testRequest{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		body := map[string]interface{}{}
+		require.NoError(t, json.Unmarshal(data, &body))
+
+		mu.Lock()
+		requests = append(requests, testRequest{Path: r.URL.Path, Query: r.URL.RawQuery, Header: r.Header, Body: body})
+		mu.Unlock()
+
+		w.WriteHeader(statusCode)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []testRequest {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return append([]testRequest{}, requests...)
+	}
+}
+
+func TestNew(t *testing.T) {
+	notifier, err := New(Options{}, zap.S())
+	require.NoError(t, err)
+	require.Equal(t, Disabled{}, notifier)
+
+	notifier, err = New(Options{
+		Webhook:    &WebhookOptions{Url: "test"},
+		Slack:      []SlackOptions{{Url: "test"}, {Url: "test2"}},
+		Telegram:   []TelegramOptions{{Token: "token", ChatId: "1"}},
+		Mattermost: []MattermostOptions{{Url: "test"}},
+		Email:      []EmailOptions{{Host: "smtp.example.com"}},
+		PagerDuty:  []PagerDutyOptions{{RoutingKey: "key"}},
+		Opsgenie:   []OpsgenieOptions{{ApiKey: "key", RouteOptions: RouteOptions{Operations: []Operation{OperationBackup}}}},
+	}, zap.S())
+	require.NoError(t, err)
+	require.IsType(t, &Router{}, notifier)
+
+	routes := notifier.(*Router).routes
+	require.Len(t, routes, 8)
+	// alerting channels only page about some operations by default
+	require.Equal(t, alertOperations, routes[6].options.Operations)
+	require.Equal(t, []Operation{OperationBackup}, routes[7].options.Operations)
+
+	_, err = New(Options{
+		Webhook: &WebhookOptions{Url: "test", Template: "{{.Header"},
+	}, zap.S())
+	require.Error(t, err)
+}
+
+// Every notification is sent to all channels; a failure of one channel doesn't affect the others
+func TestNew_AllChannels(t *testing.T) {
+	slackServer, slackRequests := newTestServer(t, http.StatusOK)
+	failingServer, failingRequests := newTestServer(t, http.StatusInternalServerError)
+	mattermostServer, mattermostRequests := newTestServer(t, http.StatusOK)
+
+	notifier, err := New(Options{
+		Slack:      []SlackOptions{{Url: failingServer.URL}, {Url: slackServer.URL}},
+		Mattermost: []MattermostOptions{{Url: mattermostServer.URL}},
+	}, zap.S())
+	require.NoError(t, err)
+
+	notifier.Notify(Message{
+		Operation: OperationBackup,
+		Header:    "Backup completed successfully",
+		Body:      "Total nodes: 3",
+	})
+	notifier.Notify(Message{
+		Operation: OperationBackup,
+		Severity:  SeverityError,
+		Header:    "Could not back up cluster nodes",
+		Body:      "Total nodes: 3",
+		Err:       io.EOF,
+	})
+
+	require.Len(t, failingRequests(), 2)
+	require.Len(t, slackRequests(), 2)
+	require.Len(t, mattermostRequests(), 2)
+}
@@ -0,0 +1,105 @@
+package notifier
+
+import (
+	"fmt"
+	"go.uber.org/zap"
+	"net/http"
+	"strings"
+)
+
+// Slack is an implementation of Notifier that sends Block Kit messages to a Slack incoming webhook
+type Slack struct {
+	options    SlackOptions
+	httpClient *http.Client
+	logger     *zap.SugaredLogger
+}
+
+type SlackOptions struct {
+	// an incoming webhook URL, e.g. https://hooks.slack.com/services/...
+	Url          string
+	RouteOptions `yaml:",inline"`
+}
+
+// slack limits the text of a section block to 3000 characters, and a header to 150
+const (
+	slackMaxSectionLength = 3000
+	slackMaxHeaderLength  = 150
+)
+
+type slackMessage struct {
+	// a fallback text for notifications
+	Text   string       `json:"text"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+type slackBlock struct {
+	Type string     `json:"type"`
+	Text *slackText `json:"text,omitempty"`
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+func NewSlack(options SlackOptions, logger *zap.SugaredLogger) *Slack {
+	return &Slack{
+		options:    options,
+		httpClient: newHttpClient(),
+		logger:     logger.Named("slack-notifier"),
+	}
+}
+
+// Notify sends a message
+func (s *Slack) Notify(msg Message) {
+	slackMsg := s.getMessage(msg)
+	err := postJson(s.httpClient, s.options.Url, slackMsg)
+	if err != nil {
+		s.logger.Warnw("could not send message to slack", "message", slackMsg.Text, "error", err)
+	}
+}
+
+// Creates a notification message: a header block, and the body and data as preformatted text
+func (s *Slack) getMessage(msg Message) slackMessage {
+	headerText := title + msg.mark()
+	if len(msg.Header) > 0 {
+		headerText += ": " + msg.Header
+	}
+
+	slackMsg := slackMessage{
+		Text: headerText,
+		Blocks: []slackBlock{
+			{
+				Type: "header",
+				Text: &slackText{Type: "plain_text", Text: truncate(headerText, slackMaxHeaderLength)},
+			},
+		},
+	}
+
+	if msg.showError() {
+		slackMsg.Blocks = append(slackMsg.Blocks, slackSection("*Error:*\n"+slackCode(msg.Err.Error(), slackMaxSectionLength-len("*Error:*\n"))))
+	} else if len(msg.Body) > 0 {
+		slackMsg.Blocks = append(slackMsg.Blocks, slackSection(slackCode(msg.Body, slackMaxSectionLength)))
+	}
+
+	if len(msg.Data) > 0 {
+		slackMsg.Blocks = append(slackMsg.Blocks, slackSection(slackCode(dataJson(msg.Data), slackMaxSectionLength)))
+	}
+
+	return slackMsg
+}
+
+func slackSection(text string) slackBlock {
+	return slackBlock{
+		Type: "section",
+		Text: &slackText{Type: "mrkdwn", Text: text},
+	}
+}
+
+// returns a preformatted text block of at most a given length; the text is truncated after escaping
+func slackCode(text string, maxLength int) string {
+	return fmt.Sprintf("```%s```", truncateEscaped(text, maxLength-6, escapeSlack))
+}
+
+// escapes the control characters of slack mrkdwn
+var escapeSlack = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace
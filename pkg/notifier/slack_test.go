@@ -0,0 +1,81 @@
+package notifier
+
+import (
+	"errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"net/http"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSlack(t *testing.T) {
+	server, requests := newTestServer(t, http.StatusOK)
+	slack := NewSlack(SlackOptions{Url: server.URL}, zap.S())
+
+	slack.Notify(Message{
+		Operation: OperationBackup,
+		Header:    "Backup completed successfully",
+		Body:      "Total nodes: 3\n<all> & more",
+		Data:      map[string]interface{}{"cluster": "test"},
+	})
+	slack.Notify(Message{
+		Operation: OperationBackup,
+		Severity:  SeverityError,
+		Header:    "Could not back up cluster nodes",
+		Err:       errors.New("node is down"),
+	})
+
+	received := requests()
+	require.Len(t, received, 2)
+	require.Equal(t, map[string]interface{}{
+		"text": "🐙 scylla-octopus: Backup completed successfully",
+		"blocks": []interface{}{
+			map[string]interface{}{
+				"type": "header",
+				"text": map[string]interface{}{
+					"type": "plain_text",
+					"text": "🐙 scylla-octopus: Backup completed successfully",
+				},
+			},
+			map[string]interface{}{
+				"type": "section",
+				"text": map[string]interface{}{
+					"type": "mrkdwn",
+					"text": "```Total nodes: 3\n&lt;all&gt; &amp; more```",
+				},
+			},
+			map[string]interface{}{
+				"type": "section",
+				"text": map[string]interface{}{
+					"type": "mrkdwn",
+					"text": "```{\n  \"cluster\": \"test\"\n}```",
+				},
+			},
+		},
+	}, received[0].Body)
+
+	blocks := received[1].Body["blocks"].([]interface{})
+	require.Len(t, blocks, 2)
+	require.Equal(
+		t,
+		"🐙 scylla-octopus 🔥: Could not back up cluster nodes",
+		blocks[0].(map[string]interface{})["text"].(map[string]interface{})["text"],
+	)
+	require.Equal(
+		t,
+		"*Error:*\n```node is down```",
+		blocks[1].(map[string]interface{})["text"].(map[string]interface{})["text"],
+	)
+}
+
+// A section is truncated after escaping, so that it fits into the slack limit, and an entity is not cut
+func TestSlackCode(t *testing.T) {
+	code := slackCode(strings.Repeat("<&>", 2000), slackMaxSectionLength)
+
+	require.LessOrEqual(t, utf8.RuneCountInString(code), slackMaxSectionLength)
+	require.True(t, strings.HasSuffix(code, ";…```"))
+	require.Equal(t, "```a &amp; b```", slackCode("a & b", 100))
+	require.Equal(t, "```a …```", slackCode("a & b", 10))
+}
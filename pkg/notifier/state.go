@@ -0,0 +1,69 @@
+package notifier
+
+import (
+	"encoding/json"
+	"github.com/kolesa-team/scylla-octopus/pkg/atomicfile"
+	"github.com/pkg/errors"
+	"os"
+	"sync"
+)
+
+// keeps the severity of the last notification of every operation in a cluster, so that recoveries can be detected across runs.
+// The state file may be shared by concurrent runs and by several clusters, so it is locked while it's updated.
+// if the path is empty, the state is only kept in memory.
+type stateStore struct {
+	path    string
+	cluster string
+	mu      sync.Mutex
+	last    map[string]Severity
+}
+
+func newStateStore(path, cluster string) *stateStore {
+	return &stateStore{
+		path:    path,
+		cluster: cluster,
+		last:    map[string]Severity{},
+	}
+}
+
+// remembers the severity of an operation, and returns the previous one (info if unknown)
+func (s *stateStore) swap(operation Operation, severity Severity) (Severity, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := alertKey(s.cluster, operation)
+
+	if len(s.path) == 0 {
+		previous := s.last[key]
+		s.last[key] = severity
+
+		return previous, nil
+	}
+
+	unlock, err := atomicfile.Lock(s.path)
+	if err != nil {
+		return SeverityInfo, errors.Wrapf(err, "could not lock notification state %s", s.path)
+	}
+	defer unlock()
+
+	// the state is read every time, since another run may have changed it
+	last := map[string]Severity{}
+	data, err := os.ReadFile(s.path)
+	if err == nil {
+		err = json.Unmarshal(data, &last)
+	}
+
+	if err != nil && !os.IsNotExist(err) {
+		return SeverityInfo, errors.Wrapf(err, "could not read notification state %s", s.path)
+	}
+
+	previous := last[key]
+	last[key] = severity
+
+	data, err = json.MarshalIndent(last, "", "  ")
+	if err == nil {
+		err = atomicfile.Write(s.path, data)
+	}
+
+	return previous, errors.Wrapf(err, "could not write notification state %s", s.path)
+}
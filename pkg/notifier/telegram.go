@@ -0,0 +1,105 @@
+package notifier
+
+import (
+	"go.uber.org/zap"
+	"net/http"
+	"strings"
+)
+
+// Telegram is an implementation of Notifier that sends HTML messages with Telegram Bot API
+type Telegram struct {
+	options    TelegramOptions
+	httpClient *http.Client
+	logger     *zap.SugaredLogger
+}
+
+type TelegramOptions struct {
+	// a bot token from @BotFather
+	Token string
+	// a chat, a group or a channel (e.g. @my_channel) to send the messages to
+	ChatId string `yaml:"chatId"`
+	// defaults to https://api.telegram.org
+	ApiUrl       string `yaml:"apiUrl"`
+	RouteOptions `yaml:",inline"`
+}
+
+const (
+	// telegram limits a message to 4096 characters
+	telegramMaxMessageLength = 4096
+	// the body is truncated first, so that the data after it fits, too
+	telegramMaxBodyLength = 3500
+)
+
+// the tags of htmlMessage that are closed if a message is truncated inside them
+var telegramTags = []string{"pre", "b"}
+
+type telegramMessage struct {
+	ChatId                string `json:"chat_id"`
+	Text                  string `json:"text"`
+	ParseMode             string `json:"parse_mode"`
+	DisableWebPagePreview bool   `json:"disable_web_page_preview"`
+}
+
+func NewTelegram(options TelegramOptions, logger *zap.SugaredLogger) *Telegram {
+	if len(options.ApiUrl) == 0 {
+		options.ApiUrl = "https://api.telegram.org"
+	}
+
+	return &Telegram{
+		options:    options,
+		httpClient: newHttpClient(),
+		logger:     logger.Named("telegram-notifier"),
+	}
+}
+
+// Notify sends a message
+func (t *Telegram) Notify(msg Message) {
+	telegramMsg := t.getMessage(msg)
+	url := strings.TrimRight(t.options.ApiUrl, "/") + "/bot" + t.options.Token + "/sendMessage"
+
+	err := postJson(t.httpClient, url, telegramMsg)
+	if err != nil {
+		// the error may contain the URL with a token
+		t.logger.Warnw(
+			"could not send message to telegram",
+			"message", telegramMsg.Text,
+			"error", strings.ReplaceAll(err.Error(), t.options.Token, "******"),
+		)
+	}
+}
+
+// Creates a notification message in Telegram HTML markup
+func (t *Telegram) getMessage(msg Message) telegramMessage {
+	msg.Body = truncate(msg.Body, telegramMaxBodyLength)
+
+	return telegramMessage{
+		ChatId:                t.options.ChatId,
+		Text:                  truncateHtml(htmlMessage(msg), telegramMaxMessageLength),
+		ParseMode:             "HTML",
+		DisableWebPagePreview: true,
+	}
+}
+
+// cuts an HTML message to a given number of characters, keeping its markup valid:
+// a cut tag or entity is removed, and the open tags are closed
+func truncateHtml(text string, maxLength int) string {
+	if len([]rune(text)) <= maxLength {
+		return text
+	}
+
+	// the space for an ellipsis and the closing tags
+	reserved := 1
+	for _, tag := range telegramTags {
+		reserved += len("</" + tag + ">")
+	}
+
+	truncated := trimIncompleteMarkup(string([]rune(text)[:maxLength-reserved])) + "…"
+
+	for _, tag := range telegramTags {
+		if strings.LastIndex(truncated, "<"+tag+">") > strings.LastIndex(truncated, "</"+tag+">") {
+			truncated += "</" + tag + ">"
+		}
+	}
+
+	return truncated
+}
@@ -0,0 +1,60 @@
+package notifier
+
+import (
+	"errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"net/http"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTelegram(t *testing.T) {
+	server, requests := newTestServer(t, http.StatusOK)
+	telegram := NewTelegram(TelegramOptions{Token: "123:abc", ChatId: "-100500", ApiUrl: server.URL + "/"}, zap.S())
+
+	telegram.Notify(Message{
+		Operation: OperationBackup,
+		Header:    "Backup completed successfully",
+		Body:      "Total nodes: 3\n<all> & more",
+	})
+	telegram.Notify(Message{
+		Operation: OperationBackup,
+		Severity:  SeverityError,
+		Header:    "Could not back up cluster nodes",
+		Err:       errors.New("node <1> is down"),
+	})
+
+	received := requests()
+	require.Len(t, received, 2)
+	require.Equal(t, "/bot123:abc/sendMessage", received[0].Path)
+	require.Equal(t, map[string]interface{}{
+		"chat_id":                  "-100500",
+		"parse_mode":               "HTML",
+		"disable_web_page_preview": true,
+		"text":                     "🐙 <b>scylla-octopus</b>\n\n<b>Backup completed successfully</b>\n\nTotal nodes: 3\n&lt;all&gt; &amp; more",
+	}, received[0].Body)
+	require.Equal(
+		t,
+		"🐙 <b>scylla-octopus</b> 🔥\n\n<b>Could not back up cluster nodes</b>\n\nError:\n<pre>node &lt;1&gt; is down</pre>",
+		received[1].Body["text"],
+	)
+}
+
+// A long message is truncated to the telegram limit, without breaking its markup
+func TestTruncateHtml(t *testing.T) {
+	require.Equal(t, "<b>short</b>", truncateHtml("<b>short</b>", 20))
+
+	text := htmlMessage(Message{
+		Header: "Could not back up cluster nodes",
+		Err:    errors.New(strings.Repeat("<&>", 2000)),
+	})
+	truncated := truncateHtml(text, telegramMaxMessageLength)
+
+	require.LessOrEqual(t, utf8.RuneCountInString(truncated), telegramMaxMessageLength)
+	require.True(t, strings.HasSuffix(truncated, ";…</pre>"), "the last entity must be complete, and the tag closed")
+
+	require.Equal(t, "<b>a…</b>", truncateHtml("<b>a&amp;bcdefghij</b>", 17))
+	require.Equal(t, "<b>ab…</b>", truncateHtml("<b>ab</b><pre>c</pre>", 19))
+}
@@ -1,106 +1,300 @@
 package notifier
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
-	"html"
+	"io"
 	"net/http"
 	"net/url"
 	"strings"
+	"text/template"
 	"time"
 )
 
-// Webhook is an implementation of Notifier that sends HTML messages to a given URL
+// Webhook is an implementation of Notifier that sends messages to a given URL,
+// either as a form field, or as a JSON payload with structured results
 type Webhook struct {
 	options    WebhookOptions
+	template   *template.Template
 	httpClient *http.Client
 	logger     *zap.SugaredLogger
+	// waits between attempts; replaced in tests
+	sleep func(time.Duration)
 }
 
+// the formats of webhook requests
+const (
+	// a message in a form field (default)
+	WebhookFormatForm = "form"
+	// a JSON payload with a message and structured results (see webhookPayload)
+	WebhookFormatJson = "json"
+)
+
 type WebhookOptions struct {
-	Url          string
-	MessageField string
+	Url string
+	// form (default) or json
+	Format string
+	// a form or JSON field with a message. defaults to "message"
+	MessageField string `yaml:"messageField"`
+	// an HTTP method. defaults to POST
+	Method string
+	// extra HTTP headers, e.g. an authorization token
+	Headers map[string]string
+	// a text/template of a message; an HTML message by default.
+	// the template is executed with webhookPayload, e.g. "{{.Severity}}: {{.Header}}\n{{.Body}}".
+	Template string
+	// a timeout of a single request. defaults to 10s
+	Timeout time.Duration
+	// a number of retries after a failed request. defaults to 3; -1 disables retries
+	Retries int
+	// a delay before the first retry, doubled after every attempt. defaults to 1s
+	RetryDelay   time.Duration `yaml:"retryDelay"`
+	RouteOptions `yaml:",inline"`
 }
 
-// Info sends an informational message
-func (w *Webhook) Info(header, body string, data map[string]interface{}) {
-	msg := w.getMessage(header, body, nil, data)
-	err := w.sendMessage(msg)
-	if err != nil {
-		w.logger.Warnw("could not send message to webhook", "message", msg, "error", err)
-	}
+// webhookPayload is a structured notification; it is sent in json format, and is available in templates
+type webhookPayload struct {
+	Operation Operation              `json:"operation"`
+	Severity  Severity               `json:"severity"`
+	Header    string                 `json:"header"`
+	Body      string                 `json:"body"`
+	Error     string                 `json:"error,omitempty"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+	// the results of an operation, such as entity.BackupResults
+	Result interface{} `json:"result,omitempty"`
+	Time   time.Time   `json:"time"`
 }
 
-// Error sends an error message
-func (w *Webhook) Error(header, body string, err error, data map[string]interface{}) {
-	msg := w.getMessage(header, body, err, data)
-	sendErr := w.sendMessage(msg)
-	if sendErr != nil {
-		w.logger.Warnw("could not send message to webhook", "message", msg, "error", sendErr)
+func NewWebhook(options WebhookOptions, logger *zap.SugaredLogger) (*Webhook, error) {
+	if len(options.MessageField) == 0 {
+		options.MessageField = "message"
+	}
+
+	if len(options.Format) == 0 {
+		options.Format = WebhookFormatForm
+	}
+
+	if options.Format != WebhookFormatForm && options.Format != WebhookFormatJson {
+		return nil, fmt.Errorf("unknown webhook format: %s (expected form or json)", options.Format)
+	}
+
+	if len(options.Method) == 0 {
+		options.Method = http.MethodPost
+	}
+
+	if options.Timeout <= 0 {
+		options.Timeout = time.Second * 10
+	}
+
+	if options.Retries == 0 {
+		options.Retries = 3
+	}
+
+	if options.RetryDelay <= 0 {
+		options.RetryDelay = time.Second
+	}
+
+	webhook := &Webhook{
+		logger:  logger.Named("webhook-notifier"),
+		options: options,
+		httpClient: &http.Client{
+			Timeout: options.Timeout,
+		},
+		sleep: time.Sleep,
+	}
+
+	if len(options.Template) > 0 {
+		var err error
+		webhook.template, err = template.New("webhook").Funcs(template.FuncMap{
+			"json": func(value interface{}) (string, error) {
+				data, err := json.Marshal(value)
+				return string(data), err
+			},
+		}).Parse(options.Template)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not parse webhook template")
+		}
 	}
+
+	return webhook, nil
 }
 
-// Creates a notification message
-func (w *Webhook) getMessage(header, body string, err error, extra map[string]interface{}) string {
-	lines := []string{}
-	prefix := "🐙 <b>scylla-octopus</b>"
+// Notify sends a message
+func (w *Webhook) Notify(msg Message) {
+	text, err := w.getMessage(msg)
+	if err == nil {
+		err = w.sendMessage(text, msg)
+	}
 
 	if err != nil {
-		prefix = prefix + " 🔥" // an error needs some fire
+		w.logger.Warnw("could not send message to webhook", "message", text, "error", err)
+	}
+}
 
-		if len(body) == 0 {
-			body = fmt.Sprintf("Error:\n<pre>%s</pre>", html.EscapeString(err.Error()))
-		}
+// Creates a notification message from a template, or an HTML message by default
+func (w *Webhook) getMessage(msg Message) (string, error) {
+	if w.template == nil {
+		return htmlMessage(msg), nil
 	}
 
-	lines = append(lines, prefix, "")
+	var text bytes.Buffer
+	err := w.template.Execute(&text, newWebhookPayload(msg))
+
+	return text.String(), errors.Wrap(err, "could not execute webhook template")
+}
+
+func newWebhookPayload(msg Message) webhookPayload {
+	payload := webhookPayload{
+		Operation: msg.Operation,
+		Severity:  msg.Severity,
+		Header:    msg.Header,
+		Body:      msg.Body,
+		Data:      msg.Data,
+		Result:    msg.Result,
+		Time:      time.Now(),
+	}
+
+	if msg.Err != nil {
+		payload.Error = msg.Err.Error()
+	}
+
+	return payload
+}
+
+// escapes the characters that have a special meaning in HTML messages (the only ones Telegram requires)
+var escapeHtml = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace
+
+// Creates an HTML notification message (also used by Telegram and email)
+func htmlMessage(msg Message) string {
+	lines := []string{"🐙 <b>scylla-octopus</b>" + msg.mark(), ""}
 
-	if len(header) > 0 {
+	if len(msg.Header) > 0 {
 		lines = append(
 			lines,
-			fmt.Sprintf("<b>%s</b>", header),
+			fmt.Sprintf("<b>%s</b>", escapeHtml(msg.Header)),
 			"",
 		)
 	}
 
-	lines = append(lines, body)
+	if msg.showError() {
+		lines = append(lines, fmt.Sprintf("Error:\n<pre>%s</pre>", escapeHtml(msg.Err.Error())))
+	} else {
+		lines = append(lines, escapeHtml(msg.Body))
+	}
 
-	if len(extra) > 0 {
-		extraJsonBytes, _ := json.MarshalIndent(extra, "", "  ")
-		extraJson := fmt.Sprintf("<pre>%s</pre>", string(extraJsonBytes))
-		lines = append(lines, "", extraJson)
+	if len(msg.Data) > 0 {
+		lines = append(lines, "", fmt.Sprintf("<pre>%s</pre>", escapeHtml(dataJson(msg.Data))))
 	}
 
 	return strings.Join(lines, "\n")
 }
 
-func (w *Webhook) sendMessage(message string) error {
-	resp, err := w.httpClient.PostForm(w.options.Url, url.Values{
-		w.options.MessageField: []string{message},
-	})
+// sends a message, retrying with exponential backoff on network errors, 5xx and 429 responses
+func (w *Webhook) sendMessage(text string, msg Message) error {
+	delay := w.options.RetryDelay
+
+	for attempt := 0; ; attempt++ {
+		retryable, err := w.send(text, msg)
+		if err == nil || !retryable || attempt >= w.options.Retries {
+			return err
+		}
+
+		w.logger.Debugw("webhook request failed; retrying", "error", err, "delay", delay)
+		w.sleep(delay)
+		delay *= 2
+	}
+}
+
+// sends a single request; returns whether it's worth retrying if it fails
+func (w *Webhook) send(text string, msg Message) (bool, error) {
+	req, err := w.newRequest(text, msg)
 	if err != nil {
-		return errors.Wrap(err, "could not send message to webhook")
+		return false, err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code from webhook: %d", resp.StatusCode)
+	for name, value := range w.options.Headers {
+		req.Header.Set(name, value)
 	}
 
-	return nil
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return true, errors.Wrap(err, "could not send message to webhook")
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		retryable := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+
+		return retryable, fmt.Errorf("unexpected status code from webhook: %d", resp.StatusCode)
+	}
+
+	return false, nil
 }
 
-func NewWebhook(options WebhookOptions, logger *zap.SugaredLogger) *Webhook {
-	if len(options.MessageField) == 0 {
-		options.MessageField = "message"
+// creates a request with a message in a form field (in a query string for GET), or with a JSON payload
+func (w *Webhook) newRequest(text string, msg Message) (*http.Request, error) {
+	if w.options.Format == WebhookFormatJson {
+		payload, err := json.Marshal(newWebhookPayload(msg))
+		if err != nil {
+			return nil, errors.Wrap(err, "could not encode webhook payload")
+		}
+
+		// a rendered message is added under MessageField
+		payload, err = addJsonField(payload, w.options.MessageField, text)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not encode webhook payload")
+		}
+
+		req, err := http.NewRequest(w.options.Method, w.options.Url, bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+
+		return req, nil
 	}
 
-	return &Webhook{
-		logger:  logger.Named("webhook-notifier"),
-		options: options,
-		httpClient: &http.Client{
-			Timeout: time.Second,
-		},
+	if w.options.Method == http.MethodGet {
+		reqUrl, err := url.Parse(w.options.Url)
+		if err != nil {
+			return nil, err
+		}
+
+		query := reqUrl.Query()
+		query.Set(w.options.MessageField, text)
+		reqUrl.RawQuery = query.Encode()
+
+		return http.NewRequest(w.options.Method, reqUrl.String(), nil)
+	}
+
+	form := url.Values{w.options.MessageField: []string{text}}
+	req, err := http.NewRequest(w.options.Method, w.options.Url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
 	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return req, nil
+}
+
+// adds a string field to a JSON object
+func addJsonField(object []byte, field, value string) ([]byte, error) {
+	fields := map[string]json.RawMessage{}
+	err := json.Unmarshal(object, &fields)
+	if err != nil {
+		return nil, err
+	}
+
+	fields[field], err = json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(fields)
 }
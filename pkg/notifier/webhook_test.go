@@ -3,13 +3,21 @@ package notifier
 import (
 	"errors"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestWebhook_getMessage_NoError(t *testing.T) {
 	w := &Webhook{options: WebhookOptions{Url: "test-url"}}
-	actualMessage := w.getMessage("Test header", "This is a test notification message", nil, map[string]interface{}{
-		"extraField": "extraValue",
+	actualMessage, _ := w.getMessage(Message{
+		Header: "Test header",
+		Body:   "This is a test notification message",
+		Data: map[string]interface{}{
+			"extraField": "extraValue",
+		},
 	})
 	expectedMessage := `🐙 <b>scylla-octopus</b>
 
@@ -26,14 +34,14 @@ This is a test notification message
 
 func TestWebhook_getMessage_WithError(t *testing.T) {
 	w := &Webhook{options: WebhookOptions{Url: "test-url"}}
-	actualMessage := w.getMessage(
-		"Test header",
-		"",
-		errors.New("this is a test error"),
-		map[string]interface{}{
+	actualMessage, _ := w.getMessage(Message{
+		Severity: SeverityError,
+		Header:   "Test header",
+		Err:      errors.New("this is a test error"),
+		Data: map[string]interface{}{
 			"extraField": "extraValue",
 		},
-	)
+	})
 	expectedMessage :=
 		`🐙 <b>scylla-octopus</b> 🔥
 
@@ -48,3 +56,158 @@ Error:
 
 	require.Equal(t, expectedMessage, actualMessage)
 }
+
+func TestWebhook_getMessage_Warning(t *testing.T) {
+	w := &Webhook{options: WebhookOptions{Url: "test-url"}}
+	actualMessage, _ := w.getMessage(Message{
+		Severity: SeverityWarning,
+		Header:   "Backup completed with warnings",
+		Body:     "error while removing expired backups: <timeout>",
+		Err:      errors.New("timeout"),
+	})
+	expectedMessage := `🐙 <b>scylla-octopus</b> ⚠️
+
+<b>Backup completed with warnings</b>
+
+error while removing expired backups: &lt;timeout&gt;`
+
+	require.Equal(t, expectedMessage, actualMessage)
+}
+
+// starts a webhook that replies with given status codes one by one, repeating the last one
+func newTestWebhookServer(t *testing.T, statusCodes ...int) (*httptest.Server, func() []*http.Request) {
+	requests := []*http.Request{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		requests = append(requests, r)
+
+		w.WriteHeader(statusCodes[min(len(requests), len(statusCodes))-1])
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []*http.Request {
+		return requests
+	}
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+func newTestWebhook(t *testing.T, options WebhookOptions) *Webhook {
+	w, err := NewWebhook(options, zap.S())
+	require.NoError(t, err)
+	w.sleep = func(time.Duration) {}
+
+	return w
+}
+
+func TestWebhook_Notify_Form(t *testing.T) {
+	server, requests := newTestWebhookServer(t, http.StatusOK)
+	w := newTestWebhook(t, WebhookOptions{Url: server.URL, MessageField: "text"})
+
+	w.Notify(Message{Header: "Test header", Body: "Test body"})
+
+	require.Len(t, requests(), 1)
+	require.Equal(t, http.MethodPost, requests()[0].Method)
+	require.Contains(t, requests()[0].PostForm.Get("text"), "<b>Test header</b>")
+}
+
+func TestWebhook_Notify_Get(t *testing.T) {
+	server, requests := newTestWebhookServer(t, http.StatusOK)
+	w := newTestWebhook(t, WebhookOptions{
+		Url:      server.URL + "?token=secret",
+		Method:   http.MethodGet,
+		Template: "{{.Severity}}: {{.Header}}",
+	})
+
+	w.Notify(Message{Severity: SeverityWarning, Header: "Test header"})
+
+	require.Len(t, requests(), 1)
+	require.Equal(t, "secret", requests()[0].URL.Query().Get("token"))
+	require.Equal(t, "warning: Test header", requests()[0].URL.Query().Get("message"))
+}
+
+func TestWebhook_Notify_Json(t *testing.T) {
+	server, requests := newTestServer(t, http.StatusOK)
+	w := newTestWebhook(t, WebhookOptions{
+		Url:      server.URL,
+		Format:   WebhookFormatJson,
+		Template: "{{.Header}}: {{json .Result}}",
+	})
+
+	w.Notify(Message{
+		Operation: OperationBackup,
+		Severity:  SeverityError,
+		Header:    "Backup failed",
+		Err:       errors.New("test error"),
+		Result:    map[string]int{"TotalNodes": 3},
+	})
+
+	require.Len(t, requests(), 1)
+	body := requests()[0].Body
+	require.Equal(t, "backup", body["operation"])
+	require.Equal(t, "error", body["severity"])
+	require.Equal(t, "Backup failed", body["header"])
+	require.Equal(t, "test error", body["error"])
+	require.Equal(t, map[string]interface{}{"TotalNodes": float64(3)}, body["result"])
+	require.Equal(t, `Backup failed: {"TotalNodes":3}`, body["message"])
+	require.NotEmpty(t, body["time"])
+}
+
+func TestWebhook_Notify_Headers(t *testing.T) {
+	server, requests := newTestWebhookServer(t, http.StatusNoContent)
+	w := newTestWebhook(t, WebhookOptions{
+		Url:     server.URL,
+		Method:  http.MethodPut,
+		Headers: map[string]string{"Authorization": "Bearer token"},
+	})
+
+	w.Notify(Message{Header: "Test header"})
+
+	require.Len(t, requests(), 1)
+	require.Equal(t, http.MethodPut, requests()[0].Method)
+	require.Equal(t, "Bearer token", requests()[0].Header.Get("Authorization"))
+}
+
+// Server errors are retried with exponential backoff
+func TestWebhook_Notify_Retry(t *testing.T) {
+	server, requests := newTestWebhookServer(t, http.StatusBadGateway, http.StatusTooManyRequests, http.StatusOK)
+	w := newTestWebhook(t, WebhookOptions{Url: server.URL, RetryDelay: time.Second})
+
+	var delays []time.Duration
+	w.sleep = func(delay time.Duration) {
+		delays = append(delays, delay)
+	}
+
+	w.Notify(Message{Header: "Test header"})
+
+	require.Len(t, requests(), 3)
+	require.Equal(t, []time.Duration{time.Second, time.Second * 2}, delays)
+}
+
+// Retries stop after a configured number of attempts; client errors are not retried
+func TestWebhook_Notify_RetryLimit(t *testing.T) {
+	server, requests := newTestWebhookServer(t, http.StatusInternalServerError)
+	w := newTestWebhook(t, WebhookOptions{Url: server.URL, Retries: 2})
+	w.Notify(Message{Header: "Test header"})
+	require.Len(t, requests(), 3)
+
+	server, requests = newTestWebhookServer(t, http.StatusBadRequest)
+	w = newTestWebhook(t, WebhookOptions{Url: server.URL})
+	w.Notify(Message{Header: "Test header"})
+	require.Len(t, requests(), 1)
+}
+
+func TestNewWebhook_InvalidOptions(t *testing.T) {
+	_, err := NewWebhook(WebhookOptions{Url: "test", Format: "xml"}, zap.S())
+	require.Error(t, err)
+
+	_, err = NewWebhook(WebhookOptions{Url: "test", Template: "{{.Header"}, zap.S())
+	require.Error(t, err)
+}
@@ -67,18 +67,16 @@ func (c *Client) Healthcheck(ctx context.Context, node *entity.Node) error {
 
 // ExportSchema writes a database schema to a file
 func (c *Client) ExportSchema(ctx context.Context, node *entity.Node, path string) (string, error) {
-	filePath := strings.TrimRight(path, "/") + "/db_schema.cql"
+	filePath := strings.TrimRight(path, "/") + "/" + entity.BackupSchemaFilename
 
 	cqlshCmd := c.cqlshCmd(node.Info)
 	cqlshCmd.Args = append(
 		cqlshCmd.Args,
 		"-e",
-		`"DESC SCHEMA"`,
-		">",
-		filePath,
+		"DESC SCHEMA",
 	)
 
-	output, err := node.Cmd.Execute(ctx, cqlshCmd)
+	output, err := node.Cmd.Execute(cmd.Idempotent(cmd.WithCategory(ctx, cmd.CategoryCqlsh)), cmd.WithOutputFile(cqlshCmd, filePath))
 	if err != nil {
 		return "", errors.Wrapf(
 			err,
@@ -98,10 +96,10 @@ func (c *Client) describeCluster(ctx context.Context, node *entity.Node) (string
 	cqlshCmd.Args = append(
 		cqlshCmd.Args,
 		"-e",
-		`"describe cluster"`,
+		"describe cluster",
 	)
 
-	output, err := node.Cmd.Execute(ctx, cqlshCmd)
+	output, err := node.Cmd.Execute(cmd.ReadOnly(cmd.WithCategory(ctx, cmd.CategoryCqlsh)), cqlshCmd)
 	if err != nil {
 		return "", errors.Wrapf(
 			err,
@@ -3,6 +3,7 @@ package scylla
 import (
 	"context"
 	"github.com/stretchr/testify/require"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd/shell"
 	"github.com/kolesa-team/scylla-octopus/pkg/cmd/test"
 	"github.com/kolesa-team/scylla-octopus/pkg/entity"
 	"testing"
@@ -34,7 +35,7 @@ func TestClient_ExportSchema(t *testing.T) {
 	require.Equal(t, "/var/lib/backup/db_schema.cql", path)
 	require.Equal(
 		t,
-		`cqlsh scylla.test -u root -p pass -e "DESC SCHEMA" > /var/lib/backup/db_schema.cql`,
-		cmdExecutor.LastCmd.String(),
+		`sh -c 'cqlsh scylla.test -u root -p pass -e '\''DESC SCHEMA'\'' > /var/lib/backup/db_schema.cql'`,
+		shell.Line(cmdExecutor.LastCmd),
 	)
 }
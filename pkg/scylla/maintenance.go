@@ -0,0 +1,89 @@
+package scylla
+
+import (
+	"context"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd"
+	"github.com/kolesa-team/scylla-octopus/pkg/entity"
+	"github.com/pkg/errors"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Maintenance executes a `nodetool` maintenance operation (cleanup, compact, scrub, upgradesstables, flush).
+// If the filter contains several keyspaces, the operation is executed for each of them consecutively.
+// Stops on the first error.
+// see https://docs.scylladb.com/operating-scylla/nodetool/
+func (c *Client) Maintenance(
+	ctx context.Context,
+	node *entity.Node,
+	operation entity.MaintenanceOperation,
+	filter entity.MaintenanceFilter,
+) (entity.MaintenanceResult, error) {
+	logCtx := c.logger.With("host", node.Info.Host, "operation", operation)
+	timeStart := time.Now()
+	result := entity.MaintenanceResult{}
+
+	err := filter.Validate()
+	if err != nil {
+		return result, err
+	}
+
+	outputs := []string{}
+
+	for _, command := range c.maintenanceCommands(node, operation, filter) {
+		output, err := node.Cmd.Execute(ctx, command)
+		outputs = append(outputs, string(output))
+
+		if err != nil {
+			logCtx.Errorw(
+				"could not execute maintenance operation",
+				"error", err,
+				"output", string(output),
+			)
+			result.Output = strings.Join(outputs, "\n")
+			result.Duration = time.Now().Sub(timeStart)
+
+			return result, errors.Wrapf(
+				err,
+				"could not execute nodetool %s on %s. output: %s",
+				operation,
+				node.Info.Host,
+				string(output),
+			)
+		}
+	}
+
+	result.Output = strings.Join(outputs, "\n")
+	result.Duration = time.Now().Sub(timeStart)
+	logCtx.Infow("maintenance operation completed", "duration", result.Duration)
+
+	return result, nil
+}
+
+// returns `nodetool` commands for a maintenance operation: one per keyspace, or one for all keyspaces
+func (c *Client) maintenanceCommands(
+	node *entity.Node,
+	operation entity.MaintenanceOperation,
+	filter entity.MaintenanceFilter,
+) []*exec.Cmd {
+	if len(filter.Keyspaces) == 0 {
+		return []*exec.Cmd{
+			cmd.Command(node.Info.Binaries.Nodetool, string(operation)),
+		}
+	}
+
+	commands := []*exec.Cmd{}
+
+	for _, keyspace := range filter.Keyspaces {
+		command := cmd.Command(
+			node.Info.Binaries.Nodetool,
+			string(operation),
+			keyspace,
+		)
+		command.Args = append(command.Args, filter.Tables...)
+		commands = append(commands, command)
+	}
+
+	return commands
+}
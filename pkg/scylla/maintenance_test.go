@@ -0,0 +1,85 @@
+package scylla
+
+import (
+	"context"
+	"errors"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd/test"
+	"github.com/kolesa-team/scylla-octopus/pkg/entity"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"os/exec"
+	"testing"
+)
+
+func TestClient_Maintenance(t *testing.T) {
+	testCases := []struct {
+		name             string
+		operation        entity.MaintenanceOperation
+		filter           entity.MaintenanceFilter
+		expectedCommands []string
+	}{
+		{
+			name:             "all keyspaces",
+			operation:        entity.MaintenanceCleanup,
+			expectedCommands: []string{"nodetool cleanup"},
+		},
+		{
+			name:      "multiple keyspaces",
+			operation: entity.MaintenanceCompact,
+			filter:    entity.MaintenanceFilter{Keyspaces: []string{"ks1", "ks2"}},
+			expectedCommands: []string{
+				"nodetool compact ks1",
+				"nodetool compact ks2",
+			},
+		},
+		{
+			name:      "tables in a keyspace",
+			operation: entity.MaintenanceFlush,
+			filter: entity.MaintenanceFilter{
+				Keyspaces: []string{"ks1"},
+				Tables:    []string{"users", "orders"},
+			},
+			expectedCommands: []string{"nodetool flush ks1 users orders"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			executedCommands := []string{}
+			cmdExecutor := &test.Executor{
+				Func: func(cmd *exec.Cmd, executedCount int) (string, error) {
+					executedCommands = append(executedCommands, cmd.String())
+					return "", nil
+				},
+			}
+			client := Client{logger: zap.S()}
+			node := entity.NewNode(entity.NodeInfo{
+				Binaries: entity.NodeBinaries{Nodetool: "nodetool"},
+			}, cmdExecutor, nil)
+
+			_, err := client.Maintenance(context.Background(), node, testCase.operation, testCase.filter)
+			require.NoError(t, err)
+			require.Equal(t, testCase.expectedCommands, executedCommands)
+		})
+	}
+}
+
+func TestClient_Maintenance_Error(t *testing.T) {
+	cmdExecutor := &test.Executor{
+		Output: "error output",
+		Err:    errors.New("exit status 1"),
+	}
+	client := Client{logger: zap.S()}
+	node := entity.NewNode(entity.NodeInfo{
+		Binaries: entity.NodeBinaries{Nodetool: "nodetool"},
+	}, cmdExecutor, nil)
+
+	_, err := client.Maintenance(
+		context.Background(),
+		node,
+		entity.MaintenanceScrub,
+		entity.MaintenanceFilter{Keyspaces: []string{"ks1", "ks2"}},
+	)
+	require.Error(t, err)
+	require.Equal(t, 1, cmdExecutor.ExecutedCount, "the execution must stop after the first error")
+}
@@ -11,7 +11,7 @@ import (
 	"strings"
 )
 
-// Updates a cluster name, status, and datacenter for a given node.
+// Updates a cluster name, status, datacenter, rack and host ID for a given node.
 // Returns error if the status is not "UN" or if it cannot be updated.
 func (c *Client) updateNodeInfo(ctx context.Context, node *entity.Node) error {
 	var err error
@@ -24,80 +24,183 @@ func (c *Client) updateNodeInfo(ctx context.Context, node *entity.Node) error {
 		}
 	}
 
-	node.Info.Status, node.Info.Datacenter, err = c.getNodeStatus(ctx, node)
+	status, err := c.getNodeStatus(ctx, node)
 	if err != nil {
 		result = multierror.Append(result, err)
-	} else if !node.Info.IsStatusOk() {
-		result = multierror.Append(result, fmt.Errorf(
-			"invalid status on node %s: %s",
-			node.Info.Host,
-			node.Info.Status,
-		))
+	} else {
+		node.Info.Status = status.Status
+		node.Info.Datacenter = status.Datacenter
+		node.Info.Rack = status.Rack
+		node.Info.HostId = status.HostId
+
+		if !node.Info.IsStatusOk() {
+			result = multierror.Append(result, fmt.Errorf(
+				"invalid status on node %s: %s",
+				node.Info.Host,
+				node.Info.Status,
+			))
+		}
 	}
 
 	return result.ErrorOrNil()
 }
 
-// executes `nodetool status`; returns node status and datacenter
+// executes `nodetool status`; returns the status of a given node with its datacenter, rack and host ID
 func (c *Client) getNodeStatus(
 	ctx context.Context,
 	node *entity.Node,
-) (status, datacenter string, err error) {
-	output, err := node.Cmd.Execute(ctx, cmd.Command(
+) (entity.NodeStatus, error) {
+	statuses, err := c.ClusterStatus(ctx, node)
+	if err != nil {
+		return entity.NodeStatus{}, err
+	}
+
+	status, found := node.Info.FindStatus(statuses)
+	if !found {
+		return status, fmt.Errorf(
+			"could not find node status for %s (%s, %s) in %+v",
+			node.Info.Host,
+			node.Info.IpAddress,
+			node.Info.DomainName,
+			statuses,
+		)
+	}
+
+	return status, nil
+}
+
+// ClusterStatus executes `nodetool status` on a node and returns the statuses of all cluster nodes
+func (c *Client) ClusterStatus(ctx context.Context, node *entity.Node) ([]entity.NodeStatus, error) {
+	output, err := node.Cmd.Execute(cmd.ReadOnly(cmd.WithCategory(ctx, cmd.CategoryNodetool)), cmd.Command(
 		node.Info.Binaries.Nodetool,
 		"status",
 	))
 	if err != nil {
-		return "", "", err
+		return nil, errors.Wrapf(err, "could not execute `nodetool status`. output:\n%s", string(output))
 	}
 
-	possibleNodeAddresses := []string{
-		node.Info.IpAddress,
-		node.Info.DomainName,
-	}
-	status, datacenter = parseNodeStatus(
-		possibleNodeAddresses,
-		string(output),
-	)
-
-	if status == "" {
-		return status, datacenter, fmt.Errorf(
-			"could not parse node status from output for %+v:\n%s",
-			possibleNodeAddresses,
-			output,
-		)
+	statuses := parseNodeStatus(string(output))
+	if len(statuses) == 0 {
+		return nil, fmt.Errorf("could not parse cluster status from output:\n%s", output)
 	}
 
-	return status, datacenter, nil
+	return statuses, nil
 }
 
-// Parses an output of `nodetool status`
-func parseNodeStatus(possibleNodeAddresses []string, output string) (status, datacenter string) {
-	lines := strings.Split(output, "\n")
+// Parses an output of `nodetool status`: the statuses of all nodes with their datacenters, racks and host IDs
+func parseNodeStatus(output string) []entity.NodeStatus {
+	statuses := []entity.NodeStatus{}
+	datacenter := ""
 
-	for _, line := range lines {
+	for _, line := range strings.Split(output, "\n") {
 		if strings.HasPrefix(line, "Datacenter:") {
 			datacenter = strings.TrimSpace(strings.TrimPrefix(line, "Datacenter:"))
 			continue
 		}
 
-		isCurrentNode := false
+		parts := strings.Fields(line)
+		// a node line starts with a 2-letter status (UN, DN, UJ, etc), followed by an address
+		if len(parts) < 2 || !nodeStatusRegexp.MatchString(parts[0]) {
+			continue
+		}
+
+		status := entity.NodeStatus{
+			Address:    parts[1],
+			Status:     parts[0],
+			Datacenter: datacenter,
+			// a rack is always the last column
+			Rack: parts[len(parts)-1],
+		}
 
-		for _, nodeAddr := range possibleNodeAddresses {
-			if len(nodeAddr) > 0 && strings.Contains(line, nodeAddr) {
-				isCurrentNode = true
+		// a load column may contain spaces ("180.47 GB"), so the host ID is found by its format
+		for _, part := range parts[2:] {
+			if hostIdRegexp.MatchString(part) {
+				status.HostId = part
+				break
 			}
 		}
 
-		if !isCurrentNode {
+		statuses = append(statuses, status)
+	}
+
+	return statuses
+}
+
+// a regexp to match a node status in `nodetool status` output: Up/Down + Normal/Leaving/Joining/Moving
+var nodeStatusRegexp = regexp.MustCompile(`^[UD][NLJM]$`)
+
+// a regexp to match a host ID (uuid) in `nodetool status` output
+var hostIdRegexp = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+// Drain executes `nodetool drain`: flushes memtables and stops accepting connections,
+// so that a node can be safely restarted
+func (c *Client) Drain(ctx context.Context, node *entity.Node) error {
+	output, err := node.Cmd.Execute(cmd.WithCategory(ctx, cmd.CategoryNodetool), cmd.Command(
+		node.Info.Binaries.Nodetool,
+		"drain",
+	))
+	if err != nil {
+		return errors.Wrapf(err, "could not execute `nodetool drain` on %s. output:\n%s", node.Info.Host, string(output))
+	}
+
+	return nil
+}
+
+// SchemaVersions executes `nodetool describecluster` and returns the schema versions with their nodes.
+// The cluster is in schema agreement when there's only one version.
+// Unreachable nodes are listed under "UNREACHABLE" key.
+func (c *Client) SchemaVersions(ctx context.Context, node *entity.Node) (map[string][]string, error) {
+	output, err := node.Cmd.Execute(cmd.ReadOnly(cmd.WithCategory(ctx, cmd.CategoryNodetool)), cmd.Command(
+		node.Info.Binaries.Nodetool,
+		"describecluster",
+	))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not execute `nodetool describecluster`")
+	}
+
+	versions := parseSchemaVersions(string(output))
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("could not parse schema versions from `nodetool describecluster` output:\n%s", output)
+	}
+
+	return versions, nil
+}
+
+// a regexp to parse a schema version line from `nodetool describecluster`, e.g.
+// 497cda5f-fdb2-3b57-8b68-611d4c6200f5: [172.20.0.3, 172.20.0.4]
+var schemaVersionRegexp = regexp.MustCompile(`^\s*([\w-]+): \[(.*)\]\s*$`)
+
+// Parses schema versions from `nodetool describecluster` output
+func parseSchemaVersions(output string) map[string][]string {
+	versions := map[string][]string{}
+	versionsFound := false
+
+	for _, line := range strings.Split(output, "\n") {
+		if strings.Contains(line, "Schema versions:") {
+			versionsFound = true
+			continue
+		}
+
+		if !versionsFound {
 			continue
 		}
 
-		status = strings.Split(line, " ")[0]
-		break
+		matches := schemaVersionRegexp.FindStringSubmatch(line)
+		if len(matches) < 3 {
+			continue
+		}
+
+		addresses := []string{}
+		for _, address := range strings.Split(matches[2], ",") {
+			if address = strings.TrimSpace(address); len(address) > 0 {
+				addresses = append(addresses, address)
+			}
+		}
+
+		versions[matches[1]] = addresses
 	}
 
-	return status, datacenter
+	return versions
 }
 
 // a regexp to retrieve a cluster name from `nodetool describecluster` command
@@ -108,7 +211,7 @@ func (c *Client) getClusterName(
 	ctx context.Context,
 	node *entity.Node,
 ) (string, error) {
-	output, err := node.Cmd.Execute(ctx, cmd.Command(
+	output, err := node.Cmd.Execute(cmd.ReadOnly(cmd.WithCategory(ctx, cmd.CategoryNodetool)), cmd.Command(
 		node.Info.Binaries.Nodetool,
 		"describecluster",
 	))
@@ -37,7 +37,7 @@ UN  172.20.1.4  186.78 GB  256          ?       56e0d871-91ea-4d7f-92e9-58fa99da
 		credentials: entity.Credentials{},
 		logger:      zap.S(),
 	}
-	status, dc, err := client.getNodeStatus(context.Background(), entity.NewNode(
+	status, err := client.getNodeStatus(context.Background(), entity.NewNode(
 		entity.NodeInfo{
 			IpAddress: "172.20.1.2",
 		},
@@ -45,8 +45,10 @@ UN  172.20.1.4  186.78 GB  256          ?       56e0d871-91ea-4d7f-92e9-58fa99da
 		nil,
 	))
 	require.NoError(t, err)
-	require.Equal(t, entity.NodeStatusOk, status)
-	require.Equal(t, "DC2", dc)
+	require.Equal(t, entity.NodeStatusOk, status.Status)
+	require.Equal(t, "DC2", status.Datacenter)
+	require.Equal(t, "R1", status.Rack)
+	require.Equal(t, "485f0ef9-9269-427a-b974-926175017bd5", status.HostId)
 }
 
 func TestClient_NodeStatus_Error(t *testing.T) {
@@ -66,7 +68,7 @@ UN  172.20.0.3  205.89 KB  256          ?       ba29ac08-b405-4d26-8a8b-2b8c18c9
 		credentials: entity.Credentials{},
 		logger:      zap.S(),
 	}
-	status, dc, err := client.getNodeStatus(context.Background(), entity.NewNode(
+	status, err := client.getNodeStatus(context.Background(), entity.NewNode(
 		entity.NodeInfo{
 			IpAddress: "172.20.0.2",
 		},
@@ -74,8 +76,9 @@ UN  172.20.0.3  205.89 KB  256          ?       ba29ac08-b405-4d26-8a8b-2b8c18c9
 		nil,
 	))
 	require.NoError(t, err)
-	require.Equal(t, "DC1", dc)
-	require.Equal(t, "DN", status, "node status must be DN (down, normal)")
+	require.Equal(t, "DC1", status.Datacenter)
+	require.Equal(t, "Rack1", status.Rack)
+	require.Equal(t, "DN", status.Status, "node status must be DN (down, normal)")
 }
 
 func TestClient_ClusterName_Ok(t *testing.T) {
@@ -105,3 +108,72 @@ func TestClient_ClusterName_Error(t *testing.T) {
 	_, err := client.getClusterName(context.Background(), entity.NewNode(entity.NodeInfo{}, cmdExecutor, nil))
 	require.Error(t, err)
 }
+
+func TestClient_ClusterStatus(t *testing.T) {
+	cmdExecutor := &test.Executor{
+		Output: `
+Datacenter: DC1
+=================
+Status=Up/Down
+|/ State=Normal/Leaving/Joining/Moving
+--  Address      Load       Tokens       Owns    Host ID                               Rack
+UN  172.20.0.1  180.47 GB  256          ?       a36d1408-f32b-469f-b22e-df314becc200  R1
+DN  172.20.0.2  169.46 GB  256          ?       985284bd-6373-480d-9780-05cbf82954de  R1
+Datacenter: DC2
+=================
+Status=Up/Down
+|/ State=Normal/Leaving/Joining/Moving
+--  Address      Load       Tokens       Owns    Host ID                               Rack
+UJ  172.20.1.1  175.68 GB  256          ?       aa355bca-2a86-4530-8671-564342d1eee2  R1
+`,
+	}
+	client := Client{logger: zap.S()}
+	statuses, err := client.ClusterStatus(context.Background(), entity.NewNode(entity.NodeInfo{}, cmdExecutor, nil))
+	require.NoError(t, err)
+	require.Equal(t, []entity.NodeStatus{
+		{
+			Address:    "172.20.0.1",
+			Status:     "UN",
+			Datacenter: "DC1",
+			Rack:       "R1",
+			HostId:     "a36d1408-f32b-469f-b22e-df314becc200",
+		},
+		{
+			Address:    "172.20.0.2",
+			Status:     "DN",
+			Datacenter: "DC1",
+			Rack:       "R1",
+			HostId:     "985284bd-6373-480d-9780-05cbf82954de",
+		},
+		{
+			Address:    "172.20.1.1",
+			Status:     "UJ",
+			Datacenter: "DC2",
+			Rack:       "R1",
+			HostId:     "aa355bca-2a86-4530-8671-564342d1eee2",
+		},
+	}, statuses)
+}
+
+func TestClient_SchemaVersions(t *testing.T) {
+	cmdExecutor := &test.Executor{
+		Output: `Using /etc/scylla/scylla.yaml as the config file
+Cluster Information:
+        Name: Test Cluster
+        Snitch: org.apache.cassandra.locator.GossipingPropertyFileSnitch
+        DynamicEndPointSnitch: disabled
+        Partitioner: org.apache.cassandra.dht.Murmur3Partitioner
+        Schema versions:
+                497cda5f-fdb2-3b57-8b68-611d4c6200f5: [172.20.0.3, 172.20.0.4]
+
+                UNREACHABLE: [172.20.0.2]`,
+	}
+	client := Client{}
+
+	versions, err := client.SchemaVersions(context.Background(), entity.NewNode(entity.NodeInfo{}, cmdExecutor, nil))
+	require.NoError(t, err)
+	require.Equal(t, map[string][]string{
+		"497cda5f-fdb2-3b57-8b68-611d4c6200f5": {"172.20.0.3", "172.20.0.4"},
+		"UNREACHABLE":                          {"172.20.0.2"},
+	}, versions)
+}
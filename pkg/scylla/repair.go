@@ -2,24 +2,110 @@ package scylla
 
 import (
 	"context"
+	"fmt"
+	"github.com/hashicorp/go-multierror"
 	"github.com/pkg/errors"
 	"github.com/kolesa-team/scylla-octopus/pkg/cmd"
 	"github.com/kolesa-team/scylla-octopus/pkg/entity"
+	"strings"
 	"time"
 )
 
-// Repair executes `nodetool repair --partitioner-range`
+// Repair executes `nodetool repair --partitioner-range` for every replicated table, one by one.
+// Repairing tables separately allows to track when each table was last repaired.
+// An error in one table does not stop the repair of the others.
 // see https://docs.scylladb.com/operating-scylla/nodetool-commands/repair/
 func (c *Client) Repair(ctx context.Context, node *entity.Node) (entity.RepairResult, error) {
 	logCtx := c.logger.With("host", node.Info.Host)
 	timeStart := time.Now()
+	result := entity.RepairResult{
+		Tables: []entity.TableRepair{},
+	}
+
+	tables, err := c.ListTables(ctx, node)
+	if err != nil {
+		return result, err
+	}
+
+	var repairErr *multierror.Error
+	outputs := []string{}
+
+	for _, table := range tables {
+		if table.IsLocal() {
+			continue
+		}
+
+		tableRepair, output, err := c.repairTable(ctx, node, table)
+		result.Tables = append(result.Tables, tableRepair)
+		outputs = append(outputs, output)
+
+		if err != nil {
+			repairErr = multierror.Append(repairErr, err)
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	result.Output = strings.Join(outputs, "\n")
+	result.Duration = time.Now().Sub(timeStart)
+
+	if repairErr != nil {
+		return result, repairErr.ErrorOrNil()
+	}
+
+	logCtx.Infow("repair completed", "duration", result.Duration, "tables", len(result.Tables))
+
+	return result, nil
+}
+
+// ListTables returns all tables in the database schema with their gc_grace_seconds
+func (c *Client) ListTables(ctx context.Context, node *entity.Node) ([]entity.Table, error) {
+	cqlshCmd := c.cqlshCmd(node.Info)
+	cqlshCmd.Args = append(
+		cqlshCmd.Args,
+		"-e",
+		"SELECT keyspace_name, table_name, gc_grace_seconds FROM system_schema.tables",
+	)
+
+	output, err := node.Cmd.Execute(cmd.ReadOnly(cmd.WithCategory(ctx, cmd.CategoryCqlsh)), cqlshCmd)
+	if err != nil {
+		return nil, errors.Wrapf(
+			err,
+			"could not list tables. output:\n%s",
+			string(output),
+		)
+	}
+
+	return entity.ParseTables(string(output)), nil
+}
+
+// executes `nodetool repair --partitioner-range` for a single table
+func (c *Client) repairTable(
+	ctx context.Context,
+	node *entity.Node,
+	table entity.Table,
+) (entity.TableRepair, string, error) {
+	logCtx := c.logger.With("host", node.Info.Host, "table", table.FullName())
+	result := entity.TableRepair{
+		Host:        node.Info.Host,
+		Keyspace:    table.Keyspace,
+		Table:       table.Name,
+		DateStarted: time.Now(),
+		Status:      entity.RepairStatusOk,
+		Ranges:      entity.RepairRangesPrimary,
+	}
+
 	command := cmd.Command(
 		node.Info.Binaries.Nodetool,
 		"repair",
 		"--partitioner-range",
+		table.Keyspace,
+		table.Name,
 	)
 	output, err := node.Cmd.Execute(ctx, command)
-	duration := time.Now().Sub(timeStart)
+	result.DateFinished = time.Now()
 
 	if err != nil {
 		logCtx.Errorw(
@@ -31,17 +117,20 @@ func (c *Client) Repair(ctx context.Context, node *entity.Node) (entity.RepairRe
 		)
 		err = errors.Wrapf(
 			err,
-			"could not execute nodetool repair on %s. output: %s",
+			"could not execute nodetool repair of %s on %s. output: %s",
+			table.FullName(),
 			node.Info.Host,
 			string(output),
 		)
+		result.Status = entity.RepairStatusFailed
+		result.Error = err.Error()
 	} else {
-		logCtx.Infow("repair completed", "duration", duration)
-		logCtx.Debugw("repair output", "output", string(output))
+		logCtx.Debugw(
+			"table repaired",
+			"duration", result.DateFinished.Sub(result.DateStarted),
+			"output", string(output),
+		)
 	}
 
-	return entity.RepairResult{
-		Output:   string(output),
-		Duration: duration,
-	}, err
+	return result, fmt.Sprintf("%s:\n%s", table.FullName(), string(output)), err
 }
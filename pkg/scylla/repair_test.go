@@ -0,0 +1,70 @@
+package scylla
+
+import (
+	"context"
+	"errors"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd/shell"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd/test"
+	"github.com/kolesa-team/scylla-octopus/pkg/entity"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"os/exec"
+	"testing"
+)
+
+func TestClient_Repair(t *testing.T) {
+	executedCommands := []string{}
+	cmdExecutor := &test.Executor{
+		Func: func(cmd *exec.Cmd, executedCount int) (string, error) {
+			executedCommands = append(executedCommands, shell.Line(cmd))
+
+			switch executedCount {
+			case 0:
+				// a list of tables from cqlsh
+				return `
+ keyspace_name | table_name | gc_grace_seconds
+---------------+------------+------------------
+        system |      local |                0
+          test |      users |           864000
+          test |     orders |           864000
+
+(3 rows)
+`, nil
+			case 2:
+				// a repair of the 2nd table fails
+				return "repair failed", errors.New("exit status 1")
+			default:
+				return "", nil
+			}
+		},
+	}
+	client := Client{logger: zap.S()}
+	node := entity.NewNode(entity.NodeInfo{
+		Host: "scylla.test",
+		Binaries: entity.NodeBinaries{
+			Cqlsh:    "cqlsh",
+			Nodetool: "nodetool",
+		},
+	}, cmdExecutor, nil)
+
+	result, err := client.Repair(context.Background(), node)
+	require.Error(t, err, "an error in one table must be reported")
+	require.Equal(
+		t,
+		[]string{
+			`cqlsh scylla.test -e 'SELECT keyspace_name, table_name, gc_grace_seconds FROM system_schema.tables'`,
+			"nodetool repair --partitioner-range test users",
+			"nodetool repair --partitioner-range test orders",
+		},
+		executedCommands,
+		"local system tables must not be repaired",
+	)
+
+	require.Len(t, result.Tables, 2, "every table must have a repair result, including the failed one")
+	require.Equal(t, "users", result.Tables[0].Table)
+	require.Equal(t, entity.RepairStatusOk, result.Tables[0].Status)
+	require.Equal(t, entity.RepairRangesPrimary, result.Tables[0].Ranges)
+	require.Equal(t, "orders", result.Tables[1].Table)
+	require.Equal(t, entity.RepairStatusFailed, result.Tables[1].Status)
+	require.Contains(t, result.Tables[1].Error, "repair failed")
+}
@@ -2,7 +2,6 @@ package scylla
 
 import (
 	"context"
-	"fmt"
 	"github.com/pkg/errors"
 	"github.com/kolesa-team/scylla-octopus/pkg/cmd"
 	"github.com/kolesa-team/scylla-octopus/pkg/entity"
@@ -66,15 +65,12 @@ func (c *Client) RemoveSnapshot(ctx context.Context, node *entity.Node, tag stri
 // MoveSnapshot moves a snapshots from scylla directory to another temporary directory.
 // TODO replace cp with mv to avoid unnecessary disk space usage
 func (c *Client) moveSnapshot(ctx context.Context, node *entity.Node, tag string, targetPath string) error {
-	output, err := node.Cmd.Execute(ctx, cmd.Command(
-		"sh",
-		"-c",
-		fmt.Sprintf(
-			`'cd %s && find . -type d | grep -i snapshots/%s | xargs -i cp --parents -r {} %s'`,
-			node.Info.DataPath,
-			tag,
-			targetPath,
-		),
+	// paths are passed by find itself, so that they may contain spaces
+	output, err := node.Cmd.Execute(ctx, cmd.Shellf(
+		"cd %s && find . -type d -ipath %s -exec cp --parents -r -t %s {} +",
+		node.Info.DataPath,
+		"*/snapshots/"+tag,
+		targetPath,
 	))
 	if err != nil {
 		c.logger.Errorw(
@@ -125,7 +121,7 @@ func (c *Client) snapshotExists(ctx context.Context, node *entity.Node, tag stri
 }
 
 func (c *Client) runClearSnapshotCmd(ctx context.Context, node *entity.Node, tag string) ([]byte, error) {
-	return node.Cmd.Execute(ctx, cmd.Command(
+	return node.Cmd.Execute(cmd.WithCategory(ctx, cmd.CategoryNodetool), cmd.Command(
 		node.Info.Binaries.Nodetool,
 		"clearsnapshot",
 		"-t",
@@ -145,11 +141,11 @@ func (c *Client) runSnapshotCmd(ctx context.Context, node *entity.Node, tag stri
 		command.Args = append(command.Args, keyspaces...)
 	}
 
-	return node.Cmd.Execute(ctx, command)
+	return node.Cmd.Execute(cmd.WithCategory(ctx, cmd.CategoryNodetool), command)
 }
 
 func (c *Client) listSnapshots(ctx context.Context, node *entity.Node) (string, error) {
-	output, err := node.Cmd.Execute(ctx, cmd.Command(
+	output, err := node.Cmd.Execute(cmd.ReadOnly(cmd.WithCategory(ctx, cmd.CategoryNodetool)), cmd.Command(
 		node.Info.Binaries.Nodetool,
 		"listsnapshots",
 	))
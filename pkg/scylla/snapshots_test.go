@@ -0,0 +1,40 @@
+package scylla
+
+import (
+	"context"
+	"github.com/kolesa-team/scylla-octopus/pkg/cmd/local"
+	"github.com/kolesa-team/scylla-octopus/pkg/entity"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// A snapshot is copied with its parent directories, even if the paths contain spaces
+func TestClient_MoveSnapshot(t *testing.T) {
+	dataPath := filepath.Join(t.TempDir(), "scylla data")
+	targetPath := filepath.Join(t.TempDir(), "backup dir")
+	require.NoError(t, os.MkdirAll(targetPath, 0755))
+
+	snapshotPath := filepath.Join(dataPath, "shop", "users table", "snapshots", "tag")
+	otherSnapshotPath := filepath.Join(dataPath, "shop", "users table", "snapshots", "tag2")
+	for _, path := range []string{snapshotPath + "/nested", otherSnapshotPath} {
+		require.NoError(t, os.MkdirAll(path, 0755))
+	}
+
+	require.NoError(t, os.WriteFile(filepath.Join(snapshotPath, "md-1-big-Data.db"), []byte("data"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(snapshotPath, "nested", "file"), []byte("nested"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(otherSnapshotPath, "md-2-big-Data.db"), []byte("other"), 0644))
+
+	client := Client{logger: zap.S()}
+	node := entity.NewNode(entity.NodeInfo{DataPath: dataPath}, local.Executor{}, nil)
+
+	err := client.moveSnapshot(context.Background(), node, "tag", targetPath)
+	require.NoError(t, err)
+
+	copiedPath := filepath.Join(targetPath, "shop", "users table", "snapshots", "tag")
+	require.FileExists(t, filepath.Join(copiedPath, "md-1-big-Data.db"))
+	require.FileExists(t, filepath.Join(copiedPath, "nested", "file"))
+	require.NoDirExists(t, filepath.Join(targetPath, "shop", "users table", "snapshots", "tag2"))
+}